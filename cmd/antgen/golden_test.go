@@ -0,0 +1,160 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// Golden-file regression tests for a full optimization run. The fast
+// test computes the antenna performance with the analytic dipole model
+// (EvalIdealDipole) instead of NEC2, so the results are reproducible on
+// every machine:
+//
+//	go test ./cmd/antgen
+//
+// The NEC2-backed test (golden_nec_test.go) requires a working NEC2
+// engine and is only built with the 'golden' tag:
+//
+//	go test -tags golden ./cmd/antgen
+//
+// Run with '-update' to (re-)write the golden files after an intended
+// change of the optimization results.
+
+var update = flag.Bool("update", false, "update golden files")
+
+// golden result of an optimization run
+type golden struct {
+	Gmax  float64 `json:"gmax"`  // max. gain (dBi)
+	Zr    float64 `json:"zr"`    // impedance (real part)
+	Zi    float64 `json:"zi"`    // impedance (imaginary part)
+	Track int     `json:"track"` // number of track entries
+}
+
+func TestGoldenBend2D(t *testing.T) {
+	// deterministic evaluation (no NEC2 engine)
+	defer func(fn lib.EvalFunc) { lib.DefaultEval = fn }(lib.DefaultEval)
+	lib.DefaultEval = lib.EvalIdealDipole
+
+	checkGolden(t, "golden-bend2d.json")
+}
+
+// checkGolden runs a bend2d optimization (seed 1000, default specification)
+// with the current evaluator and compares the result with a golden file
+// in testdata/.
+func checkGolden(t *testing.T, name string) {
+	const (
+		seed = 1000
+		iter = 200
+	)
+	fname := filepath.Join("testdata", name)
+
+	// known specification (default parameters)
+	spec := new(lib.Specification)
+	spec.K = lib.Cfg.Def.K
+	var err error
+	if spec.Wire, err = lib.ParseWire("", false); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Source, err = lib.ParseSource("", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Feedpt, err = lib.ParseFeedpt("", false); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Ground, err = lib.ParseGround("", false); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := lib.GetGenerator("stroll", spec.Source.Lambda())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdl, _, err := GetModel("bend2d", spec, gen, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp, err := lib.NewComparator("Gmax", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// run optimization
+	cb := func(*lib.Antenna, int, string) {}
+	if _, err = mdl.Prepare(seed, cb); err != nil {
+		t.Fatal(err)
+	}
+	ant, _, err := mdl.Optimize(context.Background(), seed, iter, cmp, cb)
+	if err != nil && !errors.Is(err, lib.ErrNoImprovement) {
+		t.Fatal(err)
+	}
+	res := golden{
+		Gmax:  ant.Perf.Gain.Max,
+		Zr:    real(ant.Perf.Z),
+		Zi:    imag(ant.Perf.Z),
+		Track: len(mdl.(*ModelBend2D).Track),
+	}
+
+	// write golden file on request
+	if *update {
+		data, err := json.MarshalIndent(res, "", "    ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(fname, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// compare with golden result
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("%s (run with -update to create golden file)", err)
+	}
+	var want golden
+	if err = json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	near := func(a, b, tol float64) bool {
+		return math.Abs(a-b) <= tol*math.Max(1, math.Abs(b))
+	}
+	if !near(res.Gmax, want.Gmax, 1e-4) {
+		t.Errorf("Gmax: got %g, want %g", res.Gmax, want.Gmax)
+	}
+	if !near(res.Zr, want.Zr, 1e-4) || !near(res.Zi, want.Zi, 1e-4) {
+		t.Errorf("Z: got %g%+gj, want %g%+gj", res.Zr, res.Zi, want.Zr, want.Zi)
+	}
+	if res.Track != want.Track {
+		t.Errorf("Track: got %d entries, want %d", res.Track, want.Track)
+	}
+}
@@ -21,13 +21,22 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/bfix/antgen/lib"
 )
@@ -78,24 +87,45 @@ func main() {
 		groundS string // ground specification
 		sourceS string // source parameters (without frequency)
 		feedptS string // feedpoint parameters
+		keepout string // keep-out regions (JSON file)
+		elems   string // parasitic elements (JSON file)
 
 		param float64 // free parameter
-		seed  int64   // seed for deterministic randomization
+		sweep string  // sweep over k and param
+		seed  int64   // seed for deterministic randomization (optimizer)
+		gseed int64   // seed for generator randomization
 		gen   string  // generator model to use
 
-		model  string // optimization model to use (incl. parameters)
-		target string // optimize for target [Gmax, GMean, SD, none]
-		iter   int    // number of iterations; 0=no limit
-		vis    bool   // visualize optimizations
-		logr   bool   // log iteration results
-		warn   bool   // emit warnings
+		model   string  // optimization model to use (incl. parameters)
+		target  string  // optimize for target [Gmax, GMean, SD, none]
+		penalty float64 // weight of curvature penalty
+		iter    int     // number of iterations; 0=no limit
+		vis     bool    // visualize optimizations
+		logr    bool    // log iteration results
+		logFmt  string  // format of step log [text,json]
+		warn    bool    // emit warnings
+		eff     bool    // compute radiation efficiency
+		stage2  bool    // two-stage evaluation
+		estim   bool    // estimate problem size only
+		pattern string  // radiation pattern resolution
+		matchQ  float64 // loaded Q of Pi/T matching networks
+		antQ    bool    // estimate antenna Q
+		rp      bool    // store radiation pattern in geometry file
+		robustS string  // perturbation for robustness check
+		base    bool    // evaluate straight baseline dipole
+		expose  float64 // field strength limit for exposure distance
+		coaxS   string  // feedline (coax type and length)
 
 		tag     string // tag for output filename
 		outDir  string // directory for optimization output
 		outPrf  string // filename prefix
+		layout  string // output directory layout
+		fileFmt string // format of geometry/track files
+		cross   string // resolution of wire crossings
+		engine  string // simulation engine
+		cache   int    // number of cached simulations
 		verbose int    // verbose output
 
-		ant *lib.Antenna
 		err error
 	)
 	flag.StringVar(&config, "config", "", "configuration file")
@@ -105,25 +135,62 @@ func main() {
 	flag.StringVar(&groundS, "ground", "", "antenna height")
 	flag.StringVar(&sourceS, "source", "", "feed parameters")
 	flag.StringVar(&feedptS, "feedpt", "", "feed point")
+	flag.StringVar(&keepout, "keepout", "", "keep-out regions (JSON file)")
+	flag.StringVar(&elems, "elements", "", "parasitic elements (JSON file)")
 
 	flag.StringVar(&gen, "gen", "stroll", "generator for initial geometry")
 
 	flag.StringVar(&model, "model", "bend2d", "model selection")
 	flag.StringVar(&target, "opt", "Gmax", "optimization target (default: Gmax)")
+	flag.Float64Var(&penalty, "smoothpenalty", 0, "curvature penalty weight (per radian)")
 
 	flag.Int64Var(&seed, "seed", 1000, "model seed")
+	flag.Int64Var(&gseed, "genseed", 0, "generator seed (default: same as seed)")
 	flag.IntVar(&iter, "iter", 0, "optimization iterations")
 
 	flag.Float64Var(&param, "param", math.NaN(), "free parameter")
+	flag.StringVar(&sweep, "sweep", "", "sweep k/param (k=<from>:<to>:<step>,param=...)")
 	flag.StringVar(&tag, "tag", "", "output name tag")
 	flag.StringVar(&outDir, "out", "./out", "output directory")
 	flag.StringVar(&outPrf, "prefix", "", "output prefix")
+	flag.StringVar(&layout, "layout", "", "output subdirectories (e.g. {band}/{model})")
+	flag.StringVar(&cross, "crossings", "", "wire crossings [bridge,jumper]")
+	flag.StringVar(&fileFmt, "filefmt", "", "format of geometry/track files [json,gz,bin]")
+	flag.StringVar(&engine, "engine", "", "simulation engine [necpp,nec2c,...]")
+	flag.IntVar(&cache, "cache", 0, "number of cached simulations (0: config)")
 
 	flag.IntVar(&verbose, "verbose", 1, "verbosity")
 	flag.BoolVar(&vis, "vis", false, "visualize iterations")
 	flag.BoolVar(&logr, "log", false, "log iterations")
+	flag.StringVar(&logFmt, "logfmt", "text", "format of step log [text,json]")
 	flag.BoolVar(&warn, "warn", false, "emit warning")
+	flag.BoolVar(&eff, "eff", false, "compute efficiency (doubles simulations)")
+	flag.BoolVar(&stage2, "twostage", false, "skip pattern/efficiency for rejected candidates")
+	flag.BoolVar(&rp, "rp", false, "store radiation pattern in geometry file")
+	flag.BoolVar(&estim, "estimate", false, "report problem size and exit")
+	flag.BoolVar(&antQ, "q", false, "estimate antenna Q and bandwidth (two extra simulations)")
+	flag.Float64Var(&matchQ, "matchq", 0, "loaded Q of Pi/T matching networks in model file")
+	flag.StringVar(&pattern, "pattern", "", "pattern resolution (theta=<deg>,phi=<deg>,final=<deg>)")
+	flag.BoolVar(&base, "baseline", false, "evaluate straight dipole with same wire length")
+	flag.Float64Var(&expose, "exposure", 0, "field strength limit (V/m) for RF exposure distance")
+	flag.StringVar(&coaxS, "coax", "", "feedline for system gain (<type>:<length>[m], e.g. RG213:30m)")
+	flag.StringVar(&robustS, "robust", "", "robustness check (default or freq=<rel>,wire=<rel>,jitter=<deg>,trials=<n>)")
 	flag.Parse()
+	// generator seed not set: use model seed
+	genSeed := false
+	flag.Visit(func(f *flag.Flag) {
+		genSeed = genSeed || f.Name == "genseed"
+	})
+	if !genSeed {
+		gseed = seed
+	}
+	switch logFmt {
+	case "text":
+	case "json":
+		logr = true
+	default:
+		log.Fatalf("unknown log format '%s'", logFmt)
+	}
 
 	// handle optional configuration file
 	if len(config) > 0 {
@@ -131,25 +198,91 @@ func main() {
 			log.Fatal(err)
 		}
 	}
+	if eff {
+		lib.Cfg.Sim.Efficiency = true
+	}
+	if stage2 {
+		lib.Cfg.Sim.TwoStage = true
+	}
+	if antQ {
+		lib.Cfg.Sim.AntQ = true
+	}
+	if matchQ > 0 {
+		lib.Cfg.Sim.MatchQ = matchQ
+	}
+	if len(fileFmt) > 0 {
+		lib.Cfg.Sim.FileFormat = fileFmt
+	}
+	if _, err = lib.FileExt(lib.Cfg.Sim.FileFormat); err != nil {
+		log.Fatal(err)
+	}
+	if len(cross) > 0 {
+		lib.Cfg.Sim.Crossings = cross
+	}
+	if _, err = lib.ParseFixMode(lib.Cfg.Sim.Crossings); err != nil {
+		log.Fatal(err)
+	}
+	if len(engine) > 0 {
+		lib.Cfg.Sim.Engine = engine
+	}
+	if err = lib.CheckEngine(); err != nil {
+		log.Fatal(err)
+	}
+	if cache > 0 {
+		lib.Cfg.Sim.Cache = cache
+	}
+	simCache, err := lib.GetSimCache()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var finalStep float64
+	if len(pattern) > 0 {
+		if finalStep, err = parsePattern(pattern); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var perturb *lib.Perturbation
+	if len(robustS) > 0 {
+		p, err := lib.ParsePerturbation(robustS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		perturb = &p
+	}
+	var feedline *lib.Feedline
+	if len(coaxS) > 0 {
+		if feedline, err = lib.ParseFeedline(coaxS); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// handle wire parameters
 	if spec.Wire, err = lib.ParseWire(wireS, warn); err != nil {
 		log.Fatal(err)
 	}
 
-	// handle source parameters
-	if spec.Source, err = lib.ParseSource(sourceS, warn); err != nil {
+	// handle source parameters (at specified source frequency)
+	var freq, span int64
+	if len(freqS) > 0 {
+		if freq, span, err = lib.GetFrequencyRange(freqS); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if spec.Source, err = lib.ParseSource(sourceS, freq, warn); err != nil {
 		log.Fatal(err)
 	}
+	if freq > 0 {
+		spec.Source.Freq, spec.Source.Span = freq, span
+	}
 
 	// handle feed point parameters
 	if spec.Feedpt, err = lib.ParseFeedpt(feedptS, warn); err != nil {
 		log.Fatal(err)
 	}
 
-	// change specified source frequency (range)
-	if len(freqS) > 0 {
-		if spec.Source.Freq, spec.Source.Span, err = lib.GetFrequencyRange(freqS); err != nil {
+	// handle parasitic elements
+	if len(elems) > 0 {
+		if spec.Elements, err = lib.ReadElements(elems); err != nil {
 			log.Fatal(err)
 		}
 	}
@@ -159,108 +292,369 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// get generator model
-	g, err := lib.GetGenerator(gen, spec.Source.Lambda())
-	if err != nil {
-		log.Fatal(err)
+	// assemble grid of (k,param) points; a single point if no sweep
+	// is requested.
+	ks, params := []float64{spec.K}, []float64{param}
+	if len(sweep) > 0 {
+		if ks, params, err = parseSweep(sweep, ks, params); err != nil {
+			log.Fatal(err)
+		}
+	}
+	sweeping := len(ks)*len(params) > 1
+
+	// the free parameter reaches the generator by the placeholder '{param}'
+	// in the generator spec; without it a sweep over 'param' would run the
+	// same optimization for all values.
+	paramGen := strings.Contains(gen, "{param}")
+	if len(params) > 1 && !paramGen {
+		log.Fatal("sweep over 'param' requires '{param}' in the generator spec")
+	}
+	if paramGen && slices.ContainsFunc(params, math.IsNaN) {
+		log.Fatal("generator spec with '{param}' requires a value for 'param'")
+	}
+	genSpec := func(p float64) string {
+		return strings.ReplaceAll(gen, "{param}", strconv.FormatFloat(p, 'g', -1, 64))
 	}
 
-	// get optimization model
-	mdl, side, err := GetModel(model, spec, g, verbose)
-	if err != nil {
-		log.Fatal(err)
+	// handle keep-out regions
+	var keepOuts []*lib.KeepOut
+	if len(keepout) > 0 {
+		if keepOuts, err = lib.ReadKeepOuts(keepout); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	// setup comparator
-	var cmp *lib.Comparator
-	if cmp, err = lib.NewComparator(target, spec); err != nil {
+	// get optimization models: each grid point gets its own generator,
+	// model (the segmentation depends on 'k') and specification.
+	type point struct {
+		spec  *lib.Specification
+		param float64
+		g     lib.Generator
+		mdl   lib.Model
+	}
+	var (
+		points []*point
+		side   float64
+	)
+	for _, k := range ks {
+		for _, p := range params {
+			pt := &point{spec: new(lib.Specification), param: p}
+			*pt.spec = *spec
+			pt.spec.K = k
+			if pt.g, err = lib.GetGenerator(genSpec(p), spec.Source.Lambda()); err != nil {
+				log.Fatal(err)
+			}
+			var s float64
+			if pt.mdl, s, err = GetModel(model, pt.spec, pt.g, verbose); err != nil {
+				log.Fatal(err)
+			}
+			side = max(side, s)
+			if w, ok := pt.mdl.(lib.Warner); ok && warn {
+				for _, msg := range w.Warnings() {
+					log.Printf("WARN: k=%g: %s", k, msg)
+				}
+			}
+			if len(keepOuts) > 0 {
+				ko, ok := pt.mdl.(lib.KeepOutAware)
+				if !ok {
+					log.Fatalf("model '%s' doesn't support keep-out regions", model)
+				}
+				ko.SetKeepOuts(keepOuts)
+			}
+			points = append(points, pt)
+		}
+	}
+
+	// report problem size only (dry-run)
+	if estim {
+		for _, pt := range points {
+			estimate(pt.mdl, pt.spec.K, target, iter, gseed)
+		}
+		return
+	}
+
+	// handle output prefix and base tag
+	if len(outPrf) > 0 && !strings.HasSuffix(outPrf, "_") {
+		outPrf += "_"
+	}
+	if len(tag) == 0 {
+		tag = fmt.Sprintf("%d", seed)
+	}
+	// handle output directory layout (model set directories)
+	if len(layout) > 0 {
+		outDir = filepath.Join(outDir, lib.OutputLayout(layout, spec.Source.Freq, model, gen, target, spec.Wire.Material))
+	}
+	if err = os.MkdirAll(outDir, 0o755); err != nil {
 		log.Fatal(err)
 	}
 
-	// run optimization in goroutine to allow rendering
-	var steps []string
-	var step int
-	var iniPerf *lib.Performance
-	optimize := func(render lib.Canvas) (total lib.Stats) {
+	// stop optimization on SIGINT/SIGTERM: the best geometry so far is
+	// written to the output files. A second signal terminates immediately.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		log.Printf("Stopping optimization (on signal '%s')...", sig)
+		signal.Stop(sigCh)
+		cancel()
+	}()
+
+	// optimize a model for a single grid point and write the output files
+	run := func(pt *point, tag string, render lib.Canvas) (total lib.Stats, ok bool) {
+		// setup comparator
+		cmp, err := lib.NewComparator(target, pt.spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cmp.SetPenalty(penalty)
+		// simulation cache counters (hit rate of this run)
+		var lookups, hits int
+		if simCache != nil {
+			lookups, hits = simCache.Counters()
+		}
 		// callback for opt iteration
+		var steps []string
+		step := 0
 		cb := func(ant *lib.Antenna, pos int, msg string) {
 			if render != nil {
 				render.Show(ant, pos, msg)
 			}
 			step++
 			if logr {
+				if logFmt == "json" {
+					// only accepted changes of the geometry
+					if pos != lib.TRK_MARK {
+						steps = append(steps, stepRecord(step, ant, pos, pt.mdl))
+					}
+					return
+				}
 				msg := fmt.Sprintf("[%5d] %s", step, ant.Perf.String())
 				steps = append(steps, msg)
 			}
 		}
 		// prepare initial geometry
-		if ant, err = mdl.Prepare(seed, cb); err != nil {
-			log.Printf("Model #%d: %s", seed, err.Error())
+		var ant *lib.Antenna
+		if ant, err = pt.mdl.Prepare(gseed, cb); err != nil {
+			log.Printf("Model #%s: %s", tag, err.Error())
 			return
 		}
-		iniPerf = ant.Perf
+		iniPerf := ant.Perf
 
 		// check for optimization
 		if target != "none" {
 			// optimize antenna (multiple optimizers in sequence possible)
 			var stats lib.Stats
 			for {
-				if ant, stats, err = mdl.Optimize(seed, iter, cmp, cb); err != nil {
-					log.Printf("Model #%d: %s", seed, err.Error())
-					return
+				// an interrupted optimization still returns the best
+				// geometry so far (written to the output files)
+				ant, stats, err = pt.mdl.Optimize(ctx, seed, iter, cmp, cb)
+				if errors.Is(err, lib.ErrNoImprovement) {
+					// not fatal: continue with next optimizer (if any)
+					log.Printf("Model #%s: WARN: no improvement (%s)", tag, cmp.Target())
+					err = nil
 				}
-				total.Elapsed += stats.Elapsed
-				total.NumMthds += stats.NumMthds
-				total.NumSteps += stats.NumSteps
-				total.NumSims += stats.NumSims
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						log.Printf("Model #%s: %s", tag, err.Error())
+						return
+					}
+					log.Printf("Model #%s: optimization interrupted", tag)
+				}
+				total.Add(stats)
 
 				// switch to next optimizer
-				if !cmp.Next() {
+				if err != nil || !cmp.Next() {
 					break
 				}
 			}
 		}
+		// re-evaluate final geometry with a finer pattern resolution
+		// (used for the output files)
+		if finalStep > 0 {
+			thetaStep, phiStep := lib.Cfg.Sim.ThetaStep, lib.Cfg.Sim.PhiStep
+			lib.Cfg.Sim.ThetaStep, lib.Cfg.Sim.PhiStep = finalStep, finalStep
+			defer func() {
+				lib.Cfg.Sim.ThetaStep, lib.Cfg.Sim.PhiStep = thetaStep, phiStep
+			}()
+			if err = ant.Eval(pt.spec.Source.Freq, pt.spec.Wire, pt.spec.Ground); err != nil {
+				log.Printf("Model #%s: %s", tag, err.Error())
+				return
+			}
+			total.NumSims++
+		}
+		// estimate antenna Q and bandwidth
+		if lib.Cfg.Sim.AntQ {
+			delta := int64(lib.Cfg.Sim.QDelta * float64(pt.spec.Source.Freq))
+			if err = ant.EvalQ(pt.spec.Source.Freq, delta, pt.spec.Wire, pt.spec.Ground); err != nil {
+				log.Printf("Model #%s: %s", tag, err.Error())
+				return
+			}
+			total.NumSims += 2
+			log.Printf("Model #%s: Q=%.2f, BW(2:1)=%.3f%%", tag, ant.Perf.Q, 100*ant.Perf.BW)
+		}
+		// evaluate straight dipole with same wire length (not for loops)
+		var (
+			notes    []string
+			basePerf *lib.Performance
+		)
+		s, shaped := pt.mdl.(lib.Shaped)
+		if shaped && base {
+			geo := s.Geometry(nil, nil)
+			if geo.Loop {
+				log.Printf("Model #%s: WARN: no straight baseline for loops", tag)
+			} else {
+				if basePerf, err = lib.EvalBaseline(pt.spec, geo.Nodes); err != nil {
+					log.Printf("Model #%s: %s", tag, err.Error())
+					return
+				}
+				total.NumSims++
+				log.Printf("Model #%s: Baseline: Gmax=%.3fdB, Z=%s Ω, SWR=%.2f", tag, basePerf.Gain.Max,
+					lib.FormatImpedance(basePerf.Z, 4), lib.SWR(basePerf.Z, pt.spec.Source.Impedance()))
+			}
+		}
+		// check robustness of final geometry
+		if shaped && perturb != nil {
+			geo := s.Geometry(nil, nil)
+			rnd := rand.New(rand.NewSource(seed))
+			r, err := lib.EvalRobustness(geo.Kind(), pt.spec, geo.Nodes, *perturb, rnd)
+			if err != nil {
+				log.Printf("Model #%s: %s", tag, err.Error())
+				return
+			}
+			total.NumSims += perturb.Sims()
+			log.Printf("Model #%s: robustness score=%.3f (ΔGmax=%.3fdB, ΔSWR=%.3f)", tag, r.Score, r.DGmax, r.DSWR)
+			notes = r.Info(*perturb)
+		}
+		// check NEC2 segment lengths of final geometry
+		if warn {
+			q := ant.SegmentQuality()
+			log.Printf("Model #%s: %s", tag, q.String())
+			for _, msg := range q.Warnings() {
+				log.Printf("Model #%s: WARN: %s", tag, msg)
+			}
+		}
+		// gain delivered through the feedline
+		if feedline != nil {
+			ant.Perf.Gsys = feedline.SystemGain(ant.Perf.Gain.Max, ant.Perf.Z, pt.spec.Source.Freq)
+			log.Printf("Model #%s: Gsys=%.3fdB (feedline %s, loss=%.3fdB)", tag, ant.Perf.Gsys,
+				feedline, ant.Perf.Gain.Max-ant.Perf.Gsys)
+		}
+		w, h, d := ant.Bounds().Extent()
+		// estimate RF exposure compliance distance (far field)
+		if expose > 0 {
+			size := math.Sqrt(w*w + h*h + d*d)
+			e, err := lib.EvalExposure(pt.spec.Source.Power, ant.Perf.Gain.Max, size, pt.spec.Source.Lambda(), expose)
+			if err != nil {
+				log.Printf("Model #%s: %s", tag, err.Error())
+				return
+			}
+			log.Printf("Model #%s: exposure distance=%.2fm (E<=%gV/m)", tag, e.Dist, expose)
+			notes = append(notes, e.Info()...)
+		}
+		log.Printf("Model #%s: %s, Extent=%.3f×%.3f×%.3fm (%d/%d/%d in %s)\n", tag, ant.Perf.String(),
+			w, h, d, total.NumMthds, total.NumSteps, total.NumSims, total.Elapsed)
+		if simCache != nil {
+			nl, nh := simCache.Counters()
+			total.Lookups, total.Hits = nl-lookups, nh-hits
+			log.Printf("Model #%s: cache hits %d of %d (%.1f%%)", tag, total.Hits, total.Lookups, 100*total.HitRate())
+		}
+		writeResults(pt.mdl, ant, pt.spec, pt.g, iniPerf, pt.param, model, target, seed, gseed,
+			tag, outDir, outPrf, total, rp, steps, logFmt, notes, basePerf)
+		ok = true
 		return
 	}
 
-	// setup rendering (if visualization is requested)
+	// run optimizations for all grid points
 	var total lib.Stats
+	num := 0
+	sweepRun := func(render lib.Canvas) {
+		for _, pt := range points {
+			if ctx.Err() != nil {
+				return
+			}
+			t := tag
+			if sweeping {
+				t = fmt.Sprintf("%s-k%g", tag, pt.spec.K)
+				if !math.IsNaN(pt.param) {
+					t += fmt.Sprintf("-p%g", pt.param)
+				}
+			}
+			if stats, ok := run(pt, t, render); ok {
+				total.Add(stats)
+				num++
+			}
+		}
+	}
+	// setup rendering (if visualization is requested)
 	if vis {
 		var render lib.Canvas
 		if render, err = lib.GetCanvasFromCfg(lib.Cfg.Render, side); err != nil {
 			log.Fatal(err)
 		}
 		defer render.Close()
-		go func() {
-			total = optimize(render)
-		}()
+		if ko, ok := render.(lib.KeepOutAware); ok {
+			ko.SetKeepOuts(keepOuts)
+		}
+		go sweepRun(render)
 		render.Run(nil)
 	} else {
-		total = optimize(nil)
+		sweepRun(nil)
 	}
-	if ant == nil {
+	if num == 0 {
 		log.Fatal("Aborted...")
 	}
-
-	// output optimization results
-	if len(tag) == 0 {
-		tag = fmt.Sprintf("%d", seed)
+	if sweeping {
+		log.Printf("Sweep: %d of %d models optimized (%d/%d/%d in %s)\n",
+			num, len(ks)*len(params), total.NumMthds, total.NumSteps, total.NumSims, total.Elapsed)
+		if simCache != nil {
+			log.Printf("Sweep: cache hits %d of %d (%.1f%%)", total.Hits, total.Lookups, 100*total.HitRate())
+		}
 	}
-	log.Printf("Model #%s: %s (%d/%d/%d in %s)\n", tag, ant.Perf.String(),
-		total.NumMthds, total.NumSteps, total.NumSims, total.Elapsed)
-	if !logr {
-		steps = nil
+}
+
+// write optimization results (model, geometry, track, result summary and
+// optional step log) to the output directory.
+func writeResults(mdl lib.Model, ant *lib.Antenna, spec *lib.Specification, g lib.Generator,
+	iniPerf *lib.Performance, param float64, model, target string, seed, gseed int64,
+	tag, outDir, outPrf string, total lib.Stats, rp bool, steps []string, logFmt string, notes []string,
+	basePerf *lib.Performance) {
+
+	// isotropy of the final radiation pattern, gain in the reference
+	// direction and the peak gains in the azimuth plane (at the takeoff
+	// angle) and in the elevation plane (through the reference azimuth or
+	// the azimuth of the peak at the takeoff angle)
+	if rp := ant.Perf.Rp; rp != nil {
+		ant.Perf.Iso = rp.Spherical()
+		ant.Perf.Ghoriz = rp.GainAt(lib.Cfg.Sim.RefTheta, lib.Cfg.Sim.RefPhi)
+		var phi float64
+		ant.Perf.Gaz, phi = rp.AzimuthCut(lib.Cfg.Sim.Takeoff)
+		if lib.Cfg.Sim.RefPhi >= 0 {
+			phi = lib.Cfg.Sim.RefPhi
+		}
+		ant.Perf.Gel = rp.ElevationCut(phi)
 	}
 
 	// intro and assemble comments
 	var cmts []string
 	cmts = append(cmts, fmt.Sprintf("AntGen %s (%s) - Copyright 2024-present Bernd Fix   >Y<", Version, Date))
-	cmts = append(cmts, lib.GenMdlParams(param, spec, iniPerf, ant.Perf, model, g.Info(), target, seed, tag, total)...)
-
-	// handle output prefix
-	if len(outPrf) > 0 && !strings.HasSuffix(outPrf, "_") {
-		outPrf += "_"
+	run, err := lib.NewRunInfo(os.Args)
+	if err != nil {
+		log.Fatal(err)
 	}
+	cmts = append(cmts, lib.GenMdlParams(param, spec, iniPerf, ant.Perf, basePerf, model, g.Info(), target, seed, gseed, tag, total, run)...)
+	if w, ok := mdl.(lib.Warner); ok {
+		for _, msg := range w.Warnings() {
+			cmts = append(cmts, "WARN: "+msg)
+		}
+	}
+	if target != "none" && total.NumSteps == 0 {
+		log.Printf("Model #%s: WARN: no improvement - result is the initial geometry", tag)
+		cmts = append(cmts, "WARN: no improvement (result is the initial geometry)")
+	}
+	cmts = append(cmts, notes...)
+
 	// write model to file
 	fName := fmt.Sprintf("%s/%smodel-%s.nec", outDir, outPrf, tag)
 	wrt, err := os.Create(fName)
@@ -268,12 +662,42 @@ func main() {
 		log.Fatal(err)
 	}
 	defer wrt.Close()
-	ant.DumpNEC(wrt, spec, cmts)
-	mdl.Finalize(tag, outDir, outPrf, cmts)
+	necCmts := cmts
+	if q := lib.Cfg.Sim.MatchQ; q > 0 {
+		// matching networks for the final impedance
+		necCmts = append(slices.Clone(cmts), lib.MatchInfo(spec.Source.Impedance(),
+			ant.Perf.Z, float64(spec.Source.Freq), q)...)
+	}
+	ant.DumpNEC(wrt, spec, necCmts)
+	var pattern *lib.RadPattern
+	if rp {
+		pattern = ant.Perf.Rp
+	}
+	mdl.Finalize(tag, outDir, outPrf, cmts, pattern)
+
+	// write result summary
+	rec, _, err := lib.ParseMdlParams(cmts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rec.Init, rec.Perf, rec.Base, rec.Run = iniPerf, *ant.Perf, basePerf, run
+	rec.Path = outDir
+	data, err := json.MarshalIndent(rec, "", "    ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fName = fmt.Sprintf("%s/%sresult-%s.json", outDir, outPrf, tag)
+	if err = os.WriteFile(fName, data, 0644); err != nil {
+		log.Fatal(err)
+	}
 
 	// handle logging
 	if len(steps) > 0 {
-		fName := fmt.Sprintf("%s/%ssteps-%s.log", outDir, outPrf, tag)
+		ext := "log"
+		if logFmt == "json" {
+			ext = "jsonl"
+		}
+		fName := fmt.Sprintf("%s/%ssteps-%s.%s", outDir, outPrf, tag, ext)
 		logF, err := os.Create(fName)
 		if err != nil {
 			log.Fatal(err)
@@ -284,3 +708,128 @@ func main() {
 		logF.Close()
 	}
 }
+
+// step record in JSON step log
+type stepRec struct {
+	Step  int     `json:"step"`            // step index
+	Gmax  float64 `json:"Gmax"`            // max. gain
+	Gmean float64 `json:"Gmean"`           // mean gain
+	SD    float64 `json:"SD"`              // standard deviation of gain
+	Zr    float64 `json:"Zr"`              // resistance
+	Zi    float64 `json:"Zi"`              // reactance
+	Pos   int     `json:"pos"`             // changed node (or track code)
+	Theta float64 `json:"dTheta"`          // bend angle change
+	Scale float64 `json:"scale,omitempty"` // scale factor (leg stretching)
+}
+
+// stepRecord returns a JSON-encoded step record of an accepted change
+func stepRecord(step int, ant *lib.Antenna, pos int, mdl lib.Model) string {
+	rec := &stepRec{
+		Step:  step,
+		Gmax:  ant.Perf.Gain.Max,
+		Gmean: ant.Perf.Gain.Mean,
+		SD:    ant.Perf.Gain.SD,
+		Zr:    real(ant.Perf.Z),
+		Zi:    imag(ant.Perf.Z),
+		Pos:   pos,
+	}
+	if tr, ok := mdl.(lib.Tracker); ok {
+		if chg := tr.LastChange(); chg != nil && chg.Pos == pos {
+			rec.Theta, rec.Scale = chg.Theta, chg.Scale
+		}
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(data)
+}
+
+// estimate reports the problem size of a model (after preparing the initial
+// geometry) and the worst-case number of simulations of an optimization run.
+// The optimizer gives up after Num*MaxRounds candidates without improvement;
+// this allowance grows with every progress check, so without an iteration
+// limit there is no upper bound on the number of simulations.
+func estimate(mdl lib.Model, k float64, target string, iter int, seed int64) {
+	sm, ok := mdl.(lib.Sized)
+	if !ok {
+		log.Fatalf("model '%s' doesn't report its size", mdl.Info())
+	}
+	ant, err := mdl.Prepare(seed, func(*lib.Antenna, int, string) {})
+	if err != nil {
+		log.Fatal(err)
+	}
+	size := sm.Size()
+	log.Printf("Estimate for %s (k=%g):", mdl.Info(), k)
+	log.Printf("    segments: 2x%d of %.4fm (wire length %.4fm)", size.Num, size.SegL, ant.Perf.Len)
+	log.Printf("    degrees of freedom: %d", size.DOF)
+	if target == "none" {
+		log.Printf("    simulations: 1 (no optimization)")
+		return
+	}
+	targets := len(strings.Split(target, ","))
+	stall := size.Num * lib.Cfg.Sim.MaxRounds
+	log.Printf("    simulations without improvement before termination: %d (per target)", stall)
+	if iter > 0 {
+		w := (iter + lib.Cfg.Sim.ProgressCheck - 1) / lib.Cfg.Sim.ProgressCheck
+		log.Printf("    worst-case simulations: %d (%d target(s) of max. %d steps)",
+			1+targets*stall*w*(w+1)/2, targets, iter)
+	} else {
+		log.Printf("    worst-case simulations: unbounded (no iteration limit; terminates on progress < %g)",
+			lib.Cfg.Sim.MinChange)
+	}
+}
+
+// parse pattern resolution "theta=<deg>,phi=<deg>,final=<deg>": the steps
+// for Θ and Φ are used during optimization (and set in the configuration);
+// the (optional) final step is returned.
+func parsePattern(s string) (final float64, err error) {
+	for _, p := range strings.Split(s, ",") {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return 0, fmt.Errorf("pattern: invalid entry '%s'", p)
+		}
+		var v float64
+		if v, err = lib.ParseNumber(kv[1]); err != nil {
+			return
+		}
+		if v <= 0 || v > 90 {
+			return 0, fmt.Errorf("pattern: invalid step '%s'", kv[1])
+		}
+		switch kv[0] {
+		case "theta":
+			lib.Cfg.Sim.ThetaStep = v
+		case "phi":
+			lib.Cfg.Sim.PhiStep = v
+		case "final":
+			final = v
+		default:
+			return 0, fmt.Errorf("pattern: unknown parameter '%s'", kv[0])
+		}
+	}
+	return
+}
+
+// parse sweep specification "k=<from>:<to>:<step>,param=<from>:<to>:<step>".
+// Missing entries keep the default values.
+func parseSweep(s string, ks, params []float64) ([]float64, []float64, error) {
+	for _, p := range strings.Split(s, ",") {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("sweep: invalid entry '%s'", p)
+		}
+		vals, err := lib.ParseSteps(kv[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		switch kv[0] {
+		case "k":
+			ks = vals
+		case "param":
+			params = vals
+		default:
+			return nil, nil, fmt.Errorf("sweep: unknown parameter '%s'", kv[0])
+		}
+	}
+	return ks, params, nil
+}
@@ -21,10 +21,15 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bfix/antgen/lib"
@@ -51,6 +56,12 @@ type ModelBend2D struct {
 	bendStep float64
 	bendMin  float64
 	bendMax  float64
+	adaptive bool     // adapt bend step to acceptance rate
+	parallel int      // number of candidates evaluated concurrently
+	stretch  *stretch // leg stretching (optional)
+
+	boxW float64 // max. width of antenna (0 = unbounded)
+	boxH float64 // max. height of antenna (0 = unbounded)
 }
 
 // NewModelBend2D instaniates a new optimizer model
@@ -60,10 +71,35 @@ func NewModelBend2D(verbose int) (lib.Model, error) {
 
 // Init model
 func (mdl *ModelBend2D) Init(params string, spec *lib.Specification, gen lib.Generator) (side float64, err error) {
-	// no parameters expected
+	// parse model parameters
 	if len(params) > 0 {
-		err = errors.New("no parameters expected")
-		return
+		for _, p := range strings.Split(params, ",") {
+			kv := strings.SplitN(p, "=", 2)
+			switch kv[0] {
+			case "adaptive":
+				mdl.adaptive = true
+			case "parallel":
+				if len(kv) != 2 {
+					err = errors.New("parallel: missing number of candidates")
+					return
+				}
+				if mdl.parallel, err = strconv.Atoi(kv[1]); err != nil || mdl.parallel < 1 {
+					err = fmt.Errorf("parallel: invalid number of candidates '%s'", kv[1])
+					return
+				}
+			case "box":
+				if len(kv) != 2 {
+					err = errors.New("box: missing dimensions")
+					return
+				}
+				if mdl.boxW, mdl.boxH, err = parseBox(kv[1]); err != nil {
+					return
+				}
+			default:
+				err = fmt.Errorf("unknown model parameter '%s'", kv[0])
+				return
+			}
+		}
 	}
 	// check for valid generator
 	if gen == nil {
@@ -73,7 +109,9 @@ func (mdl *ModelBend2D) Init(params string, spec *lib.Specification, gen lib.Gen
 	mdl.gen = gen
 
 	// init dipole
-	side, err = mdl.ModelDipole.Init(params, spec, gen)
+	if side, err = mdl.ModelDipole.Init(params, spec, gen); err != nil {
+		return
+	}
 
 	// compute bending angles (min, max, step)
 	mdl.bendMax = lib.BendMax(lib.Cfg.Sim.MinRadius*spec.Source.Lambda(), mdl.SegL)
@@ -85,7 +123,39 @@ func (mdl *ModelBend2D) Init(params string, spec *lib.Specification, gen lib.Gen
 
 // Info returns model information
 func (mdl *ModelBend2D) Info() string {
-	return "bend2d"
+	var params []string
+	if mdl.boxW > 0 {
+		params = append(params, fmt.Sprintf("box=%gx%g", mdl.boxW, mdl.boxH))
+	}
+	if mdl.adaptive {
+		params = append(params, "adaptive")
+	}
+	if mdl.parallel > 1 {
+		params = append(params, fmt.Sprintf("parallel=%d", mdl.parallel))
+	}
+	if len(params) == 0 {
+		return "bend2d"
+	}
+	return "bend2d[" + strings.Join(params, ",") + "]"
+}
+
+// parse box dimensions ("<width>x<height>", in meter)
+func parseBox(s string) (w, h float64, err error) {
+	dims := strings.Split(s, "x")
+	if len(dims) != 2 {
+		err = fmt.Errorf("invalid box dimensions '%s'", s)
+		return
+	}
+	if w, err = lib.ParseNumber(dims[0]); err != nil {
+		return
+	}
+	if h, err = lib.ParseNumber(dims[1]); err != nil {
+		return
+	}
+	if w <= 0 || h <= 0 {
+		err = fmt.Errorf("invalid box dimensions '%s'", s)
+	}
+	return
 }
 
 // Prepare initial geometry.
@@ -93,11 +163,16 @@ func (mdl *ModelBend2D) Prepare(seed int64, cb lib.Callback) (ant *lib.Antenna,
 	// deterministic random numbers
 	mdl.rnd = lib.Randomizer(seed)
 	mdl.seed = seed
+	mdl.bendStep = mdl.bendMax / 3
 
 	// generate the initial geometry
 	mdl.Nodes = mdl.gen.Nodes(mdl.Num, mdl.SegL, mdl.rnd)
 	mdl.Num = len(mdl.Nodes)
-	if mdl.best, err = mdl.eval(); err != nil {
+	if !mdl.checkGeometry() {
+		err = errors.New("initial geometry violates constraints")
+		return
+	}
+	if mdl.best, err = mdl.eval(nil); err != nil {
 		return
 	}
 	ant = mdl.best
@@ -111,92 +186,146 @@ func (mdl *ModelBend2D) Prepare(seed int64, cb lib.Callback) (ant *lib.Antenna,
 }
 
 // Optimize model and return best antenna geometry
-func (mdl *ModelBend2D) Optimize(seed int64, iter int, cmp *lib.Comparator, cb lib.Callback) (ant *lib.Antenna, stats lib.Stats, err error) {
+func (mdl *ModelBend2D) Optimize(ctx context.Context, seed int64, iter int, cmp *lib.Comparator, cb lib.Callback) (ant *lib.Antenna, stats lib.Stats, err error) {
+
+	// use separate randomizer if optimizer seed differs from generator seed
+	if seed != mdl.seed {
+		mdl.rnd = lib.Randomizer(seed)
+		mdl.seed = seed
+	}
 
 	// pick random segments and change their angle (direction).
 	// revert change if gain is not increasing
 	start := time.Now()
 	stats.NumMthds = 1
 
-	// optimize antenna by bending
+	// optimize antenna by bending (statistics are kept on cancellation)
 	var steps, sims int
-	if ant, steps, sims, err = mdl.optBend(iter, cmp, cb); err != nil {
-		return
-	}
+	ant, steps, sims, err = mdl.optBend(ctx, iter, cmp, cb)
 	stats.NumSteps += steps
 	stats.NumSims += sims
-
 	stats.Elapsed = time.Since(start).Round(time.Second)
+	if err != nil {
+		return
+	}
 	cb(ant, -1, fmt.Sprintf("optimized geometry (%s)", cmp.Target()))
+	if steps == 0 {
+		err = lib.ErrNoImprovement
+	}
 	return
 }
 
+// candidate change of the geometry with its performance
+type candidate struct {
+	chg   *lib.Change  // change (for track)
+	undo  func()       // revert change (applied to model nodes)
+	nodes []*lib.Node  // changed nodes (copy; parallel evaluation)
+	ant   *lib.Antenna // evaluated antenna
+}
+
 // Optimize geometry by bending the wire at joints between segments
-func (mdl *ModelBend2D) optBend(iter int, cmp *lib.Comparator, cb lib.Callback) (ant *lib.Antenna, steps, sims int, err error) {
+func (mdl *ModelBend2D) optBend(ctx context.Context, iter int, cmp *lib.Comparator, cb lib.Callback) (ant *lib.Antenna, steps, sims int, err error) {
 
-	lastVal, valChange, dw := math.NaN(), math.NaN(), 0.
+	lastVal, valChange := math.NaN(), math.NaN()
 	pos, tries, maxTries := -1, 0, 0
+	trials, accepted := 0, 0
+
+	// two-stage evaluation (if possible): the pattern is only
+	// computed for improvements
+	var proxy *lib.Proxy
+	if lib.Cfg.Sim.TwoStage && cmp.Scalar() {
+		proxy = &lib.Proxy{
+			Accept: func(p *lib.Performance) bool {
+				sign, _ := cmp.Compare(p, mdl.best.Perf)
+				return sign == 1
+			},
+			Impedance: cmp.Impedance(),
+		}
+	}
 
 	for i := 1; ; i++ {
+		// stop on cancellation (returning the best geometry so far)
+		if err = ctx.Err(); err != nil {
+			break
+		}
 		// show progress
 		if ant != nil && mdl.verbose > 0 {
 			fmt.Printf("\r%d: bend [%4d] %5d -- %.6f / %.6f  %s\033[0K",
 				mdl.seed, steps, i, valChange, lastVal, mdl.best.Perf.String())
 		}
-		// pick a random position if not set
-		if pos == -1 {
-			pos = mdl.rnd.Intn(mdl.Num)
-		}
-
-		// vary bend angle of node
-		dw = 2 * (mdl.rnd.Float64() - 0.5) * mdl.bendStep
-		if math.Abs(dw) < mdl.bendMin {
-			pos = -1
-			continue
-		}
-		node := mdl.Nodes[pos]
-		// limit bending to max
-		if math.Abs(node.Theta+dw) > mdl.bendMax {
-			pos = -1
-			continue
-		}
-		// check geometry
-		node.AddAngles(dw, 0)
-		if !mdl.checkGeometry() {
-			node.AddAngles(-dw, 0)
-			pos = -1
-			continue
-		}
-		// evaluate new antenna geometry
-		ant, err = mdl.eval()
-		if err != nil {
-			return
+		// generate and evaluate candidate change(s)
+		var cands []*candidate
+		if mdl.parallel > 1 {
+			if cands, err = mdl.candidates(ctx, proxy); err != nil {
+				return
+			}
+			if len(cands) == 0 {
+				continue
+			}
+		} else {
+			c := new(candidate)
+			if c.chg, c.undo = mdl.change(&pos); c.chg == nil {
+				continue
+			}
+			if c.ant, err = mdl.eval(proxy); err != nil {
+				return
+			}
+			cands = []*candidate{c}
 		}
-		sims++
+		sims += len(cands)
 
 		// NEC2 safe-guard: terminate optimization if resistance
 		// goes below 1Ω or above 20kΩ (defaults, can use custom range)
-		if r := real(ant.Perf.Z); r < lib.Cfg.Sim.MinZr || r > lib.Cfg.Sim.MaxZr {
+		valid := true
+		for _, c := range cands {
+			if r := real(c.ant.Perf.Z); r < lib.Cfg.Sim.MinZr || r > lib.Cfg.Sim.MaxZr {
+				valid = false
+			}
+		}
+		if !valid {
 			break
 		}
 
 		// quit after max number of rounds
-		if tries++; tries > maxTries+mdl.Num*lib.Cfg.Sim.MaxRounds {
+		if tries += len(cands); tries > maxTries+mdl.Num*lib.Cfg.Sim.MaxRounds {
 			break
 		}
 
-		// check for improved performance
-		if sign, val := cmp.Compare(ant.Perf, mdl.best.Perf); sign == 1 {
+		// check for improved performance (best candidate)
+		cand := cands[0]
+		for _, c := range cands[1:] {
+			if sign, _ := cmp.Compare(c.ant.Perf, cand.ant.Perf); sign == 1 {
+				cand = c
+			}
+		}
+		ant = cand.ant
+		sign, val := cmp.Compare(ant.Perf, mdl.best.Perf)
+		if mdl.adaptive {
+			for _, c := range cands {
+				trials++
+				if s, _ := cmp.Compare(c.ant.Perf, mdl.best.Perf); s == 1 {
+					accepted++
+				}
+				if trials == lib.Cfg.Sim.ProgressCheck {
+					mdl.adaptStep(float64(accepted) / float64(trials))
+					trials, accepted = 0, 0
+				}
+			}
+		}
+		if sign == 1 {
 			mdl.best = ant
-			mdl.Track = append(mdl.Track, &lib.Change{
-				Pos:   pos,
-				Theta: dw,
-			})
+			if cand.nodes != nil {
+				mdl.Nodes = cand.nodes
+			}
+			mdl.Track = append(mdl.Track, cand.chg)
+			if cand.chg.Pos == lib.TRK_SCALE {
+				mdl.stretch.accept(cand.chg.Scale)
+			}
 
 			// render geometry (if applicable)
 			i = 0
 			steps++
-			cb(ant, pos, fmt.Sprintf("Step #%d", steps))
+			cb(ant, cand.chg.Pos, fmt.Sprintf("Step #%d", steps))
 			if iter == steps {
 				break
 			}
@@ -216,7 +345,9 @@ func (mdl *ModelBend2D) optBend(iter int, cmp *lib.Comparator, cb lib.Callback)
 				tries = 0
 			}
 		} else {
-			node.AddAngles(-dw, 0)
+			if cand.undo != nil {
+				cand.undo()
+			}
 			pos = -1
 		}
 	}
@@ -225,27 +356,161 @@ func (mdl *ModelBend2D) optBend(iter int, cmp *lib.Comparator, cb lib.Callback)
 	return
 }
 
-// check geometry (bounded to positive x-coordinates)
+// change selects a random change (stretch the legs occasionally, if
+// enabled, or bend the wire at a joint) and applies it to the model nodes.
+// Returns nil if the change is not feasible.
+func (mdl *ModelBend2D) change(pos *int) (chg *lib.Change, undo func()) {
+	if mdl.stretch != nil && mdl.rnd.Float64() < stretchRate {
+		if chg, undo = mdl.stretch.change(mdl.Nodes, mdl.rnd); chg == nil {
+			return
+		}
+	} else {
+		// pick a random position if not set
+		if *pos == -1 {
+			*pos = mdl.rnd.Intn(mdl.Num)
+		}
+
+		// vary bend angle of node
+		dw := 2 * (mdl.rnd.Float64() - 0.5) * mdl.bendStep
+		if math.Abs(dw) < mdl.bendMin {
+			*pos = -1
+			return
+		}
+		node := mdl.Nodes[*pos]
+		// limit bending to max
+		if math.Abs(node.Theta+dw) > mdl.bendMax {
+			*pos = -1
+			return
+		}
+		node.AddAngles(dw, 0)
+		chg = &lib.Change{Pos: *pos, Theta: dw}
+		undo = func() { node.AddAngles(-dw, 0) }
+	}
+	// check geometry
+	if !mdl.checkGeometry() {
+		undo()
+		chg, undo = nil, nil
+		*pos = -1
+	}
+	return
+}
+
+// max. number of attempts (per requested candidate) to find feasible
+// changes for a set of candidates
+const maxCandTries = 100
+
+// candidates generates a set of random changes (each applied to a copy of
+// the model nodes) and evaluates them concurrently. The number of running
+// evaluations (each with its own NEC2 context) is limited by the number
+// of CPUs. If feasible changes are rare, fewer candidates (or none) are
+// returned after a limited number of attempts.
+func (mdl *ModelBend2D) candidates(ctx context.Context, proxy *lib.Proxy) (cands []*candidate, err error) {
+	for tries := 0; len(cands) < mdl.parallel && tries < maxCandTries*mdl.parallel; tries++ {
+		if ctx.Err() != nil {
+			break
+		}
+		pos := -1
+		chg, undo := mdl.change(&pos)
+		if chg == nil {
+			continue
+		}
+		cands = append(cands, &candidate{chg: chg, nodes: lib.CloneNodes(mdl.Nodes)})
+		undo()
+	}
+	var wg sync.WaitGroup
+	pool := make(chan struct{}, min(len(cands), runtime.NumCPU()))
+	errs := make([]error, len(cands))
+	for i, c := range cands {
+		wg.Add(1)
+		pool <- struct{}{}
+		go func() {
+			defer func() {
+				<-pool
+				wg.Done()
+			}()
+			c.ant = lib.BuildAntenna(mdl.Kind, mdl.Spec, c.nodes)
+			_, errs[i] = c.ant.EvalStaged(mdl.Spec.Source.Freq, mdl.Spec.Wire, mdl.Spec.Ground, proxy)
+		}()
+	}
+	wg.Wait()
+	err = errors.Join(errs...)
+	return
+}
+
+// adapt the bend step to the acceptance rate of recent changes (1/5 rule):
+// grow the step if more than a fifth of the changes are accepted, shrink it
+// otherwise. The step is kept between twice the min. bend and max. bend.
+func (mdl *ModelBend2D) adaptStep(rate float64) {
+	const fac = 1.5
+	if rate > 0.2 {
+		mdl.bendStep = min(mdl.bendStep*fac, mdl.bendMax)
+	} else if rate < 0.2 {
+		mdl.bendStep = max(mdl.bendStep/fac, 2*mdl.bendMin)
+	}
+}
+
+// check geometry (bounded to positive x-coordinates, to the optional
+// bounding box and outside of keep-out regions; end hats must keep the
+// crossing distance to the leg)
 func (mdl *ModelBend2D) checkGeometry() (ok bool) {
 	d := mdl.Nodes[0].Length
 	pos := lib.NewVec3(d/2, 0, 0)
+	if mdl.Blocked(lib.NewLine(pos.MirrorX(), pos)) {
+		return
+	}
+	bb := lib.NewBoundingBox()
+	bb.Include(pos)
+	var leg []*lib.Line
 	dir := 0.
 	for _, node := range mdl.Nodes {
 		dir = math.Mod(dir+node.Theta, lib.CircAng)
 		end := pos.Move2D(node.Length, dir)
-		if end[0] < d/2 {
+		if end[0] < d/2 && !lib.IsNull(end[0]-d/2) {
+			return
+		}
+		if mdl.Blocked(lib.NewLine(pos, end)) {
 			return
 		}
+		bb.Include(end)
+		leg = append(leg, lib.NewLine(pos, end))
 		pos = end
 	}
+	if mdl.Spec.Loop && mdl.Blocked(lib.NewLine(pos, pos.MirrorX())) {
+		return
+	}
+	if fpt := mdl.Spec.Feedpt; fpt.HatSpokes > 0 && !mdl.Spec.Loop {
+		// segments next to the tip are skipped (as in the crossing check)
+		tip := leg[len(leg)-1]
+		far := leg[:max(0, len(leg)-5)]
+		length := fpt.HatLength * mdl.Spec.Source.Lambda()
+		for _, s := range lib.HatSpokes(tip, fpt.HatSpokes, length) {
+			end := s.End()
+			if end[0] < d/2 || mdl.Blocked(s) {
+				return
+			}
+			for _, l := range far {
+				if s.Distance(l) < 2*d {
+					return
+				}
+			}
+			bb.Include(end)
+		}
+	}
+	// legs are symmetric: check half the width
+	if mdl.boxW > 0 {
+		if bb.Xmax > mdl.boxW/2 || bb.Ymax-bb.Ymin > mdl.boxH {
+			return
+		}
+	}
 	ok = true
 	return
 }
 
-// evaluate performance of antenna geometry
-func (mdl *ModelBend2D) eval() (ant *lib.Antenna, err error) {
+// evaluate performance of antenna geometry (with optional proxy for
+// two-stage evaluation)
+func (mdl *ModelBend2D) eval(proxy *lib.Proxy) (ant *lib.Antenna, err error) {
 	ant = lib.BuildAntenna(mdl.Kind, mdl.Spec, mdl.Nodes)
 	// ant.DumpNEC(mdl.spec, nil, "./curr.nec")
-	err = ant.Eval(mdl.Spec.Source.Freq, mdl.Spec.Wire, mdl.Spec.Ground)
+	_, err = ant.EvalStaged(mdl.Spec.Source.Freq, mdl.Spec.Wire, mdl.Spec.Ground, proxy)
 	return
 }
@@ -0,0 +1,132 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/bfix/antgen/lib"
+)
+
+func init() {
+	mdls["bendstretch"] = NewModelBendStretch
+}
+
+//----------------------------------------------------------------------
+
+// stretching parameters
+const (
+	stretchRate = 0.1  // probability of a stretch change
+	stretchStep = 0.05 // max. relative change of leg length
+	stretchMax  = 1.25 // max. scale of leg length
+)
+
+// stretch handles changes of the leg length by scaling all segments
+// uniformly; segments can't get shorter than the minimum segment
+// length (NEC2 constraints).
+type stretch struct {
+	scale    float64 // current scale
+	minScale float64 // min. scale
+}
+
+// change returns a random stretch change (applied to the nodes) and the
+// function to undo it. Returns nil if the change would exceed the limits.
+func (s *stretch) change(nodes []*lib.Node, rnd *rand.Rand) (chg *lib.Change, undo func()) {
+	f := 1 + 2*(rnd.Float64()-0.5)*stretchStep
+	if scale := s.scale * f; scale < s.minScale || scale > stretchMax {
+		return
+	}
+	lengths := make([]float64, len(nodes))
+	for i, n := range nodes {
+		lengths[i] = n.Length
+		n.Length *= f
+	}
+	chg = &lib.Change{Pos: lib.TRK_SCALE, Scale: f}
+	undo = func() {
+		for i, n := range nodes {
+			n.Length = lengths[i]
+		}
+	}
+	return
+}
+
+// accept a stretch change
+func (s *stretch) accept(f float64) {
+	s.scale *= f
+}
+
+//----------------------------------------------------------------------
+
+// ModelBendStretch is a bend2d model that can also stretch/shrink the
+// legs to find the right shape and (resonant) length in one run.
+type ModelBendStretch struct {
+	ModelBend2D
+}
+
+// NewModelBendStretch instaniates a new optimizer model
+func NewModelBendStretch(verbose int) (lib.Model, error) {
+	mdl := new(ModelBendStretch)
+	mdl.verbose = verbose
+	mdl.stretch = new(stretch)
+	return mdl, nil
+}
+
+// Init model
+func (mdl *ModelBendStretch) Init(params string, spec *lib.Specification, gen lib.Generator) (side float64, err error) {
+	if side, err = mdl.ModelBend2D.Init(params, spec, gen); err != nil {
+		return
+	}
+	// min. scale given by min. segment length
+	lambda := spec.Source.Lambda()
+	dx := max(lib.Cfg.Sim.SegMinLambda*lambda, lib.Cfg.Sim.SegMinWire*spec.Wire.Diameter)
+	mdl.stretch.minScale = min(1, dx/mdl.SegL)
+	side *= stretchMax
+	return
+}
+
+// Info returns model information
+func (mdl *ModelBendStretch) Info() string {
+	return "bendstretch" + strings.TrimPrefix(mdl.ModelBend2D.Info(), "bend2d")
+}
+
+// Size returns the problem size of the model (bend angles and leg length)
+func (mdl *ModelBendStretch) Size() lib.Size {
+	size := mdl.ModelBend2D.Size()
+	size.DOF++
+	return size
+}
+
+// Prepare initial geometry.
+func (mdl *ModelBendStretch) Prepare(seed int64, cb lib.Callback) (ant *lib.Antenna, err error) {
+	mdl.stretch.scale = 1
+	return mdl.ModelBend2D.Prepare(seed, cb)
+}
+
+// Optimize model and return best antenna geometry
+func (mdl *ModelBendStretch) Optimize(ctx context.Context, seed int64, iter int, cmp *lib.Comparator, cb lib.Callback) (ant *lib.Antenna, stats lib.Stats, err error) {
+	if ant, stats, err = mdl.ModelBend2D.Optimize(ctx, seed, iter, cmp, cb); err == nil && mdl.verbose > 0 {
+		fmt.Printf("leg length scaled by %.4f\n", mdl.stretch.scale)
+	}
+	return
+}
@@ -0,0 +1,109 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// convert geometry to a cut list (bending instructions for a leg)
+func convert2Cutlist(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification, v float64, fmtLen func(float64) string) (err error) {
+	// write to file or stdout
+	if len(fOut) == 0 {
+		fOut = "-"
+	}
+	var wrt io.WriteCloser
+	if wrt, err = lib.CreateOutput(fOut); err != nil {
+		return
+	}
+	defer wrt.Close()
+	// scaling factor
+	f := v
+
+	_, holes := legGeometry(geo)
+	ant, _ := buildAntenna(geo, spec)
+	bridges := ant.Bridges()
+	jumpers := ant.Jumpers()
+	for _, j := range jumpers {
+		for _, seg := range j.Segs {
+			if n, _, ok := ant.LegNode(seg); ok && !slices.Contains(bridges, n) {
+				bridges = append(bridges, n)
+			}
+		}
+	}
+	mark := "B"
+	if len(jumpers) > 0 {
+		mark = "J"
+	}
+	fmt.Fprintf(wrt, "Cut list for '%s' (one leg):\n", fGeo)
+	fmt.Fprintln(wrt, "Starting at the feed point (hole), turn at each node by the given angle")
+	fmt.Fprintln(wrt, "(positive: counter-clockwise), then follow the wire for the given length;")
+	fmt.Fprintln(wrt, "'Total' is the distance from the feed point at the end of the segment.")
+	if len(jumpers) > 0 {
+		fmt.Fprintln(wrt, "Segments marked 'J' cross other wires: use insulated jumpers (see list).")
+	} else if len(bridges) > 0 {
+		fmt.Fprintln(wrt, "Segments marked 'B' cross other wires: lift them over (wire bridge).")
+	}
+	fmt.Fprintln(wrt)
+	fmt.Fprintln(wrt, "  Node |  Turn [°] |       Length |        Total | Hole | Cross")
+	fmt.Fprintln(wrt, "-------+-----------+--------------+--------------+------+------")
+	total := 0.
+	for i, node := range geo.Nodes {
+		total += node.Length
+		hole, bridge := "    ", ""
+		if slices.Contains(holes, i+1) {
+			hole = "  * "
+		}
+		if slices.Contains(bridges, i) {
+			bridge = "  " + mark
+		}
+		fmt.Fprintf(wrt, " %5d | %+9.2f | %12s | %12s | %s | %s\n",
+			i+1, node.Theta*180/math.Pi, fmtLen(f*node.Length), fmtLen(f*total), hole, bridge)
+	}
+	fmt.Fprintf(wrt, "\nTotal wire length: %s (leg), %s (antenna)\n", fmtLen(f*total), fmtLen(2*f*total))
+
+	// list jumper positions (both legs)
+	if len(jumpers) > 0 {
+		fmt.Fprintln(wrt, "\nJumpers (x/y relative to the feed point):")
+		for i, j := range jumpers {
+			fmt.Fprintf(wrt, "  J%d: x=%s, y=%s: %s / %s\n", i+1,
+				fmtLen(f*j.Pos[0]), fmtLen(f*j.Pos[1]), segName(ant, j.Segs[0]), segName(ant, j.Segs[1]))
+		}
+	}
+	return
+}
+
+// human-readable name of an antenna segment (leg node)
+func segName(ant *lib.Antenna, seg int) string {
+	n, left, ok := ant.LegNode(seg)
+	switch {
+	case !ok:
+		return fmt.Sprintf("wire %d", seg+1)
+	case left:
+		return fmt.Sprintf("node %d (left leg)", n+1)
+	}
+	return fmt.Sprintf("node %d (right leg)", n+1)
+}
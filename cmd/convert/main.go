@@ -21,11 +21,10 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
-	"os"
+	"path/filepath"
 
 	"github.com/bfix/antgen/lib"
 )
@@ -36,19 +35,67 @@ func main() {
 		spec = new(lib.Specification)
 
 		fGeo  string  // name of geometry file
+		fGeo2 string  // name of second geometry file (overlay)
 		mode  string  // conversion mode
 		fOut  string  // output file/directory
 		freqS string  // frequency range
 		v     float64 // velocity factor
+		units string  // length units
+		srcS  string  // source parameters (s1p)
+		gndS  string  // ground parameters (s1p)
+		pts   int     // number of frequency points (s1p)
+		sFmt  string  // data format (s1p)
+		plane lib.NearFieldPlane
+		cross string // resolution of wire crossings
+		eng   string // simulation engine (nearfield)
 	)
 	// handle command-line arguments
-	flag.StringVar(&mode, "mode", "svg", "conversion mode [svg]")
-	flag.StringVar(&fGeo, "in", "", "geometry input")
+	flag.StringVar(&mode, "mode", "svg", "conversion mode [svg,cutlist,overlay,s1p,match,nearfield]")
+	flag.StringVar(&fGeo, "in", "", "geometry input ('-' for stdin)")
+	flag.StringVar(&fGeo2, "in2", "", "second geometry input (overlay)")
 	flag.StringVar(&freqS, "freq", "", "operating frequency")
 	flag.Float64Var(&v, "v", 1.0, "velocity factor")
-	flag.StringVar(&fOut, "out", "", "output")
+	flag.StringVar(&fOut, "out", "", "output ('-' for stdout)")
+	flag.StringVar(&units, "units", "m", "length units [m,ft]")
+	flag.StringVar(&srcS, "source", "", "source parameters (s1p,match)")
+	flag.StringVar(&gndS, "ground", "", "ground parameters (s1p,match)")
+	flag.IntVar(&pts, "points", 21, "number of frequency points (s1p,match)")
+	flag.StringVar(&sFmt, "format", "RI", "data format [RI,MA] (s1p)")
+	flag.Float64Var(&plane.Z, "height", 0, "height of sample plane (nearfield)")
+	flag.Float64Var(&plane.Size, "size", 0, "edge length of sample plane (nearfield; default: lambda)")
+	flag.StringVar(&cross, "crossings", "", "wire crossings [bridge,jumper] (default: from configuration)")
+	flag.IntVar(&plane.Num, "grid", 21, "sample points along an edge (nearfield)")
+	flag.StringVar(&eng, "engine", "", "simulation engine [necpp,nec2c,...] (s1p,match,nearfield)")
 	flag.Parse()
 
+	// simulation engine
+	if len(eng) > 0 {
+		lib.Cfg.Sim.Engine = eng
+	}
+
+	// resolution of wire crossings
+	if len(cross) > 0 {
+		lib.Cfg.Sim.Crossings = cross
+	}
+	if _, err := lib.ParseFixMode(lib.Cfg.Sim.Crossings); err != nil {
+		log.Fatal(err)
+	}
+
+	// length formatter
+	var fmtLen func(float64) string
+	switch units {
+	case "m":
+		fmtLen = func(x float64) string {
+			return fmt.Sprintf("%.1fmm", 1000*x)
+		}
+	case "ft":
+		fmtLen = func(x float64) string {
+			return lib.FormatImperial(x, 16)
+		}
+	default:
+		log.Fatalf("unknown units '%s'", units)
+	}
+
 	// check mandatory args
 	if len(fGeo) == 0 {
 		flag.Usage()
@@ -56,20 +103,30 @@ func main() {
 	}
 
 	// handle specified frequency (range)
-	var err error
+	var (
+		freq, span int64
+		err        error
+	)
 	if len(freqS) > 0 {
-		if spec.Source.Freq, _, err = lib.GetFrequencyRange(freqS); err != nil {
+		if freq, span, err = lib.GetFrequencyRange(freqS); err != nil {
 			log.Fatal(err)
 		}
 	}
 
-	// read geometry file
-	var body []byte
-	if body, err = os.ReadFile(fGeo); err != nil {
+	// handle source and ground parameters (defaults from configuration)
+	if spec.Source, err = lib.ParseSource(srcS, freq, false); err != nil {
+		log.Fatal(err)
+	}
+	if freq > 0 {
+		spec.Source.Freq, spec.Source.Span = freq, span
+	}
+	if spec.Ground, err = lib.ParseGround(gndS, false); err != nil {
 		log.Fatal(err)
 	}
-	geo := new(lib.Geometry)
-	if err = json.Unmarshal(body, &geo); err != nil {
+
+	// read geometry file (or stdin)
+	var geo *lib.Geometry
+	if geo, err = readGeometry(fGeo); err != nil {
 		log.Fatal(err)
 	}
 	spec.Wire = geo.Wire
@@ -77,7 +134,25 @@ func main() {
 	// handle conversion
 	switch mode {
 	case "svg":
-		err = convert2SVG(fGeo, fOut, geo, spec, v)
+		err = convert2SVG(fGeo, fOut, geo, spec, v, fmtLen)
+	case "cutlist":
+		err = convert2Cutlist(fGeo, fOut, geo, spec, v, fmtLen)
+	case "s1p":
+		err = convert2S1P(fGeo, fOut, geo, spec, pts, sFmt)
+	case "match":
+		err = convert2Match(fGeo, fOut, geo, spec, pts)
+	case "nearfield":
+		err = convert2NearField(fGeo, fOut, geo, spec, plane)
+	case "overlay":
+		if len(fGeo2) == 0 {
+			log.Fatal("missing second geometry filename")
+		}
+		var geo2 *lib.Geometry
+		if geo2, err = readGeometry(fGeo2); err != nil {
+			log.Fatal(err)
+		}
+		names := []string{filepath.Base(fGeo), filepath.Base(fGeo2)}
+		err = convert2Overlay(names, fOut, []*lib.Geometry{geo, geo2}, v, fmtLen)
 	default:
 		err = fmt.Errorf("unknown conversion '%s'", mode)
 	}
@@ -85,3 +160,14 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// read geometry from file ('-' for stdin)
+func readGeometry(fName string) (geo *lib.Geometry, err error) {
+	var body []byte
+	if body, err = lib.ReadInput(fName); err != nil {
+		return
+	}
+	geo = new(lib.Geometry)
+	err = lib.DecodeData(body, geo)
+	return
+}
@@ -0,0 +1,103 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// report a fixed L-match (designed at band center) across the band
+func convert2Match(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification, points int) (err error) {
+	// write to file or stdout
+	if len(fOut) == 0 {
+		fOut = "-"
+	}
+	// impedance at band center (design frequency) and across the band
+	f0 := float64(spec.Source.Freq)
+	var (
+		freqs []int64
+		zs    []complex128
+		z0    []complex128
+	)
+	if freqs, zs, err = evalBand(geo, spec, points); err != nil {
+		return
+	}
+	span := spec.Source.Span
+	spec.Source.Span = 0
+	_, z0, err = evalBand(geo, spec, 1)
+	spec.Source.Span = span
+	if err != nil {
+		return
+	}
+	Zs := spec.Source.Impedance()
+	_, m := lib.Zmatch(Zs, z0[0])
+
+	// networks (low-pass and high-pass) with fixed component values;
+	// only networks matching at band center are usable.
+	var (
+		nets  []*lib.LNetwork
+		names []string
+	)
+	for i, hp := range []bool{false, true} {
+		n := m.Network(f0, hp)
+		if lib.SWR(n.Input(f0, z0[0]), Zs) < 1.01 {
+			nets = append(nets, n)
+			names = append(names, []string{"LP", "HP"}[i])
+		}
+	}
+
+	var wrt io.WriteCloser
+	if wrt, err = lib.CreateOutput(fOut); err != nil {
+		return
+	}
+	defer wrt.Close()
+
+	fmt.Fprintf(wrt, "L-match for '%s' at %sHz (Z=%s Ω, source %s Ω):\n", fGeo,
+		lib.FormatNumber(f0, 4), lib.FormatImpedance(z0[0], 3), lib.FormatImpedance(Zs, 3))
+	side := "load"
+	if m.AtSource {
+		side = "source"
+	}
+	for i, n := range nets {
+		fmt.Fprintf(wrt, "  %s: shunt %s (at %s side), series %s\n", names[i], n.Shunt, side, n.Series)
+	}
+	if len(nets) == 0 {
+		fmt.Fprintln(wrt, "  no matching network found")
+	}
+	fmt.Fprintln(wrt)
+	fmt.Fprint(wrt, "    Freq [MHz] |             Z [Ω] | SWR (none)")
+	for _, name := range names {
+		fmt.Fprintf(wrt, " |   SWR (%s)", name)
+	}
+	fmt.Fprintln(wrt)
+	for i, f := range freqs {
+		fmt.Fprintf(wrt, "%14.4f | %17s | %10.3f", float64(f)/1e6,
+			lib.FormatImpedance(zs[i], 2), lib.SWR(zs[i], Zs))
+		for _, n := range nets {
+			fmt.Fprintf(wrt, " | %10.3f", lib.SWR(n.Input(float64(f), zs[i]), Zs))
+		}
+		fmt.Fprintln(wrt)
+	}
+	return
+}
@@ -0,0 +1,87 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// compute the near field on a plane around the antenna (external NEC2
+// engine) and write it as CSV or heatmap (SVG/PNG); a '.nec' output gets
+// the card deck instead (for other NEC2 programs).
+func convert2NearField(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification, plane lib.NearFieldPlane) (err error) {
+	if spec.Source.Freq == 0 {
+		return errors.New("missing frequency")
+	}
+	// set output filename if not given (stdout if reading from stdin)
+	if len(fOut) == 0 {
+		fOut = fGeo + ".nf.csv"
+		if fGeo == "-" {
+			fOut = "-"
+		}
+	}
+	// default plane: one wavelength square
+	if plane.Size <= 0 {
+		plane.Size = spec.Source.Lambda()
+	}
+	spec.Feedpt = geo.Feedpt
+	spec.Elements = geo.Elements
+	spec.Loop = geo.Loop
+	ant := lib.BuildAntenna(geo.Kind(), spec, geo.Nodes)
+
+	// compute near field (unless writing a card deck)
+	ext := strings.ToLower(filepath.Ext(fOut))
+	var nf []*lib.NearFieldPoint
+	if ext != ".nec" {
+		var pts []lib.Vec3
+		if pts, err = plane.Points(); err != nil {
+			return
+		}
+		if nf, err = ant.NearField(spec.Source.Freq, spec.Wire, spec.Ground, pts); err != nil {
+			return
+		}
+	}
+
+	// write to file or stdout
+	var wrt io.WriteCloser
+	if wrt, err = lib.CreateOutput(fOut); err != nil {
+		return
+	}
+	defer wrt.Close()
+	switch ext {
+	case ".nec":
+		cmts := append([]string{"Near field of '" + fGeo + "'"}, geo.Cmts...)
+		return ant.DumpNearField(wrt, spec, cmts, plane)
+	case ".svg", ".png":
+		var out string
+		if out, err = lib.PlotNearField(plane, nf, ext[1:]); err != nil {
+			return
+		}
+		_, err = io.WriteString(wrt, out)
+		return
+	}
+	return lib.WriteNearFieldCSV(wrt, nf)
+}
@@ -0,0 +1,105 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/bfix/antgen/lib"
+	"github.com/twpayne/go-svg"
+	"github.com/twpayne/go-svg/svgpath"
+)
+
+// convert geometries to a SVG file with all legs superimposed (shared
+// bounding box, colors from the plot palette, legend with input names)
+func convert2Overlay(names []string, fOut string, geos []*lib.Geometry, v float64, fmtLen func(float64) string) (err error) {
+	// set output filename if not given
+	if len(fOut) == 0 {
+		fOut = "overlay.svg"
+	}
+	// scaling factor
+	f := 1000 * v
+
+	// build geometries with shared bounding box
+	var lines [][]lib.Vec3
+	bb := lib.NewBoundingBox()
+	for _, geo := range geos {
+		line, _ := legGeometry(geo)
+		for _, pos := range line {
+			bb.Include(pos)
+		}
+		lines = append(lines, line)
+	}
+	log.Printf("BoundingBox: (%.2f,%.2f) - (%.2f,%.2f)",
+		f*bb.Xmin, f*bb.Ymin, f*bb.Xmax, f*bb.Ymax)
+
+	// reserve space for legend (above the geometries)
+	fontSize := 4.
+	lineH := 1.5 * fontSize
+	top := f*bb.Ymin - float64(len(geos))*lineH - fontSize/2
+
+	// create SVG
+	graph := svg.New()
+	w, h := f*(bb.Xmax-bb.Xmin), f*bb.Ymax-top
+	log.Printf("Width=%s, Height=%s", fmtLen(w/1000), fmtLen(f*(bb.Ymax-bb.Ymin)/1000))
+	graph.WidthHeight(w, h, svg.MM)
+	graph.ViewBox(f*bb.Xmin, top, w, h)
+
+	// add legs and legend entries
+	scale := func(p lib.Vec3) []float64 {
+		return []float64{f * p[0], f * p[1]}
+	}
+	for i, line := range lines {
+		_, ls := lib.PlotStyle(i)
+		R, G, B, _ := ls.Color.RGBA()
+		clr := fmt.Sprintf("#%02x%02x%02x", R>>8, G>>8, B>>8)
+
+		path := svgpath.New()
+		path.MoveToAbs(scale(line[0]))
+		for _, p := range line[1:] {
+			path.LineToAbs(scale(p))
+		}
+		style := svg.String(fmt.Sprintf(
+			"stroke:%s;stroke-opacity:1;stroke-width:%.2f;stroke-dasharray:none",
+			clr, 1000*geos[i].Wire.Diameter))
+		label := svg.Text(svg.CharData(names[i])).
+			XY(f*bb.Xmin, top+float64(i+1)*lineH, svg.Number).
+			FontSize(svg.String(fmt.Sprintf("%g", fontSize))).
+			Fill(svg.String(clr))
+		graph.AppendChildren(
+			svg.Path().Style(style).Fill("none").D(path),
+			label,
+		)
+	}
+
+	// output SVG file (or stdout)
+	var fp io.WriteCloser
+	if fp, err = lib.CreateOutput(fOut); err != nil {
+		return
+	}
+	if _, err = graph.WriteToIndent(fp, "", "  "); err != nil {
+		return
+	}
+	err = fp.Close()
+	return
+}
@@ -0,0 +1,91 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// convert geometry to a Touchstone file (S11 over the frequency span)
+func convert2S1P(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification, points int, format string) (err error) {
+	// set output filename if not given (stdout if reading from stdin)
+	if len(fOut) == 0 {
+		fOut = fGeo + ".s1p"
+		if fGeo == "-" {
+			fOut = "-"
+		}
+	}
+	// Touchstone only knows real reference impedances
+	z0 := spec.Source.Z.R
+	if spec.Source.Z.X != 0 {
+		log.Printf("reactive part of source impedance ignored (reference is %g Ω)", z0)
+	}
+	var (
+		freqs []int64
+		zs    []complex128
+	)
+	if freqs, zs, err = evalBand(geo, spec, points); err != nil {
+		return
+	}
+
+	// write to file or stdout
+	var wrt io.WriteCloser
+	if wrt, err = lib.CreateOutput(fOut); err != nil {
+		return
+	}
+	defer wrt.Close()
+	cmts := append([]string{"S11 of '" + fGeo + "'"}, geo.Cmts...)
+	return lib.WriteTouchstone(wrt, cmts, format, z0, freqs, zs)
+}
+
+// evaluate the feed point impedance of a geometry at equidistant
+// frequencies across the band (center frequency only if no span is set)
+func evalBand(geo *lib.Geometry, spec *lib.Specification, points int) (freqs []int64, zs []complex128, err error) {
+	if points < 1 || (spec.Source.Span > 0 && points < 2) {
+		err = fmt.Errorf("invalid number of frequency points (%d)", points)
+		return
+	}
+	spec.Feedpt = geo.Feedpt
+	spec.Elements = geo.Elements
+	spec.Loop = geo.Loop
+
+	fLow, fStep := spec.Source.Freq, int64(0)
+	if spec.Source.Span > 0 {
+		fLow -= spec.Source.Span
+		fStep = 2 * spec.Source.Span / int64(points-1)
+	} else {
+		points = 1
+	}
+	for i := range points {
+		f := fLow + int64(i)*fStep
+		ant := lib.BuildAntenna(geo.Kind(), spec, geo.Nodes)
+		if err = ant.Eval(f, spec.Wire, spec.Ground); err != nil {
+			return
+		}
+		freqs = append(freqs, f)
+		zs = append(zs, ant.Perf.Z)
+	}
+	return
+}
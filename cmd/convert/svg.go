@@ -22,8 +22,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
-	"os"
+	"slices"
 	"strings"
 
 	"github.com/bfix/antgen/lib"
@@ -31,11 +32,77 @@ import (
 	"github.com/twpayne/go-svg/svgpath"
 )
 
+// legGeometry returns the dipole leg as a "line" (sequence of 2D points)
+// and the indices of "holes" in the line (every five segments or if
+// curvature is above limit; first and last point are always holes).
+func legGeometry(geo *lib.Geometry) (line []lib.Vec3, holes []int) {
+	pos := lib.NewVec3(0, 0, 0)
+	line = append(line, pos)
+	holes = append(holes, 0)
+	hStep := 0
+	lastHole := pos
+	dir := 0.
+	for i, node := range geo.Nodes {
+		dir += node.Theta
+		end := pos.Move2D(node.Length, dir)
+		line = append(line, end)
+		hStep++
+		deviation := float64(hStep) * node.Length / end.Sub(lastHole).Length()
+		if hStep == 5 || deviation > 1.02 {
+			hStep = 0
+			holes = append(holes, i+1)
+			lastHole = end
+		}
+		pos = end
+	}
+	if last := len(line) - 1; holes[len(holes)-1] != last {
+		holes = append(holes, last)
+	}
+	return
+}
+
+// buildAntenna returns the simulated antenna for a geometry (with wire
+// crossings resolved as bridges or jumpers) and the start of the right
+// leg (first point of legGeometry).
+func buildAntenna(geo *lib.Geometry, spec *lib.Specification) (ant *lib.Antenna, start lib.Vec3) {
+	s := *spec
+	s.Feedpt = geo.Feedpt
+	s.Loop = geo.Loop
+	ant = lib.BuildAntenna(geo.Kind(), &s, geo.Nodes)
+	start = lib.NewVec3(s.Feedpt.Gap/2, 0, 0)
+	return
+}
+
+// legJumpers returns the positions of jumpers (in leg coordinates) where
+// the leg crosses itself.
+func legJumpers(ant *lib.Antenna, start lib.Vec3) (pos []lib.Vec3) {
+	for _, j := range ant.Jumpers() {
+		_, l1, ok1 := ant.LegNode(j.Segs[0])
+		_, l2, ok2 := ant.LegNode(j.Segs[1])
+		if !ok1 || !ok2 || l1 != l2 {
+			continue
+		}
+		p := j.Pos
+		if l1 {
+			p = p.MirrorX()
+		}
+		p[2] = 0
+		p = p.Sub(start)
+		if !slices.ContainsFunc(pos, func(q lib.Vec3) bool { return q.Sub(p).Length() < 1e-9 }) {
+			pos = append(pos, p)
+		}
+	}
+	return
+}
+
 // convert geometry to SVG file
-func convert2SVG(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification, v float64) (err error) {
-	// set output filename if not given
+func convert2SVG(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification, v float64, fmtLen func(float64) string) (err error) {
+	// set output filename if not given (stdout if reading from stdin)
 	if len(fOut) == 0 {
 		fOut = fGeo + ".svg"
+		if fGeo == "-" {
+			fOut = "-"
+		}
 	}
 	// scaling factor
 	f := 1000 * v
@@ -55,33 +122,13 @@ func convert2SVG(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification,
 		}
 	}
 
-	// build geometry:
-	// (1) dipole leg as a "line" (sequence of 2D points)
-	// (2) "holes" (every five segments or if curvature is above limit)
-	var line, holes []lib.Vec3
-	pos := lib.NewVec3(0, 0, 0)
-	line = append(line, pos)
-	holes = append(holes, pos)
-	hStep := 0
-	lastHole := pos
-	dir := 0.
-	bb := lib.NewBoundingBox()
-	bb.Include(pos)
-	for _, node := range geo.Nodes {
-		dir += node.Theta
-		end := pos.Move2D(node.Length, dir)
-		line = append(line, end)
-		hStep++
-		deviation := float64(hStep) * node.Length / end.Sub(lastHole).Length()
-		if hStep == 5 || deviation > 1.02 {
-			hStep = 0
-			holes = append(holes, end)
-			lastHole = end
-		}
-		bb.Include(end)
-		pos = end
+	// build geometry
+	line, holeIdx := legGeometry(geo)
+	var holes []lib.Vec3
+	for _, idx := range holeIdx {
+		holes = append(holes, line[idx])
 	}
-	holes = append(holes, pos)
+	bb := lib.NewBoundingBoxOf(line)
 
 	log.Printf("BoundingBox: (%.2f,%.2f) - (%.2f,%.2f)",
 		f*bb.Xmin, f*bb.Ymin, f*bb.Xmax, f*bb.Ymax)
@@ -103,6 +150,43 @@ func convert2SVG(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification,
 		Fill("none").
 		D(path)
 
+	// mark bridges (dashed, labeled) and jumpers
+	var bridges []svg.Element
+	ant, start := buildAntenna(geo, spec)
+	if idx := ant.Bridges(); len(idx) > 0 {
+		log.Printf("WARN: %d node(s) need a wire bridge (not flat)", len(idx))
+		for _, i := range idx {
+			p := svgpath.New()
+			p.MoveToAbs(scale(line[i]))
+			p.LineToAbs(scale(line[i+1]))
+			bridges = append(bridges, svg.Path().
+				Style("stroke:#ff0000;stroke-width:1;stroke-dasharray:2,2").
+				Fill("none").
+				D(p))
+		}
+		// label first node of every bridge
+		for j, i := range idx {
+			if j > 0 && idx[j-1] == i-1 {
+				continue
+			}
+			p := scale(line[i])
+			bridges = append(bridges, svg.Text(svg.CharData("bridge here")).
+				Style("font-size:4px;fill:#ff0000").
+				XY(p[0]+3, p[1]-3, svg.Number))
+		}
+	}
+	if jumps := legJumpers(ant, start); len(jumps) > 0 {
+		log.Printf("%d jumper(s) needed in leg", len(jumps))
+		for _, pos := range jumps {
+			p := scale(pos)
+			bridges = append(bridges,
+				svg.Circle().CXCYR(p[0], p[1], 4, svg.Number).Fill("none").Stroke("#ff0000"),
+				svg.Text(svg.CharData("jumper")).
+					Style("font-size:4px;fill:#ff0000").
+					XY(p[0]+5, p[1]-5, svg.Number))
+		}
+	}
+
 	// place hole markers
 	var circles []svg.Element
 	for _, hole := range holes {
@@ -113,7 +197,7 @@ func convert2SVG(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification,
 	// create SVG
 	graph := svg.New()
 	w, h := f*(bb.Xmax-bb.Xmin), f*(bb.Ymax-bb.Ymin)
-	log.Printf("Width= %.3fmm, Height=%.3fmm", w, h)
+	log.Printf("Width=%s, Height=%s", fmtLen(w/1000), fmtLen(h/1000))
 	graph.WidthHeight(w, h, svg.MM)
 	graph.ViewBox(f*bb.Xmin, f*bb.Ymin, w, h)
 	graph.AppendChildren(
@@ -121,11 +205,12 @@ func convert2SVG(fGeo, fOut string, geo *lib.Geometry, spec *lib.Specification,
 		svg.Desc(desc...),
 		leg,
 	)
+	graph.AppendChildren(bridges...)
 	graph.AppendChildren(circles...)
 
-	// output SVG file
-	var fp *os.File
-	if fp, err = os.Create(fOut); err != nil {
+	// output SVG file (or stdout)
+	var fp io.WriteCloser
+	if fp, err = lib.CreateOutput(fOut); err != nil {
 		return
 	}
 	if _, err = graph.WriteToIndent(fp, "", "  "); err != nil {
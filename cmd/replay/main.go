@@ -27,10 +27,12 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/bfix/antgen/lib"
 )
@@ -43,14 +45,16 @@ func main() {
 		fIn    string
 		evalS  string
 		outDir string
+		engine string
 		err    error
 		eval   bool
 		render lib.Canvas
 	)
-	flag.StringVar(&mode, "mode", "track", "operating mode [track,geo]")
-	flag.StringVar(&fIn, "in", "", "input file/directory")
-	flag.StringVar(&evalS, "eval", "", "evaluate at frequency")
+	flag.StringVar(&mode, "mode", "track", "operating mode [track,geo,current]")
+	flag.StringVar(&fIn, "in", "", "input file/directory ('-' for stdin)")
+	flag.StringVar(&evalS, "eval", "", "evaluate at frequency (range)")
 	flag.StringVar(&outDir, "out", "./out", "output directory")
+	flag.StringVar(&engine, "engine", "", "simulation engine [necpp,nec2c,...]")
 	flag.Parse()
 
 	if len(fIn) == 0 {
@@ -58,27 +62,31 @@ func main() {
 		log.Fatal("missing input file/directory")
 	}
 
+	if len(engine) > 0 {
+		lib.Cfg.Sim.Engine = engine
+	}
+	if err = lib.CheckEngine(); err != nil {
+		log.Fatal(err)
+	}
+
 	// handle specified frequency (range)
 	if len(evalS) > 0 {
-		if spec.Source.Freq, _, err = lib.GetFrequencyRange(evalS); err != nil {
+		if spec.Source.Freq, spec.Source.Span, err = lib.GetFrequencyRange(evalS); err != nil {
 			log.Fatal(err)
 		}
 		eval = true
 	}
 
 	if mode == "track" {
-		// read track file
-		body, err := os.ReadFile(fIn)
+		// read track file (or stdin)
+		body, err := lib.ReadInput(fIn)
 		if err != nil {
 			log.Fatal(err)
 		}
 		track := new(lib.TrackList)
-		if err = json.Unmarshal(body, &track); err != nil {
+		if err = lib.DecodeData(body, track); err != nil {
 			log.Fatal(err)
 		}
-		spec.Wire = track.Wire
-		spec.Ground.Height = track.Height
-
 		side := 1.1 * float64(track.Num) * track.SegL
 
 		// setup rendering
@@ -86,56 +94,14 @@ func main() {
 			log.Fatal(err)
 		}
 
-		// build initial geometry
-		num := track.Num
-		nodes := make([]*lib.Node, num)
-		for i := range nodes {
-			nodes[i] = lib.NewNode(track.SegL, 0, 0)
-		}
-
+		// replay track
+		var nodes []*lib.Node
 		go func() {
-			// iterate over changes
-			var ant *lib.Antenna
-			init := true
-			step := 0
-			for _, chg := range track.Track {
-				switch chg.Pos {
-				case lib.TRK_MARK:
-					// marker ends initial geometry build
-					init = false
-
-				case lib.TRK_SHORT:
-					// shorten leg
-					num--
-					nodes = nodes[:num]
-					continue
-
-				case lib.TRK_LENGTH:
-					// lengthen leg
-					num++
-					nodes = append(nodes, lib.NewNode(track.SegL, 0, 0))
-					continue
-
-				default:
-					// apply change
-					n := nodes[chg.Pos]
-					n.AddAngles(chg.Theta, chg.Phi)
-				}
-
-				// visualize antenna
-				if !init {
-					step++
-					ant = lib.BuildAntenna("track", spec, nodes)
-					if eval {
-						if err = ant.Eval(spec.Source.Freq, spec.Wire, spec.Ground); err != nil {
-							log.Fatal(err)
-						}
-					}
-					render.Show(ant, chg.Pos, fmt.Sprintf("Step #%d", step))
-				}
+			if err := track.Replay(render, spec, eval, func(n []*lib.Node) {
+				nodes = n
+			}); err != nil {
+				log.Fatal(err)
 			}
-			render.Show(ant, -1, "final geometry")
-			render.Close()
 		}()
 		render.Run(func(ant *lib.Antenna, key rune, step int) (rc bool) {
 			switch key {
@@ -161,19 +127,30 @@ func main() {
 		}
 		render.SetHint("Keys: (p)revious, (n)ext")
 
-		var geos []string
-		log.Printf("Scanning directory '%s' for geometry files...", fIn)
-		if err = filepath.Walk(fIn, func(path string, info fs.FileInfo, err error) error {
-			if info == nil {
-				return errors.New("invalid walk")
+		// collect geometry files (single geometry from stdin)
+		var (
+			geos  []string
+			stdin []byte
+		)
+		if fIn == "-" {
+			if stdin, err = lib.ReadInput(fIn); err != nil {
+				log.Fatal(err)
 			}
-			if strings.Contains(info.Name(), "geometry-") {
-				log.Printf("   Processing '%s'...", path)
-				geos = append(geos, path)
+			geos = append(geos, fIn)
+		} else {
+			log.Printf("Scanning directory '%s' for geometry files...", fIn)
+			if err = filepath.Walk(fIn, func(path string, info fs.FileInfo, err error) error {
+				if info == nil {
+					return errors.New("invalid walk")
+				}
+				if strings.Contains(info.Name(), "geometry-") {
+					log.Printf("   Processing '%s'...", path)
+					geos = append(geos, path)
+				}
+				return nil
+			}); err != nil {
+				log.Fatal(err)
 			}
-			return nil
-		}); err != nil {
-			log.Fatal(err)
 		}
 		var gpos atomic.Uint32
 		gpos.Store(0)
@@ -185,20 +162,25 @@ func main() {
 				path := geos[pos]
 
 				// read geometry file
-				body, err := os.ReadFile(path)
-				if err != nil {
-					log.Fatal(err)
+				var err error
+				body := stdin
+				if path != "-" {
+					if body, err = os.ReadFile(path); err != nil {
+						log.Fatal(err)
+					}
 				}
 				geo := new(lib.Geometry)
-				if err = json.Unmarshal(body, &geo); err != nil {
+				if err = lib.DecodeData(body, geo); err != nil {
 					log.Fatal(err)
 				}
 				spec.Wire = geo.Wire
+				spec.Elements = geo.Elements
+				spec.Loop = geo.Loop
 
 				// build initial geometry
-				ant := lib.BuildAntenna("geo", spec, geo.Nodes)
+				ant := lib.BuildAntenna(geo.Kind(), spec, geo.Nodes)
 				if eval {
-					if err = ant.Eval(spec.Source.Freq, spec.Wire, spec.Ground); err != nil {
+					if err = ant.EvalBand(spec.Source.Freq, spec.Source.Span, spec.Wire, spec.Ground); err != nil {
 						log.Fatal(err)
 					}
 				}
@@ -227,5 +209,45 @@ func main() {
 			}
 			return
 		})
+	} else if mode == "current" {
+		if !eval {
+			log.Fatal("mode 'current' requires a frequency (-eval)")
+		}
+		// read geometry file (or stdin)
+		body, err := lib.ReadInput(fIn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		geo := new(lib.Geometry)
+		if err = lib.DecodeData(body, geo); err != nil {
+			log.Fatal(err)
+		}
+		spec.Wire = geo.Wire
+		spec.Elements = geo.Elements
+		spec.Loop = geo.Loop
+
+		// compute segment currents
+		ant := lib.BuildAntenna(geo.Kind(), spec, geo.Nodes)
+		if err = ant.EvalCurrents(spec.Source.Freq, spec.Wire, spec.Ground); err != nil {
+			log.Fatal(err)
+		}
+
+		// setup rendering
+		if render, err = lib.NewSDLCanvas(1024, 768, 2.01); err != nil {
+			log.Fatal(err)
+		}
+		render.SetHint("Keys: (Enter) pause/resume")
+
+		// animate the instantaneous currents over a RF cycle
+		const steps = 36
+		go func() {
+			for step := 0; ; step = (step + 1) % steps {
+				phase := 2 * math.Pi * float64(step) / steps
+				msg := fmt.Sprintf("%s (phase %3.0f°)", fIn, phase*180/math.Pi)
+				render.Show(ant.CurrentColors(phase), -1, msg)
+				time.Sleep(40 * time.Millisecond)
+			}
+		}()
+		render.Run(nil)
 	}
 }
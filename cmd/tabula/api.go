@@ -0,0 +1,178 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bfix/antgen/lib"
+)
+
+//======================================================================
+// REST/JSON API of the plot server
+//======================================================================
+
+// APISet is a plot set in API responses
+type APISet struct {
+	Dir   string    `json:"dir"`   // model set directory
+	Tag   string    `json:"tag"`   // plot tag
+	K     []float64 `json:"k"`     // available 'k' values
+	Param []float64 `json:"param"` // available 'param' values
+}
+
+// APITable is a table of plot values in API responses; missing values
+// are null, impedances are [Zr,Zi] pairs.
+type APITable struct {
+	Name    string   `json:"name"`    // plot target
+	Columns []string `json:"columns"` // column names (parameters, set tags)
+	NumIdx  int      `json:"numIdx"`  // number of parameter columns
+	Rows    [][]any  `json:"rows"`    // table rows
+}
+
+// APIError is the response for failed requests
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// register API handlers
+func apiHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/api/sets", apiSets)
+	mux.HandleFunc("/api/plot", apiPlot)
+	mux.HandleFunc("/api/smith", apiSmith)
+}
+
+// GET /api/sets: list of available plot sets (sorted by directory)
+func apiSets(w http.ResponseWriter, r *http.Request) {
+	if !apiMethod(w, r) {
+		return
+	}
+	current := plotSets()
+	list := make([]*APISet, 0, len(current))
+	for dir, ps := range current {
+		// empty lists instead of null
+		list = append(list, &APISet{
+			Dir:   dir,
+			Tag:   ps.Tag,
+			K:     append([]float64{}, ps.Klist...),
+			Param: append([]float64{}, ps.Plist...),
+		})
+	}
+	slices.SortFunc(list, func(a, b *APISet) int {
+		return strings.Compare(a.Dir, b.Dir)
+	})
+	apiReply(w, http.StatusOK, list)
+}
+
+// GET /api/plot?target=<target>&sets=<sets>: table of
+// plot values (sets as in "plot-file")
+func apiPlot(w http.ResponseWriter, r *http.Request) {
+	apiTable(w, r, r.URL.Query().Get("target"), lib.PlotTable)
+}
+
+// GET /api/smith?sets=<sets>: table of impedances (Smith chart tracks)
+func apiSmith(w http.ResponseWriter, r *http.Request) {
+	apiTable(w, r, "Smith", lib.SmithTable)
+}
+
+// handle table requests
+func apiTable(w http.ResponseWriter, r *http.Request, target string,
+	table func(*lib.Database, *lib.Selection) (*lib.Table, error)) {
+	if !apiMethod(w, r) {
+		return
+	}
+	q := r.URL.Query()
+	if len(target) == 0 {
+		target = "Gmax"
+	}
+	if len(q.Get("sets")) == 0 {
+		apiReply(w, http.StatusBadRequest, &APIError{"missing plot sets"})
+		return
+	}
+	// only known plot sets are accepted
+	specs := strings.Split(q.Get("sets"), ",")
+	current := plotSets()
+	for _, spec := range specs {
+		if t := strings.Split(spec, ":"); len(t) > 1 {
+			if _, ok := current[t[1]]; !ok {
+				apiReply(w, http.StatusBadRequest, &APIError{"unknown plot set '" + t[1] + "'"})
+				return
+			}
+		}
+	}
+	sel, err := parseSelection(db, target, "", specs)
+	if err != nil {
+		apiReply(w, http.StatusBadRequest, &APIError{err.Error()})
+		return
+	}
+	tbl, err := table(db, sel)
+	if err != nil {
+		apiReply(w, http.StatusBadRequest, &APIError{err.Error()})
+		return
+	}
+	apiReply(w, http.StatusOK, newAPITable(tbl))
+}
+
+// convert table for JSON encoding (no NaN values or complex numbers)
+func newAPITable(tbl *lib.Table) *APITable {
+	out := &APITable{
+		Name:    tbl.Name,
+		Columns: tbl.Dims,
+		NumIdx:  tbl.NumIdx,
+		Rows:    make([][]any, len(tbl.Vals)),
+	}
+	for i, row := range tbl.Vals {
+		vals := make([]any, len(row))
+		for j, v := range row {
+			switch x := v.(type) {
+			case float64:
+				if !math.IsNaN(x) && !math.IsInf(x, 0) {
+					vals[j] = x
+				}
+			case complex128:
+				if !math.IsNaN(real(x)) && !math.IsNaN(imag(x)) {
+					vals[j] = []float64{real(x), imag(x)}
+				}
+			}
+		}
+		out.Rows[i] = vals
+	}
+	return out
+}
+
+// only GET requests are allowed
+func apiMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		apiReply(w, http.StatusMethodNotAllowed, &APIError{"method not allowed"})
+		return false
+	}
+	return true
+}
+
+// send JSON response
+func apiReply(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
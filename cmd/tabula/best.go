@@ -21,8 +21,8 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -39,6 +39,9 @@ func showBest(db *lib.Database, in string, args []string) {
 		target string // opt. parameter
 		band   string // frequency band
 		zRange string // impedance range [min_Zr,max_Zr,|Zi|]
+		where  string // filter expression
+		grid   string // contact sheet layout (<cols>x<rows>)
+		out    string // contact sheet file
 
 		spec = new(lib.Specification)
 		err  error
@@ -46,9 +49,20 @@ func showBest(db *lib.Database, in string, args []string) {
 	fs := flag.NewFlagSet("best", flag.ContinueOnError)
 	fs.StringVar(&target, "target", "Gmax", "opt. parameter")
 	fs.StringVar(&band, "band", "2m", "frequency band")
-	fs.StringVar(&zRange, "zRange", "any", "impedance range: [min_Zr,max_Zr,|Zi|]")
+	fs.StringVar(&zRange, "zRange", "any", "impedance range: preset or [min_Zr,max_Zr,|Zi|]")
+	fs.StringVar(&where, "where", "", "filter expression (e.g. \"Gmax > 5 and k < 0.5\")")
+	fs.StringVar(&grid, "grid", "", "render best models as contact sheet (<cols>x<rows>)")
+	fs.StringVar(&out, "out", "best.svg", "contact sheet file (SVG)")
 	fs.Parse(args)
 
+	// handle contact sheet layout
+	var cols, rows int
+	if len(grid) > 0 {
+		if cols, rows, err = parseGrid(grid); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// handle impedance range
 	var zClause string
 	addZ := func(s string) {
@@ -57,18 +71,16 @@ func showBest(db *lib.Database, in string, args []string) {
 		}
 		zClause += s
 	}
-	switch zRange {
-	case "any":
-		zClause = ""
-	case "resonant":
-		zClause = "abs(Zi) < 1"
-	case "good":
-		zClause = "Zr > 30 and Zr < 70 and abs(Zi) < 20"
-	case "matched":
-		zClause = "Zr > 48 and Zr < 52 and abs(Zi) < 1"
-	case "loss":
-		zClause = "Zr/sqrt(Zr*Zr+Zi*Zi) > 0.95"
-	default:
+	if preset, ok := lib.Cfg.ZRanges[zRange]; ok {
+		// named preset (configurable)
+		if len(preset) > 0 {
+			clause, err := lib.ParseWhere(preset)
+			if err != nil {
+				log.Fatalf("zRange '%s': %s", zRange, err)
+			}
+			addZ("(" + clause + ")")
+		}
+	} else {
 		zRange = strings.Trim(zRange, "[]")
 		parts := strings.Split(zRange, ",")
 		if len(parts) != 3 {
@@ -98,17 +110,20 @@ func showBest(db *lib.Database, in string, args []string) {
 			addZ("abs(Zi) < " + parts[2])
 		}
 	}
-	// handle specified frequency (range)
-	switch band {
-	case "2m":
-		spec.Source.Freq = 145000000
-	case "70cm":
-		spec.Source.Freq = 435000000
-	case "35cm":
-		spec.Source.Freq = 868000000
-	default:
-		log.Fatalf("unknown band '%s'", band)
+	// handle filter expression
+	if len(where) > 0 {
+		clause, err := lib.ParseWhere(where)
+		if err != nil {
+			log.Fatal(err)
+		}
+		addZ("(" + clause + ")")
+	}
+	// handle specified frequency band (band directories)
+	b, err := lib.GetBand(band)
+	if err != nil {
+		log.Fatal(err)
 	}
+	spec.Source.Freq = b.Center()
 
 	// target-dependent database query
 	var order string
@@ -133,16 +148,23 @@ func showBest(db *lib.Database, in string, args []string) {
 		log.Fatalf("unknown target '%s'", target)
 	}
 	// assemble model/geometry list from database
-	var geos []string
+	var geos, labels []string
 	var perf []*lib.Performance
-	rows, err := db.GetRows(zClause, order)
+	list, err := db.GetRows(zClause, order)
 	if err != nil {
 		log.Fatal(err)
 	}
-	for _, r := range rows {
+	for _, r := range list {
+		if cols > 0 && len(geos) == cols*rows {
+			break
+		}
 		_, dir, tag := r.Reference()
 		if strings.HasPrefix(dir, band) {
-			f := in + "/" + dir + "/geometry-" + tag + ".json"
+			f, err := lib.FindFile(in + "/" + dir + "/geometry-" + tag)
+			if err != nil {
+				log.Printf("WARN: %s", err.Error())
+				continue
+			}
 			geos = append(geos, f)
 
 			p := new(lib.Performance)
@@ -152,7 +174,33 @@ func showBest(db *lib.Database, in string, args []string) {
 			p.Gain.SD = r.Value("SD")
 			p.Z = complex(r.Value("Zr"), r.Value("Zi"))
 			perf = append(perf, p)
+			labels = append(labels, fmt.Sprintf("%s/%s: Gmax=%.2f dBi", dir, tag, p.Gain.Max))
+		}
+	}
+	if len(geos) == 0 {
+		log.Fatal("no matching models")
+	}
+
+	// render contact sheet
+	if cols > 0 {
+		var cells [][]byte
+		for i, path := range geos {
+			ant, err := loadAntenna(path, spec, perf[i])
+			if err != nil {
+				log.Fatal(err)
+			}
+			canvas, err := lib.NewSVGCanvas(0, 0, 0)
+			if err != nil {
+				log.Fatal(err)
+			}
+			canvas.Show(ant, -1, "")
+			cells = append(cells, canvas.Bytes())
 		}
+		if err = os.WriteFile(out, lib.SVGSheet(cells, labels, cols, 400), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%d models rendered to %s", len(cells), out)
+		return
 	}
 
 	// setup rendering
@@ -170,25 +218,10 @@ func showBest(db *lib.Database, in string, args []string) {
 		for {
 			pos := int(gpos.Load())
 			path := geos[pos]
-
-			// read geometry file
-			body, err := os.ReadFile(path)
+			ant, err := loadAntenna(path, spec, perf[pos])
 			if err != nil {
 				log.Fatal(err)
 			}
-			geo := new(lib.Geometry)
-			if err = json.Unmarshal(body, &geo); err != nil {
-				log.Fatal(err)
-			}
-			spec.Wire = geo.Wire
-			spec.Feedpt = geo.Feedpt
-			if lib.IsNull(spec.Feedpt.Gap) {
-				spec.Feedpt.Gap = geo.Nodes[0].Length
-			}
-
-			// build initial geometry
-			ant := lib.BuildAntenna("geo", spec, geo.Nodes)
-			ant.Perf = perf[pos]
 			name := strings.TrimPrefix(path, in)
 			render.Show(ant, -1, name)
 			if rc := <-cont; rc < 0 {
@@ -216,3 +249,41 @@ func showBest(db *lib.Database, in string, args []string) {
 		return
 	})
 }
+
+// loadAntenna reads a geometry file and builds the antenna (with given
+// performance values)
+func loadAntenna(path string, spec *lib.Specification, perf *lib.Performance) (ant *lib.Antenna, err error) {
+	var body []byte
+	if body, err = os.ReadFile(path); err != nil {
+		return
+	}
+	geo := new(lib.Geometry)
+	if err = lib.DecodeData(body, geo); err != nil {
+		return
+	}
+	spec.Wire = geo.Wire
+	spec.Feedpt = geo.Feedpt
+	spec.Elements = geo.Elements
+	spec.Loop = geo.Loop
+	if lib.IsNull(spec.Feedpt.Gap) {
+		spec.Feedpt.Gap = geo.Nodes[0].Length
+	}
+	ant = lib.BuildAntenna(geo.Kind(), spec, geo.Nodes)
+	ant.Perf = perf
+	return
+}
+
+// parse contact sheet layout "<cols>x<rows>"
+func parseGrid(s string) (cols, rows int, err error) {
+	dims := strings.Split(s, "x")
+	if len(dims) == 2 {
+		cols, err = strconv.Atoi(dims[0])
+		if err == nil {
+			rows, err = strconv.Atoi(dims[1])
+		}
+	}
+	if len(dims) != 2 || err != nil || cols < 1 || rows < 1 {
+		err = fmt.Errorf("invalid grid '%s'", s)
+	}
+	return
+}
@@ -0,0 +1,136 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// compare the results of two databases (e.g. runs with different
+// optimizer settings); entries are matched by model directory and tag.
+func diffDatabases(db *lib.Database, args []string) {
+	// handle command-line arguments
+	var (
+		dbName string  // second database
+		target string  // compared performance value
+		lower  bool    // lower values are better
+		eps    float64 // threshold for unchanged values
+	)
+	fls := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fls.StringVar(&dbName, "db2", "", "second result database")
+	fls.StringVar(&target, "target", "Gmax", "performance value (or expression)")
+	fls.BoolVar(&lower, "lower", false, "lower values are better")
+	fls.Float64Var(&eps, "eps", 0.01, "threshold for unchanged values")
+	fls.Parse(args)
+	if len(dbName) == 0 {
+		log.Fatal("no second database specified")
+	}
+	db2, err := lib.OpenDatabase(dbName)
+	if err != nil {
+		log.Fatal("open db2: " + err.Error())
+	}
+	defer db2.Close()
+
+	// read both databases
+	rowsA, err := db.GetRows("", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	rowsB, err := db2.GetRows("", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ref := func(r *lib.Row) string {
+		_, fdir, ftag := r.Reference()
+		return fdir + "/" + ftag
+	}
+	listB := make(map[string]*lib.Row)
+	for _, r := range rowsB {
+		listB[ref(r)] = r
+	}
+
+	// match entries and compute changes (positive: better)
+	type entry struct {
+		ref    string
+		a, b   float64
+		change float64
+	}
+	var (
+		list  []*entry
+		onlyA int
+	)
+	for _, ra := range rowsA {
+		name := ref(ra)
+		rb, ok := listB[name]
+		if !ok {
+			onlyA++
+			continue
+		}
+		delete(listB, name)
+		e := &entry{ref: name, a: ra.Value(target), b: rb.Value(target)}
+		e.change = e.b - e.a
+		if lower {
+			e.change = -e.change
+		}
+		if math.IsNaN(e.change) {
+			continue
+		}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].change > list[j].change
+	})
+
+	// print table of changes
+	fmt.Printf("%-40s %12s %12s %12s\n", "Model", "A", "B", "Change")
+	better, worse, sum := 0, 0, 0.
+	for _, e := range list {
+		fmt.Printf("%-40s %12.4f %12.4f %+12.4f\n", e.ref, e.a, e.b, e.change)
+		switch {
+		case e.change > eps:
+			better++
+		case e.change < -eps:
+			worse++
+		}
+		sum += e.change
+	}
+	// print summary
+	fmt.Println()
+	fmt.Printf("Compared '%s' for %d models", target, len(list))
+	if lower {
+		fmt.Print(" (lower is better)")
+	}
+	fmt.Println(":")
+	fmt.Printf("    improved: %d\n", better)
+	fmt.Printf("   regressed: %d\n", worse)
+	fmt.Printf("   unchanged: %d (|change| <= %g)\n", len(list)-better-worse, eps)
+	if len(list) > 0 {
+		fmt.Printf(" mean change: %+.4f\n", sum/float64(len(list)))
+	}
+	fmt.Printf("   only in A: %d\n", onlyA)
+	fmt.Printf("   only in B: %d\n", len(listB))
+}
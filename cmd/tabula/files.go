@@ -24,8 +24,11 @@ import (
 	"flag"
 	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/bfix/antgen/lib"
 )
@@ -34,40 +37,109 @@ import (
 func importFromDirectory(db *lib.Database, in string, args []string) {
 	// handle command-line arguments
 	var (
-		set string // only import set with given prefix
+		set   string // only import set with given prefix
+		track bool   // store optimization tracks
 	)
 	fls := flag.NewFlagSet("import", flag.ContinueOnError)
 	fls.StringVar(&set, "set", "", "set prefix")
+	fls.BoolVar(&track, "track", false, "store optimization tracks")
 	fls.Parse(args)
 
-	// traverse directory and import model files
-	num := 0
-	if err := filepath.Walk(in, func(path string, info fs.FileInfo, err error) error {
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".nec") {
-			if len(set) > 0 && !strings.HasPrefix(path, in+"/"+set) {
-				return nil
-			}
-			log.Printf(">>> %s", path)
+	// import model files (of given set)
+	num, err := importModels(db, in, track, func(path string) bool {
+		return len(set) == 0 || strings.HasPrefix(path, in+"/"+set)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Done: %d models imported.", num)
+}
 
-			// extract information from model file
-			p, ok, err := lib.ParseMdlParamsFromNEC(path, in)
-			if err != nil {
-				log.Printf("ERROR: %s", err.Error())
-				return nil
+// importModels traverses a directory and imports all model files accepted
+// by the filter function (on their path). Model files are parsed by a pool
+// of workers (one per CPU); the records are inserted by a single inserter
+// in one transaction. Returns the number of imported models.
+func importModels(db *lib.Database, in string, track bool, accept func(path string) bool) (num int, err error) {
+	paths := make(chan string)
+	recs := make(chan *lib.Record)
+
+	// parse model files
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if rec := parseModel(path, in, track); rec != nil {
+					recs <- rec
+				}
 			}
-			if !ok {
-				log.Printf("FAILED parsing %s", path)
-				return nil
+		}()
+	}
+	// traverse directory
+	var walkErr error
+	go func() {
+		walkErr = filepath.Walk(in, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
 			}
-			if err = db.Insert(p); err != nil {
-				log.Printf("ERROR: %s", err.Error())
-				return nil
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".nec") && accept(path) {
+				paths <- path
 			}
-			num++
+			return nil
+		})
+		close(paths)
+		wg.Wait()
+		close(recs)
+	}()
+
+	// insert records
+	var batch *lib.Batch
+	if batch, err = db.BeginBatch(); err != nil {
+		// drain pipeline
+		for range recs {
+		}
+		return
+	}
+	for rec := range recs {
+		if err := batch.Insert(rec); err != nil {
+			log.Printf("ERROR: %s", err.Error())
+			continue
 		}
+		num++
+	}
+	if err = batch.Commit(); err != nil {
+		num = 0
+		return
+	}
+	err = walkErr
+	return
+}
+
+// parseModel extracts the model parameters from a model file (and reads
+// the track file if requested). Errors are logged; returns nil on error.
+func parseModel(path, in string, track bool) (rec *lib.Record) {
+	log.Printf(">>> %s", path)
+	p, ok, err := lib.ParseMdlParamsFromNEC(path, in)
+	if err != nil {
+		log.Printf("ERROR: %s: %s", path, err.Error())
 		return nil
-	}); err != nil {
-		log.Fatal(err)
 	}
-	log.Printf("Done: %d models imported.", num)
+	if !ok {
+		log.Printf("FAILED parsing %s", path)
+		return nil
+	}
+	// read track file (if requested and available)
+	if track {
+		dir, name := filepath.Split(path)
+		name = strings.Replace(name, "model-", "track-", 1)
+		name = strings.TrimSuffix(name, ".nec")
+		if name, err = lib.FindFile(filepath.Join(dir, name)); err == nil {
+			p.Track, err = os.ReadFile(name)
+		}
+		if err != nil {
+			log.Printf("WARN: no track for %s", path)
+		}
+	}
+	return p
 }
@@ -0,0 +1,104 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// show best performance achievable for a given antenna size (gain-vs-size
+// frontier) of a plot set
+func showFrontier(db *lib.Database, args []string) {
+	// handle command-line arguments
+	var (
+		set     string // plot set (model directory)
+		size    string // size measure
+		target  string // performance value
+		buckets int    // number of size buckets
+		where   string // filter expression
+		out     string // plot file
+	)
+	fls := flag.NewFlagSet("frontier", flag.ContinueOnError)
+	fls.StringVar(&set, "set", "", "plot set (model directory)")
+	fls.StringVar(&size, "size", "Extent", "size measure (plot value or expression)")
+	fls.StringVar(&target, "target", "Gmax", "performance value (or expression)")
+	fls.IntVar(&buckets, "buckets", 20, "number of size buckets")
+	fls.StringVar(&where, "where", "", "filter expression")
+	fls.StringVar(&out, "out", "frontier.svg", "plot file (SVG)")
+	fls.Parse(args)
+	if len(set) == 0 {
+		log.Fatal("no plot set specified")
+	}
+
+	// get models of plot set
+	clause := "fdir = ?"
+	if len(where) > 0 {
+		cond, err := lib.ParseWhere(where)
+		if err != nil {
+			log.Fatal(err)
+		}
+		clause += " and (" + cond + ")"
+	}
+	rows, err := db.GetRows(clause, "", set)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(rows) == 0 {
+		log.Fatalf("no models in set '%s'", set)
+	}
+
+	// models imported without size need to be re-imported
+	if !slices.ContainsFunc(rows, func(r *lib.Row) bool {
+		return !math.IsNaN(r.Value("WireLen"))
+	}) {
+		log.Fatalf("no size of models in set '%s' (re-import the models)", set)
+	}
+	// compute frontier
+	front, err := lib.Frontier(rows, size, target, buckets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	best := front[len(front)-1].Value
+
+	// print table of frontier models
+	fmt.Printf("%12s %12s %12s  %s\n", size, target, "Delta", "Model")
+	for _, pt := range front {
+		_, fdir, ftag := pt.Row.Reference()
+		fmt.Printf("%12.4f %12.4f %+12.4f  %s/%s\n", pt.Size, pt.Value, pt.Value-best, fdir, ftag)
+	}
+
+	// plot frontier
+	plt, err := lib.PlotFrontier(rows, front, size, target, "svg")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(out, []byte(plt), 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d of %d models on frontier (plot in %s)", len(front), len(rows), out)
+}
@@ -0,0 +1,92 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// GET /geometry?dir=<dir>&tag=<tag>: render the geometry of a model as SVG
+func geometryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	svg, err := renderGeometry(q.Get("dir"), q.Get("tag"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = w.Write(svg)
+}
+
+// render geometry of a model in the database. Only models listed in the
+// database are rendered (no access to arbitrary files).
+func renderGeometry(dir, tag string) (svg []byte, err error) {
+	// get model from database
+	var row *lib.Row
+	var freq int64
+	if row, freq, err = db.Model(dir, tag); err != nil {
+		return
+	}
+	// read geometry file
+	var fName string
+	if fName, err = lib.FindFile(base + "/" + dir + "/geometry-" + tag); err != nil {
+		return
+	}
+	var body []byte
+	if body, err = os.ReadFile(fName); err != nil {
+		return
+	}
+	geo := new(lib.Geometry)
+	if err = lib.DecodeData(body, geo); err != nil {
+		return
+	}
+	// build antenna
+	spec := new(lib.Specification)
+	spec.Source.Freq = freq
+	spec.Wire = geo.Wire
+	spec.Feedpt = geo.Feedpt
+	spec.Elements = geo.Elements
+	spec.Loop = geo.Loop
+	ant := lib.BuildAntenna(geo.Kind(), spec, geo.Nodes)
+	ant.Perf.Gain = &lib.Gain{
+		Max:  row.Value("Gmax"),
+		Mean: row.Value("Gmean"),
+		SD:   row.Value("SD"),
+	}
+	ant.Perf.Z = complex(row.Value("Zr"), row.Value("Zi"))
+
+	// render to SVG
+	var canvas *lib.SVGCanvas
+	if canvas, err = lib.NewSVGCanvas(0, 0, 0); err != nil {
+		return
+	}
+	canvas.SetScale(true)
+	canvas.Show(ant, -1, dir+"/"+tag)
+	svg = canvas.Bytes()
+	return
+}
@@ -0,0 +1,96 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// list columns for 'list' items
+var listItems = map[string]string{
+	"sets":   "fdir",
+	"models": "mdl",
+	"gens":   "gen",
+	"opts":   "opt",
+	"mats":   "mat",
+	"freqs":  "freq",
+}
+
+// list distinct values in the database (one per line on stdout)
+func listValues(db *lib.Database, args []string) {
+	// handle command-line arguments
+	var set string // plot set (k/param only)
+	fls := flag.NewFlagSet("list", flag.ContinueOnError)
+	fls.StringVar(&set, "set", "", "plot set (k, param)")
+	fls.Parse(args)
+	if fls.NArg() != 1 {
+		log.Fatal("usage: list [-set <set>] sets|models|gens|opts|mats|freqs|bands|k|param")
+	}
+	var (
+		list []string
+		err  error
+	)
+	switch item := fls.Arg(0); item {
+	case "k", "param":
+		var kList, pList []float64
+		if kList, pList, err = db.VarLists(set); err != nil {
+			log.Fatal(err)
+		}
+		vals := kList
+		if item == "param" {
+			vals = pList
+		}
+		for _, v := range vals {
+			list = append(list, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	case "bands":
+		var freqs []string
+		if freqs, err = db.Distinct("freq"); err != nil {
+			log.Fatal(err)
+		}
+		for _, f := range freqs {
+			var freq int64
+			if freq, err = strconv.ParseInt(f, 10, 64); err != nil {
+				log.Fatal(err)
+			}
+			if band := lib.BandName(freq); !slices.Contains(list, band) {
+				list = append(list, band)
+			}
+		}
+	default:
+		column, ok := listItems[item]
+		if !ok {
+			log.Fatalf("unknown list item '%s'", item)
+		}
+		if list, err = db.Distinct(column); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, val := range list {
+		fmt.Println(val)
+	}
+}
@@ -38,13 +38,27 @@ var (
 func main() {
 	// handle command-line arguments
 	args := os.Args[1:]
-	var dbName, in string
+	var dbName, in, config string
 	fs := flag.NewFlagSet("main", flag.ContinueOnError)
 	fs.StringVar(&dbName, "db", "./out/results.db", "result database")
 	fs.StringVar(&in, "in", "./out", "model base directory")
+	fs.StringVar(&config, "config", "", "configuration file")
 	fs.Parse(args)
 	args = fs.Args()
 
+	// handle optional configuration file
+	if len(config) > 0 {
+		if err := lib.ReadConfig(config); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// commands without database
+	if len(args) > 0 && args[0] == "plot-steps" {
+		plotSteps(args[1:])
+		return
+	}
+
 	// open database
 	if len(dbName) == 0 {
 		flag.Usage()
@@ -66,12 +80,15 @@ func main() {
 		plotToFile(db, in, args[1:])
 	case "show-best":
 		showBest(db, in, args[1:])
+	case "replay":
+		replay(db, args[1:])
+	case "diff":
+		diffDatabases(db, args[1:])
 	case "stats":
-		stats := db.Stats()
-		log.Println("Database statistics:")
-		log.Printf("       Number of antennas: %10d", stats.NumAnt)
-		log.Printf("  Number of optimizations: %10d", stats.NumSteps)
-		log.Printf("    Number of simulations: %10d", stats.NumSims)
-		log.Printf("             Elapsed time: %s", stats.Duration)
+		showStats(db, args[1:])
+	case "frontier":
+		showFrontier(db, args[1:])
+	case "list":
+		listValues(db, args[1:])
 	}
 }
@@ -28,11 +28,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/bfix/antgen/lib"
+	"gonum.org/v1/plot/plotter"
 )
 
 // Plot data from database
@@ -48,26 +51,16 @@ func plotToFile(db *lib.Database, _ string, args []string) {
 	fs.StringVar(&fOut, "out", "out.svg", "output file (SVG)")
 	fs.Parse(args)
 
-	// build selection
-	sel := lib.NewSelection(target)
-
 	// get plot sets
 	s := strings.Split(sets, ",")
-	if len(s) == 0 {
-		log.Fatal("missing plot sets")
-	}
 	if len(s) > lib.NumPlots {
 		log.Printf("WARN: plot sets trimmed to %d", lib.NumPlots)
 		s = s[:lib.NumPlots]
 	}
-	for i, set := range s {
-		t := strings.Split(set, ":")
-		sel.Sets[i] = &lib.PlotSet{
-			Tag:  t[0],
-			Dir:  t[1],
-			Kidx: -1,
-			Pidx: -1,
-		}
+	// build selection
+	sel, err := parseSelection(db, target, "", s)
+	if err != nil {
+		log.Fatal(err)
 	}
 	out, err := lib.Plotter(db, sel, "svg")
 	if err != nil {
@@ -79,6 +72,90 @@ func plotToFile(db *lib.Database, _ string, args []string) {
 	}
 }
 
+// parseSelection builds a plot selection from a list of plot sets; each
+// set is specified as "<tag>:<dir>[:<k>[:<param>]]" (an empty or missing
+// k/param value selects all values).
+func parseSelection(db *lib.Database, target, target2 string, sets []string) (sel *lib.Selection, err error) {
+	if len(sets) > lib.NumPlots {
+		return nil, fmt.Errorf("too many plot sets (max. %d)", lib.NumPlots)
+	}
+	sel = lib.NewSelection(target)
+	sel.Target2 = target2
+	for i, set := range sets {
+		t := strings.Split(set, ":")
+		if len(t) < 2 || len(t[1]) == 0 {
+			return nil, fmt.Errorf("invalid plot set '%s'", set)
+		}
+		ps := lib.NewPlotSet(t[1])
+		ps.Tag = t[0]
+		if ps.Klist, ps.Plist, err = db.VarLists(ps.Dir); err != nil {
+			return
+		}
+		// fixed parameter values
+		for j, name := range []string{"k", "param"} {
+			if len(t) < j+3 || len(t[j+2]) == 0 {
+				continue
+			}
+			var v float64
+			if v, err = strconv.ParseFloat(t[j+2], 64); err != nil {
+				return
+			}
+			idx := ps.Index(v, name)
+			if idx < 0 {
+				return nil, fmt.Errorf("plot set '%s': no %s=%g", ps.Dir, name, v)
+			}
+			if name == "k" {
+				ps.Kidx = idx
+			} else {
+				ps.Pidx = idx
+			}
+		}
+		sel.Sets[i] = ps
+	}
+	return
+}
+
+// Plot convergence graphs from (JSON-lines) step logs
+func plotSteps(args []string) {
+	var (
+		metric string
+		fOut   string
+	)
+	fs := flag.NewFlagSet("plot-steps", flag.ContinueOnError)
+	fs.StringVar(&metric, "metric", "Gmax", "plotted metric")
+	fs.StringVar(&fOut, "out", "steps.svg", "output file (SVG)")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal("missing step log(s)")
+	}
+
+	// read step logs (one graph per log)
+	var (
+		names  []string
+		series []plotter.XYs
+	)
+	for _, fName := range fs.Args() {
+		f, err := os.Open(fName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := lib.ReadSteps(f, metric)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", fName, err.Error())
+		}
+		names = append(names, filepath.Base(fName))
+		series = append(series, data)
+	}
+	out, err := lib.PlotSteps(names, series, metric, "svg")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(fOut, []byte(out), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
 //======================================================================
 // handle plot request
 //======================================================================
@@ -98,9 +175,12 @@ type PlotData struct {
 	Stats  *lib.DbStats // database statistics
 
 	Targets []string                // list of possible plot targets
+	Values  []string                // list of possible secondary targets
+	Expr    string                  // custom target expression
 	Sets    map[string]*lib.PlotSet // list of available plot sets
 	Styles  [lib.NumPlots]string    // list of plot styles
 
+	Runs   bool              // optimization runs enabled
 	Select *lib.Selection    // current selection
 	Graphs map[string]string // SVG-encoded graphs
 	Msgs   []*Message        // list of messages
@@ -123,6 +203,15 @@ func plotHandler(w http.ResponseWriter, r *http.Request) {
 		pd.Styles[i] = s
 	}
 
+	// selection to work on (a private copy in read-only mode, so requests
+	// don't change the shared selection)
+	cur := &sel
+	if readonly {
+		cur = sel.Clone()
+	}
+	// plot sets available for this request
+	current := plotSets()
+
 	// check for POST request: generate plot from user settings if true
 	if r.Method == "POST" {
 		// parse form data
@@ -146,17 +235,20 @@ func plotHandler(w http.ResponseWriter, r *http.Request) {
 			switch parts[0] {
 			case "target":
 				// value to be plotted
-				sel.Target = value
+				cur.Target = value
+			case "target2":
+				// secondary value (right Y axis)
+				cur.Target2 = value
 			case "plotset":
 				var idx int
 				if idx, err = strconv.Atoi(parts[1]); err != nil {
 					pd.AddMsg("ERROR", "plotset index: "+err.Error())
 					break
 				}
-				ps := sel.Sets[idx]
+				ps := cur.Sets[idx]
 				if ps == nil {
 					ps = lib.NewPlotSet("")
-					sel.Sets[idx] = ps
+					cur.Sets[idx] = ps
 				}
 				switch parts[2] {
 				// parameters
@@ -191,16 +283,24 @@ func plotHandler(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		// custom expression overrides the selected target
+		if expr := strings.TrimSpace(values["expr"]); len(expr) > 0 {
+			if _, err = lib.PlotExpr(expr); err != nil {
+				pd.AddMsg("ERROR", err.Error())
+			} else {
+				cur.Target = expr
+			}
+		}
 		// set parameter ranges and remove empty plot sets
-		for i, ps := range sel.Sets {
+		for i, ps := range cur.Sets {
 			if ps == nil {
 				continue
 			}
 			if len(ps.Dir) == 0 {
-				sel.Sets[i] = nil
+				cur.Sets[i] = nil
 				continue
 			}
-			if s, ok := sets[ps.Dir]; ok {
+			if s, ok := current[ps.Dir]; ok {
 				ps.Klist = s.Klist
 				ps.Plist = s.Plist
 			} else {
@@ -208,15 +308,20 @@ func plotHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		// create plot
-		if pd.Graphs, err = lib.Plotter(db, &sel, "svg"); err != nil {
+		if pd.Graphs, err = lib.Plotter(db, cur, "svg"); err != nil {
 			pd.AddMsg("ERROR", err.Error())
 		}
 	}
 	// collect information for view
 	pd.Prefix = prefix
-	pd.Select = &sel
+	pd.Runs = len(antgen) > 0 && !readonly
+	pd.Select = cur
 	pd.Targets = append(lib.PlotValues, lib.PlotSpecial...)
-	pd.Sets = sets
+	pd.Values = lib.PlotValues
+	if !slices.Contains(pd.Targets, cur.Target) {
+		pd.Expr = cur.Target
+	}
+	pd.Sets = current
 
 	// show plot view
 	renderPage(w, pd, "plot")
@@ -228,8 +333,14 @@ func plotHandler(w http.ResponseWriter, r *http.Request) {
 
 // render a webpage with given data and template reference
 func renderPage(w io.Writer, data interface{}, body string) {
+	// get (current) templates
+	tpls, err := templates()
+	if err != nil {
+		io.WriteString(w, "templates: "+err.Error())
+		return
+	}
 	// create content section
-	t := tpl.Lookup(body)
+	t := tpls.Lookup(body)
 	if t == nil {
 		io.WriteString(w, "No template '"+body+"' found")
 		return
@@ -240,7 +351,7 @@ func renderPage(w io.Writer, data interface{}, body string) {
 		return
 	}
 	// emit final page
-	t = tpl.Lookup("main")
+	t = tpls.Lookup("main")
 	if t == nil {
 		io.WriteString(w, "No main template found")
 		return
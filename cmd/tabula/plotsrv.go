@@ -21,6 +21,7 @@
 package main
 
 import (
+	"crypto/subtle"
 	"embed"
 	"errors"
 	"flag"
@@ -28,9 +29,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"text/template"
 	"time"
@@ -42,37 +46,154 @@ import (
 var fsys embed.FS
 
 // shared variables with request handlers.
-// N.B.: database changes after application start may not be accessable.
+// N.B.: database changes after application start are accessible after
+// the plot sets are reloaded (on SIGHUP).
 var (
-	tpl    *template.Template      // HTML templates
-	srv    *http.Server            // HTTP server
-	prefix string                  // URL prefix (if behind reverse proxy)
-	sets   map[string]*lib.PlotSet // list of available plot sets
+	tpl      *template.Template      // HTML templates
+	srv      *http.Server            // HTTP server
+	prefix   string                  // URL prefix (if behind reverse proxy)
+	base     string                  // model base directory
+	readonly bool                    // no changes to shared server state
+	tplDir   string                  // external template directory
+	sets     map[string]*lib.PlotSet // list of available plot sets
+	setsLock sync.RWMutex            // guard for plot sets
 )
 
 // application entry point
-func plotsrv(db *lib.Database, _ string, args []string) {
+func plotsrv(db *lib.Database, in string, args []string) {
 	// handle command-line arguments
 	var (
 		listen string // HTTP server listen
-		prefix string // HTTP URL prefix
+		auth   string // basic authentication ("user:pass")
+		exe    string // antgen executable (optimization runs)
+		limit  int    // max. number of concurrent runs
 		err    error
 	)
 	fs := flag.NewFlagSet("srv", flag.ContinueOnError)
 	fs.StringVar(&listen, "l", "localhost:12345", "Listen address for web GUI")
 	fs.StringVar(&prefix, "p", "", "URL prefix")
+	fs.BoolVar(&readonly, "readonly", false, "don't change shared server state")
+	fs.StringVar(&auth, "auth", "", "basic authentication (user:pass)")
+	fs.StringVar(&tplDir, "templates", "", "template directory (reloaded on request)")
+	fs.StringVar(&exe, "antgen", "", "antgen executable (enables optimization runs)")
+	fs.IntVar(&limit, "runs", 1, "max. number of concurrent optimization runs")
 	fs.Parse(args)
 
 	// normalize prefix (no trailing slash)
 	prefix = strings.TrimRight(prefix, "/")
+	base = in
 
 	// collect sets from database
-	if sets, err = db.ListPlotSets(); err != nil {
+	if err = reloadPlotSets(); err != nil {
 		log.Fatal("list sets: " + err.Error())
 	}
 	// read and prepare templates
-	tpl = template.New("gui")
-	tpl.Funcs(template.FuncMap{
+	if tpl, err = loadTemplates(tplDir); err != nil {
+		log.Fatal("tpl: " + err.Error())
+	}
+
+	// define request handlers
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", plotHandler)
+	mux.HandleFunc("/geometry", geometryHandler)
+	apiHandlers(mux)
+	if len(exe) > 0 {
+		if exe, err = exec.LookPath(exe); err != nil {
+			log.Fatal("antgen: " + err.Error())
+		}
+		runHandlers(mux, exe, limit)
+	}
+
+	var handler http.Handler = mux
+	if len(auth) > 0 {
+		user, pass, ok := strings.Cut(auth, ":")
+		if !ok || len(user) == 0 {
+			log.Fatal("auth: expected 'user:pass'")
+		}
+		handler = basicAuth(mux, user, pass)
+	}
+
+	// prepare HTTP server
+	srv = &http.Server{
+		Addr:              listen,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       300 * time.Second,
+		ReadHeaderTimeout: 20 * time.Second,
+		Handler:           handler,
+	}
+	// run HTTP server in go-routine
+	go func() {
+		log.Printf("Starting HTTP server at %s...", listen)
+		if err := srv.ListenAndServe(); err != nil {
+			log.Println("GUI listener: " + err.Error())
+		}
+	}()
+
+	// handle OS signals
+	sigCh := make(chan os.Signal, 5)
+	signal.Notify(sigCh)
+	for sig := range sigCh {
+		switch sig {
+		case syscall.SIGKILL, syscall.SIGINT, syscall.SIGTERM:
+			log.Printf("Terminating service (on signal '%s')\n", sig)
+			return
+		case syscall.SIGHUP:
+			// reload plot sets (database changes)
+			if err = reloadPlotSets(); err != nil {
+				log.Println("SIGHUP: list sets: " + err.Error())
+				break
+			}
+			log.Printf("SIGHUP: %d plot sets loaded", len(plotSets()))
+		case syscall.SIGCHLD:
+			// optimization run finished
+		case syscall.SIGURG:
+			// TODO: https://github.com/golang/go/issues/37942
+		default:
+			log.Println("Unhandled signal: " + sig.String())
+		}
+	}
+}
+
+// basicAuth requires HTTP basic authentication for all requests
+func basicAuth(h http.Handler, user, pass string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(u), []byte(user)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(p), []byte(pass)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="tabula", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// plotSets returns the current list of available plot sets. The list is
+// replaced (not changed) on reload, so it can be used without locking.
+func plotSets() map[string]*lib.PlotSet {
+	setsLock.RLock()
+	defer setsLock.RUnlock()
+	return sets
+}
+
+// reloadPlotSets reads the list of available plot sets from the database
+func reloadPlotSets() (err error) {
+	var list map[string]*lib.PlotSet
+	if list, err = db.ListPlotSets(); err != nil {
+		return
+	}
+	setsLock.Lock()
+	sets = list
+	setsLock.Unlock()
+	return
+}
+
+// loadTemplates reads the HTML templates from a directory (all "*.htpl"
+// files) or from the embedded file system (if no directory is given).
+func loadTemplates(dir string) (t *template.Template, err error) {
+	t = template.New("gui").Funcs(template.FuncMap{
 		"msgClass": func(mode string) string {
 			cl := "stat-"
 			switch mode {
@@ -119,46 +240,31 @@ func plotsrv(db *lib.Database, _ string, args []string) {
 			n := len(list)
 			return fmt.Sprintf("%s - %s", trim(list[0]), trim(list[n-1]))
 		},
+		// tag of the best model (max. gain) in a plot set selection
+		"bestTag": func(ps *lib.PlotSet) string {
+			k, param := ps.Params()
+			set, err := db.Set(ps.Dir, lib.NewIndex(k, param))
+			if err != nil {
+				return ""
+			}
+			if r := set.Best("Gmax"); r != nil {
+				_, _, tag := r.Reference()
+				return tag
+			}
+			return ""
+		},
 	})
-	if _, err := tpl.ParseFS(fsys, "gui.htpl"); err != nil {
-		log.Fatal("tpl: " + err.Error())
+	if len(dir) == 0 {
+		return t.ParseFS(fsys, "gui.htpl")
 	}
+	return t.ParseGlob(filepath.Join(dir, "*.htpl"))
+}
 
-	// define request handlers
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", plotHandler)
-
-	// prepare HTTP server
-	srv = &http.Server{
-		Addr:              listen,
-		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      10 * time.Second,
-		IdleTimeout:       300 * time.Second,
-		ReadHeaderTimeout: 20 * time.Second,
-		Handler:           mux,
-	}
-	// run HTTP server in go-routine
-	go func() {
-		log.Printf("Starting HTTP server at %s...", listen)
-		if err := srv.ListenAndServe(); err != nil {
-			log.Println("GUI listener: " + err.Error())
-		}
-	}()
-
-	// handle OS signals
-	sigCh := make(chan os.Signal, 5)
-	signal.Notify(sigCh)
-	for sig := range sigCh {
-		switch sig {
-		case syscall.SIGKILL, syscall.SIGINT, syscall.SIGTERM:
-			log.Printf("Terminating service (on signal '%s')\n", sig)
-			return
-		case syscall.SIGHUP:
-			log.Println("SIGHUP")
-		case syscall.SIGURG:
-			// TODO: https://github.com/golang/go/issues/37942
-		default:
-			log.Println("Unhandled signal: " + sig.String())
-		}
+// templates returns the HTML templates; templates in an external directory
+// are reloaded on every request.
+func templates() (t *template.Template, err error) {
+	if len(tplDir) == 0 {
+		return tpl, nil
 	}
+	return loadTemplates(tplDir)
 }
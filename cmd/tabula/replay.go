@@ -0,0 +1,65 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// replay optimization track stored in the database
+func replay(db *lib.Database, args []string) {
+	// handle command-line arguments
+	var (
+		dir  string // model directory (relative)
+		tag  string // model tag
+		eval bool   // evaluate performance
+	)
+	fls := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fls.StringVar(&dir, "dir", "", "model directory")
+	fls.StringVar(&tag, "tag", "", "model tag")
+	fls.BoolVar(&eval, "eval", false, "evaluate at operating frequency")
+	fls.Parse(args)
+	if len(tag) == 0 {
+		log.Fatal("no model tag specified")
+	}
+
+	// get track (and ground and feedpoint spec) from database
+	spec, track, err := db.Track(dir, tag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// setup rendering
+	side := 1.1 * float64(track.Num) * track.SegL
+	render, err := lib.NewSDLCanvas(1024, 768, side)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		if err := track.Replay(render, spec, eval, nil); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	render.Run(nil)
+}
@@ -0,0 +1,325 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+//======================================================================
+// Optimization runs (started from the plot server)
+//======================================================================
+
+// RunOptions are the 'antgen' options accepted for optimization runs;
+// options referencing files on the server (like '-config' or '-keepout'),
+// the output directory (and its layout) and generators, models or targets
+// loading files or code (see runRefs) are not available.
+var RunOptions = []string{
+	"freq", "k", "param", "sweep", "wire", "ground", "source", "feedpt",
+	"gen", "genseed", "model", "opt", "smoothpenalty", "seed", "iter",
+	"tag", "prefix", "crossings", "eff", "twostage", "q", "pattern",
+}
+
+// run options that are part of output file names
+var runNameOptions = []string{"tag", "prefix"}
+
+// run options that select a generator, model or optimization target
+var runRefOptions = []string{"gen", "model", "opt"}
+
+// references to files or code on the server (Lua scripts, geometry
+// files and plugins) in generators, models and targets
+var runRefs = []string{"lua", "geo", "plugin"}
+
+// maximum number of output lines kept for a run
+const runMaxLines = 1000
+
+// Run is an optimization run (antgen process)
+type Run struct {
+	sync.Mutex
+
+	ID    int       // run identifier
+	Args  []string  // antgen command line arguments
+	Start time.Time // start of run
+
+	lines []string // output lines
+	skip  int      // number of dropped output lines
+	done  bool     // run finished
+	err   error    // run failed
+	num   int      // number of imported models
+}
+
+// add output line
+func (r *Run) add(line string) {
+	r.Lock()
+	defer r.Unlock()
+	r.lines = append(r.lines, line)
+	if n := len(r.lines); n > runMaxLines {
+		drop := n - runMaxLines/2
+		r.lines = slices.Clone(r.lines[drop:])
+		r.skip += drop
+	}
+}
+
+// output returns the output lines starting at given position; returns
+// the next position and the run status.
+func (r *Run) output(pos int) (lines []string, next int, done bool) {
+	r.Lock()
+	defer r.Unlock()
+	pos = max(pos-r.skip, 0)
+	lines = slices.Clone(r.lines[pos:])
+	return lines, r.skip + len(r.lines), r.done
+}
+
+// shared run state
+var (
+	antgen   string               // path to antgen executable (runs enabled)
+	runSlots chan struct{}        // concurrency limit for runs
+	runs     = make(map[int]*Run) // list of runs
+	runsLock sync.Mutex           // guard for runs
+)
+
+// register run handlers (runs must be enabled)
+func runHandlers(mux *http.ServeMux, exe string, limit int) {
+	antgen = exe
+	runSlots = make(chan struct{}, max(limit, 1))
+	mux.HandleFunc("/api/run", apiRun)
+}
+
+// POST /api/run: start an optimization run with options (JSON object)
+// GET /api/run?id=<id>: progress of a run (server-sent events)
+func apiRun(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		if readonly {
+			apiReply(w, http.StatusForbidden, &APIError{"read-only server"})
+			return
+		}
+		opts := make(map[string]string)
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			apiReply(w, http.StatusBadRequest, &APIError{err.Error()})
+			return
+		}
+		args, err := runArgs(opts)
+		if err != nil {
+			apiReply(w, http.StatusBadRequest, &APIError{err.Error()})
+			return
+		}
+		// check concurrency limit
+		select {
+		case runSlots <- struct{}{}:
+		default:
+			apiReply(w, http.StatusServiceUnavailable, &APIError{"too many runs"})
+			return
+		}
+		run := startRun(args)
+		apiReply(w, http.StatusAccepted, map[string]int{"id": run.ID})
+
+	case http.MethodGet:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		runsLock.Lock()
+		run, ok := runs[id]
+		runsLock.Unlock()
+		if err != nil || !ok {
+			apiReply(w, http.StatusNotFound, &APIError{"unknown run"})
+			return
+		}
+		runEvents(w, r, run)
+
+	default:
+		apiReply(w, http.StatusMethodNotAllowed, &APIError{"method not allowed"})
+	}
+}
+
+// runArgs assembles the antgen command line from run options
+func runArgs(opts map[string]string) (args []string, err error) {
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		if !slices.Contains(RunOptions, key) {
+			return nil, fmt.Errorf("option '%s' not allowed", key)
+		}
+		// values end up in comment lines of the model files
+		val := opts[key]
+		if strings.IndexFunc(val, unicode.IsControl) >= 0 {
+			return nil, fmt.Errorf("invalid value %q for option '%s'", val, key)
+		}
+		// file name parts must not leave the output directory
+		if slices.Contains(runNameOptions, key) && len(val) > 0 {
+			if strings.ContainsAny(val, `/\`) || strings.Contains(val, "..") || !filepath.IsLocal(val) {
+				return nil, fmt.Errorf("invalid value '%s' for option '%s'", val, key)
+			}
+		}
+		// no files or code from the server
+		if slices.Contains(runRefOptions, key) {
+			for _, item := range strings.Split(val, ",") {
+				ref, _, _ := strings.Cut(item, ":")
+				if slices.Contains(runRefs, strings.TrimSpace(ref)) {
+					return nil, fmt.Errorf("'%s' not allowed for option '%s'", item, key)
+				}
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-"+key+"="+opts[key])
+	}
+	// results are written to the model base directory
+	args = append(args, "-layout={band}/{model}", "-out="+base)
+	return
+}
+
+// startRun launches antgen; the output is collected for progress reports
+// and the results are imported into the database when the run finishes.
+func startRun(args []string) (run *Run) {
+	runsLock.Lock()
+	run = &Run{
+		ID:    len(runs) + 1,
+		Args:  args,
+		Start: time.Now(),
+	}
+	runs[run.ID] = run
+	runsLock.Unlock()
+
+	go func() {
+		defer func() { <-runSlots }()
+		log.Printf("Run #%d: antgen %s", run.ID, strings.Join(args, " "))
+		err := execRun(run)
+		num := 0
+		if err == nil {
+			// import new model files and update plot sets
+			num, err = importModels(db, base, false, func(path string) bool {
+				info, err := os.Stat(path)
+				return err == nil && !info.ModTime().Before(run.Start)
+			})
+			if err == nil {
+				err = reloadPlotSets()
+			}
+		}
+		if err != nil {
+			log.Printf("Run #%d: %s", run.ID, err.Error())
+		} else {
+			log.Printf("Run #%d: %d models imported", run.ID, num)
+		}
+		run.Lock()
+		run.done, run.err, run.num = true, err, num
+		run.Unlock()
+	}()
+	return
+}
+
+// execute antgen and collect its output (progress lines on stdout are
+// separated by carriage returns)
+func execRun(run *Run) (err error) {
+	cmd := exec.Command(antgen, run.Args...)
+	var outs [2]io.Reader
+	if outs[0], err = cmd.StdoutPipe(); err != nil {
+		return
+	}
+	if outs[1], err = cmd.StderrPipe(); err != nil {
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, out := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sc := bufio.NewScanner(out)
+			sc.Split(scanLines)
+			for sc.Scan() {
+				line := strings.TrimSpace(strings.ReplaceAll(sc.Text(), "\033[0K", ""))
+				if len(line) > 0 {
+					run.add(line)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return cmd.Wait()
+}
+
+// split output at line feeds and carriage returns
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// stream run output as server-sent events; the final "done" event holds
+// the result of the run.
+func runEvents(w http.ResponseWriter, r *http.Request, run *Run) {
+	// runs take longer than the server write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	tick := time.NewTicker(250 * time.Millisecond)
+	defer tick.Stop()
+	pos := 0
+	for {
+		lines, next, done := run.output(pos)
+		pos = next
+		for _, line := range lines {
+			fmt.Fprintf(w, "data: %s\n\n", line)
+		}
+		if done {
+			res := map[string]any{"id": run.ID, "imported": run.num}
+			if run.err != nil {
+				res["error"] = run.err.Error()
+			}
+			data, _ := json.Marshal(res)
+			fmt.Fprintf(w, "event: done\ndata: %s\n\n", data)
+			_ = rc.Flush()
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRunArgs(t *testing.T) {
+	defer func(b string) { base = b }(base)
+	base = "/models"
+	for _, tc := range []struct {
+		opts map[string]string
+		args []string // nil: rejected
+	}{
+		{
+			map[string]string{"freq": "435M", "gen": "walk:smooth=5,pin", "opt": "Gmax,Z"},
+			[]string{"-freq=435M", "-gen=walk:smooth=5,pin", "-opt=Gmax,Z", "-layout={band}/{model}", "-out=/models"},
+		},
+		{map[string]string{"model": "bend2d:parallel=4", "tag": "a1"}, []string{"-model=bend2d:parallel=4", "-tag=a1", "-layout={band}/{model}", "-out=/models"}},
+		{map[string]string{"config": "x.json"}, nil},
+		{map[string]string{"out": "/tmp"}, nil},
+		{map[string]string{"tag": "../x"}, nil},
+		{map[string]string{"prefix": "a/b"}, nil},
+		{map[string]string{"gen": "lua:/tmp/gen.lua"}, nil},
+		{map[string]string{"gen": "geo:/etc/passwd"}, nil},
+		{map[string]string{"opt": "plugin:x.so"}, nil},
+		{map[string]string{"opt": "Gmax, lua:eval.lua"}, nil},
+		{map[string]string{"model": "lua:model.lua"}, nil},
+		{map[string]string{"tag": "a\nCM x"}, nil},
+		{map[string]string{"freq": "435M\r"}, nil},
+	} {
+		args, err := runArgs(tc.opts)
+		if tc.args == nil {
+			if err == nil {
+				t.Errorf("%v: accepted as %v", tc.opts, args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %s", tc.opts, err)
+		} else if !slices.Equal(args, tc.args) {
+			t.Errorf("%v: got %v, want %v", tc.opts, args, tc.args)
+		}
+	}
+}
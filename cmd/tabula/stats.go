@@ -0,0 +1,66 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bfix/antgen/lib"
+)
+
+// show database statistics (optionally grouped by column)
+func showStats(db *lib.Database, args []string) {
+	// handle command-line arguments
+	var by string // group by column
+	fls := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fls.StringVar(&by, "by", "", "group by column ["+strings.Join(lib.StatsGroups, ",")+"]")
+	fls.Parse(args)
+
+	stats := db.Stats()
+	log.Println("Database statistics:")
+	log.Printf("       Number of antennas: %10d", stats.NumAnt)
+	log.Printf("  Number of optimizations: %10d", stats.NumSteps)
+	log.Printf("    Number of simulations: %10d", stats.NumSims)
+	log.Printf("             Elapsed time: %s", stats.Duration)
+	if len(by) == 0 {
+		return
+	}
+	groups, err := db.StatsBy(by)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	log.Printf("Statistics by '%s':", by)
+	log.Printf("%-24s %8s %9s %9s %9s %9s  %s", by, "Count", "avg Gmax", "avg Steps", "avg Sims", "best Gmax", "best model")
+	for _, key := range keys {
+		gs := groups[key]
+		log.Printf("%-24s %8d %9.3f %9.1f %9.1f %9.3f  %s",
+			key, gs.Count, gs.AvgGmax, gs.AvgSteps, gs.AvgSims, gs.BestGmax, gs.BestRef)
+	}
+}
@@ -22,34 +22,79 @@ package lib
 
 import (
 	"fmt"
+	"image/color"
 	"io"
-
-	necpp "github.com/ctdk/go-libnecpp"
+	"math"
+	"math/cmplx"
+	"slices"
 )
 
 // Antenna geometry, parameter and performance
 type Antenna struct {
-	kind   string       // kind of antenna
-	segs   []*Line      // antenna geometry
-	dia    float64      // constant wire diameter
-	excite int          // position of exitation segment
-	Lambda float64      // wavelength at operating frequency
-	Perf   *Performance // antenna performance
+	kind   string         // kind of antenna
+	segs   []*Line        // antenna geometry
+	dia    float64        // constant wire diameter
+	excite int            // position of exitation segment
+	volts  float64        // drive voltage at primary feed point
+	feeds  []Excitation   // additional feed points (phased arrays)
+	leg    int            // index of first leg segment (BuildAntenna)
+	legs   int            // number of leg segments (both legs)
+	lifted []int          // crossing segments lifted by FixGeometry
+	jumps  []Jumper       // planar wire crossings (FixJumper)
+	evalFn EvalFunc       // evaluation of antenna performance
+	colors []*color.RGBA  // segment colors for rendering (optional)
+	Lambda float64        // wavelength at operating frequency
+	Perf   *Performance   // antenna performance
+	Edges  []*Performance // performance at lower/upper band edge (optional)
+}
+
+// EvalFunc computes the performance of an antenna at a given frequency
+// (see Antenna.EvalStaged for the meaning of the proxy).
+type EvalFunc func(a *Antenna, freq int64, wire Wire, ground Ground, proxy *Proxy) (ok bool, err error)
+
+// Proxy is the first stage of a two-stage evaluation: it decides on the
+// scalar performance (gain, impedance) of a candidate if the full
+// performance is computed.
+type Proxy struct {
+	Accept    func(*Performance) bool // accept candidate for full evaluation
+	Impedance bool                    // Accept only depends on the impedance
+}
+
+// DefaultEval is the evaluator assigned to new antennas (NEC2 simulation).
+// Tests can replace it with an analytic model (e.g. EvalIdealDipole).
+var DefaultEval EvalFunc
+
+func init() {
+	DefaultEval = evalNEC
+}
+
+// Excitation of a wire segment (feed point)
+type Excitation struct {
+	Seg   int        // index of excited segment
+	Volts complex128 // applied voltage (magnitude and phase)
 }
 
 // NewAntenna instantiates a new kind of antenna
 func NewAntenna(kind string) *Antenna {
 	return &Antenna{
-		kind: kind,
-		segs: make([]*Line, 0),
-		Perf: new(Performance),
+		kind:   kind,
+		segs:   make([]*Line, 0),
+		volts:  Cfg.Sim.ExciteU,
+		evalFn: DefaultEval,
+		Perf:   new(Performance),
 	}
 }
 
+// SetEvaluator replaces the evaluator of the antenna
+func (a *Antenna) SetEvaluator(fn EvalFunc) {
+	a.evalFn = fn
+}
+
 // BuildAntenna from given geometry
 func BuildAntenna(kind string, spec *Specification, nodes []*Node) (ant *Antenna) {
 	ant = NewAntenna(kind)
 	ant.Lambda = spec.Source.Lambda()
+	ant.volts = spec.Source.Voltage()
 	ant.dia = spec.Wire.Diameter
 	d := spec.Feedpt.Gap
 	if IsNull(d) {
@@ -68,18 +113,160 @@ func BuildAntenna(kind string, spec *Specification, nodes []*Node) (ant *Antenna
 	}
 
 	ant.excite = 0
+	ant.leg = len(ant.segs)
 	dir := 0.
 	for _, node := range nodes {
 		dir += node.Theta
+		ant.Perf.Curv += math.Abs(node.Theta)
+		ant.Perf.Len += 2 * node.Length
 		end := pos.Move2D(node.Length, dir)
 		ant.Add(NewLine(pos, end))
 		ant.Add(NewLine(end.MirrorX(), pos.MirrorX()))
 		pos = end
 	}
-	ant.FixGeometry(2 * nodes[0].Length)
+	// close the geometry (loop antennas)
+	ant.legs = len(ant.segs) - ant.leg
+	tip := len(ant.segs) - 2
+	if spec.Loop {
+		ant.Add(NewLine(pos, pos.MirrorX()))
+	}
+	// crossing mode is validated by the applications (default: bridges)
+	mode, _ := ParseFixMode(Cfg.Sim.Crossings)
+	ant.FixGeometry(2*nodes[0].Length, mode)
+
+	// add end hats (not for loops); the hats are attached to the fixed
+	// tip and are not part of the crossing check (models must validate
+	// them, see HatSpokes)
+	if spec.Feedpt.HatSpokes > 0 && !spec.Loop {
+		ant.addHat(ant.segs[tip], spec.Feedpt.HatSpokes, spec.Feedpt.HatLength*ant.Lambda)
+	}
+
+	// add parasitic elements (after the driven element, so the feed
+	// point index is not affected)
+	for _, el := range spec.Elements {
+		e := *el
+		e.Offset[2] += spec.Ground.Height
+		ant.AddElement(&e)
+	}
+	return
+}
+
+// AddElement adds an unfed (parasitic) wire element (at absolute position)
+// to the antenna. The element segments are appended to the segment list
+// and are not connected to the driven element.
+func (a *Antenna) AddElement(el *Element) {
+	mirror := func(v Vec3) Vec3 {
+		return v.Sub(el.Offset).MirrorX().Add(el.Offset)
+	}
+	pos := el.Offset
+	dir := 0.
+	for _, node := range el.Nodes {
+		dir += node.Theta
+		end := pos.Move2D(node.Length, dir)
+		a.Add(NewLine(pos, end))
+		a.Add(NewLine(mirror(end), mirror(pos)))
+		pos = end
+	}
+}
+
+// addHat attaches a capacitive end hat to the tip of both legs. The tip
+// segment is on the positive x-axis side; the hat on the other leg is
+// mirrored.
+func (a *Antenna) addHat(tip *Line, spokes int, length float64) {
+	for _, s := range HatSpokes(tip, spokes, length) {
+		a.Add(s)
+		a.Add(NewLine(s.Start().MirrorX(), s.End().MirrorX()))
+	}
+}
+
+// HatSpokes returns the wires of a capacitive end hat (radial spokes
+// perpendicular to the wire) at the end of a tip segment. Models use it
+// to check that the hat of a geometry stays clear of other wires.
+func HatSpokes(tip *Line, spokes int, length float64) (list []*Line) {
+	// spokes are in the plane perpendicular to the tip direction
+	// (spanned by the in-plane normal and the z-axis)
+	d := tip.Dir().Norm()
+	n := NewVec3(-d[1], d[0], 0).Norm()
+	z := NewVec3(0, 0, 1)
+	end := tip.End()
+	for i := range spokes {
+		ang := CircAng * float64(i) / float64(spokes)
+		dir := n.Mult(math.Cos(ang)).Add(z.Mult(math.Sin(ang)))
+		list = append(list, NewLine(end, end.Add(dir.Mult(length))))
+	}
+	return
+}
+
+// clone antenna geometry (without performance data)
+func (a *Antenna) clone() *Antenna {
+	c := NewAntenna(a.kind)
+	c.segs, c.dia, c.excite, c.Lambda = a.segs, a.dia, a.excite, a.Lambda
+	c.feeds, c.volts, c.evalFn = slices.Clone(a.feeds), a.volts, a.evalFn
+	c.leg, c.legs, c.lifted, c.jumps = a.leg, a.legs, a.lifted, a.jumps
+	c.Perf.Curv, c.Perf.Len = a.Perf.Curv, a.Perf.Len
+	return c
+}
+
+// EvalBand evaluates the antenna performance at the center frequency and
+// (if the span is not zero) at the lower and upper band edges.
+func (a *Antenna) EvalBand(freq, span int64, wire Wire, ground Ground) (err error) {
+	a.Edges = nil
+	if span > 0 {
+		for _, f := range []int64{freq - span, freq + span} {
+			edge := a.clone()
+			if err = edge.Eval(f, wire, ground); err != nil {
+				return
+			}
+			a.Edges = append(a.Edges, edge.Perf)
+		}
+	}
+	return a.Eval(freq, wire, ground)
+}
+
+// EvalQ evaluates the antenna impedance at f-δ and f+δ (in addition to
+// the evaluation at center frequency f that must have been done before)
+// to estimate the antenna Q and the implied 2:1 SWR bandwidth.
+func (a *Antenna) EvalQ(freq, delta int64, wire Wire, ground Ground) (err error) {
+	var z [2]complex128
+	for i, f := range []int64{freq - delta, freq + delta} {
+		// only the impedance is needed (skip pattern and efficiency)
+		probe := a.clone()
+		reject := &Proxy{Accept: func(*Performance) bool { return false }, Impedance: true}
+		if _, err = probe.EvalStaged(f, wire, ground, reject); err != nil {
+			return
+		}
+		z[i] = probe.Perf.Z
+	}
+	a.Perf.Q, a.Perf.BW = AntennaQ(z[0], a.Perf.Z, z[1], float64(freq), float64(delta))
 	return
 }
 
+// EvalBaseline evaluates the straight dipole with the same wire length as
+// a geometry (same node lengths, no bends) at the source frequency. Only
+// the scalar performance (gain, impedance) is computed.
+func EvalBaseline(spec *Specification, nodes []*Node) (perf *Performance, err error) {
+	straight := make([]*Node, len(nodes))
+	for i, n := range nodes {
+		straight[i] = NewNode2D(n.Length, 0)
+	}
+	ant := BuildAntenna("straight", spec, straight)
+	scalar := &Proxy{Accept: func(*Performance) bool { return false }}
+	if _, err = ant.EvalStaged(spec.Source.Freq, spec.Wire, spec.Ground, scalar); err != nil {
+		return
+	}
+	return ant.Perf, nil
+}
+
+// Bounds returns the bounding box of all wire segments
+func (a *Antenna) Bounds() *BoundingBox {
+	box := NewBoundingBox()
+	for _, seg := range a.segs {
+		box.Include(seg.Start())
+		box.Include(seg.End())
+	}
+	return box
+}
+
 // Type of antenna
 func (a *Antenna) Type() string {
 	return a.kind
@@ -90,6 +277,24 @@ func (a *Antenna) SetExcitation(pos int) {
 	a.excite = pos
 }
 
+// AddExcitation adds a further feed point (in addition to the primary
+// feed point) with given voltage (magnitude and phase relative to the
+// drive voltage of the primary feed point) to the antenna.
+func (a *Antenna) AddExcitation(seg int, volts complex128) {
+	a.feeds = append(a.feeds, Excitation{Seg: seg, Volts: volts})
+}
+
+// Excitations returns all feed points of the antenna; the primary feed
+// point (with the drive voltage derived from the source) is always first.
+func (a *Antenna) Excitations() []Excitation {
+	v := complex(a.volts, 0)
+	list := []Excitation{{Seg: a.excite, Volts: v}}
+	for _, ex := range a.feeds {
+		list = append(list, Excitation{Seg: ex.Seg, Volts: v * ex.Volts})
+	}
+	return list
+}
+
 // Add segment to antenna geometry
 func (a *Antenna) Add(s *Line) {
 	a.segs = append(a.segs, s)
@@ -97,48 +302,30 @@ func (a *Antenna) Add(s *Line) {
 
 // Eval antenna performance at given frequency
 func (a *Antenna) Eval(freq int64, wire Wire, ground Ground) (err error) {
-	// allocate NEC2 context
-	var ctx *necpp.NecppCtx
-	if ctx, err = necpp.New(); err != nil {
-		return
-	}
-	defer ctx.Delete()
+	_, err = a.EvalStaged(freq, wire, ground, nil)
+	return
+}
 
-	// build antenna wire segments
-	a.Lambda = C / float64(freq)
-	dx := a.Lambda / 100
-	for i, seg := range a.segs {
-		k := max(1, min(100, int(seg.Length()/dx)))
-		start, end := seg.Start(), seg.End()
-		if err = ctx.Wire(i+1, k, start[0], start[1], start[2], end[0], end[1], end[2], a.dia/2, 1, 1); err != nil {
-			return
-		}
-	}
-	if err = ctx.GeometryComplete(necpp.GeoGroundPlaneFlag(ground.Mode)); err != nil {
-		return
-	}
-	// set ground parameters
-	if ground.Mode != 0 {
-		if err = ctx.GnCard(necpp.GroundTypeFlag(ground.Type), ground.NRadl, ground.Epse, ground.Sig, 0, 0, 0, 0); err != nil {
-			return
-		}
-	}
-	// set material for all segments
-	if !IsNull(wire.Conductivity) {
-		if err = ctx.LdCard(5, 0, 0, 0, wire.Conductivity, 0, 0); err != nil {
-			return
-		}
-	}
-	if !IsNull(wire.Inductance) {
-		if err = ctx.LdCard(2, 0, 0, 0, 0, wire.Inductance, 0); err != nil {
-			return
-		}
-	}
-	// specify evaluation parameters
-	if err = ctx.FrCard(necpp.Linear, 1, float64(freq)/1e6, 0); err != nil {
+// EvalStaged evaluates the antenna performance in two stages: the scalar
+// performance values (gain, impedance) are computed first; the radiation
+// pattern and efficiency (second simulation) are only computed if the
+// proxy (if defined) accepts the scalar performance. If the proxy only
+// depends on the impedance, the first stage skips the radiation pattern
+// (Perf.Gain then covers a single direction) and accepted candidates are
+// simulated again.
+func (a *Antenna) EvalStaged(freq int64, wire Wire, ground Ground, proxy *Proxy) (ok bool, err error) {
+	return a.evalFn(a, freq, wire, ground, proxy)
+}
+
+// evalNEC evaluates the antenna performance with a NEC2 simulation
+func evalNEC(a *Antenna, freq int64, wire Wire, ground Ground, proxy *Proxy) (ok bool, err error) {
+	// allocate simulator
+	var sim Simulator
+	if sim, err = NewSimulator(); err != nil {
 		return
 	}
-	if err = ctx.ExCard(necpp.VoltageApplied, a.excite+1, 1, 0, Cfg.Sim.ExciteU, 0, 0, 0, 0, 0); err != nil {
+	defer sim.Close()
+	if err = a.setupSim(sim, freq, wire, ground); err != nil {
 		return
 	}
 
@@ -149,24 +336,52 @@ func (a *Antenna) Eval(freq int64, wire Wire, ground Ground) (err error) {
 	//            YZ plane (azimuth = π/2 - Φ)
 	nTheta := int(180./Cfg.Sim.ThetaStep) + 1
 	nPhi := int(360./Cfg.Sim.PhiStep) + 1
-	if err = ctx.RpCard(necpp.Normal, nTheta, nPhi, necpp.MajorMinor, necpp.TotalNormalized,
-		necpp.PowerGain, necpp.NoAvg, 0, 0, Cfg.Sim.ThetaStep, Cfg.Sim.PhiStep, 0, 0); err != nil {
+	zOnly := proxy != nil && proxy.Impedance
+	if zOnly {
+		// impedance-only first stage: single direction
+		err = sim.Pattern(1, 1, 0, 0)
+	} else {
+		err = sim.Pattern(nTheta, nPhi, Cfg.Sim.ThetaStep, Cfg.Sim.PhiStep)
+	}
+	if err != nil {
 		return
 	}
 
 	// get simulated preformance result
-	a.Perf.Gain = new(Gain)
-	if a.Perf.Gain.Max, err = ctx.GainMax(0); err != nil {
+	if a.Perf.Gain, a.Perf.Z, err = sim.Results(); err != nil {
 		return
 	}
-	if a.Perf.Gain.Mean, err = ctx.GainMean(0); err != nil {
-		return
+
+	a.Perf.reset()
+
+	// input impedances of all feed points (phased arrays)
+	if fr, isFR := sim.(FeedReader); isFR && len(a.feeds) > 0 {
+		if a.Perf.FeedZ, err = fr.Impedances(); err != nil {
+			return
+		}
 	}
-	if a.Perf.Gain.SD, err = ctx.GainSd(0); err != nil {
+
+	// two-stage evaluation: stop if the candidate is rejected
+	if proxy != nil && !proxy.Accept(a.Perf) {
 		return
 	}
-	if a.Perf.Z, err = ctx.Impedance(0); err != nil {
-		return
+	if zOnly {
+		// accepted: full simulation
+		return evalNEC(a, freq, wire, ground, nil)
+	}
+	ok = true
+
+	// compute radiation efficiency (optional): compare with the gain
+	// of a lossless antenna with identical geometry (second simulation)
+	if Cfg.Sim.Efficiency {
+		a.Perf.Eff = 1
+		if !IsNull(wire.Conductivity) || !IsNull(wire.Inductance) {
+			ref := a.clone()
+			if err = ref.Eval(freq, Wire{Diameter: wire.Diameter}, ground); err != nil {
+				return
+			}
+			a.Perf.Eff = math.Pow(10, (a.Perf.Gain.Max-ref.Perf.Gain.Max)/10)
+		}
 	}
 
 	// get radiation pattern
@@ -181,7 +396,7 @@ func (a *Antenna) Eval(freq int64, wire Wire, ground Ground) (err error) {
 	var val float64
 	for theta := range nTheta {
 		for phi := range nPhi {
-			if val, err = ctx.Gain(0, theta, phi); err != nil {
+			if val, err = sim.Gain(theta, phi); err != nil {
 				return
 			}
 			a.Perf.Rp.Max = max(a.Perf.Rp.Max, val)
@@ -192,13 +407,191 @@ func (a *Antenna) Eval(freq int64, wire Wire, ground Ground) (err error) {
 	return
 }
 
+// build the antenna model in the simulator (wires, ground, load,
+// frequency and feed points)
+func (a *Antenna) setupSim(sim Simulator, freq int64, wire Wire, ground Ground) (err error) {
+	// build antenna wire segments
+	a.Lambda = C / float64(freq)
+	for i, seg := range a.segs {
+		if err = sim.Wire(i+1, a.numSegs(seg), seg.Start(), seg.End(), a.dia/2); err != nil {
+			return
+		}
+	}
+	if err = sim.GroundComplete(ground); err != nil {
+		return
+	}
+	// set material for all segments
+	if err = sim.Load(wire, freq); err != nil {
+		return
+	}
+	// specify evaluation parameters
+	if err = sim.Frequency(freq); err != nil {
+		return
+	}
+	// excite all feed points (the impedances of additional feed points
+	// are read back if the engine reports them, see FeedReader)
+	for _, ex := range a.Excitations() {
+		if err = sim.Excite(ex.Seg, ex.Volts); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// EvalCurrents simulates the antenna for its impedance and reads back the
+// wire currents: Perf.Currents holds the current at the center of each
+// antenna segment. Only a single direction is computed, so Perf.Gain is
+// not the gain summary of Eval. The NEC2 binding can't read currents,
+// so an external engine is required (see CurrentReader).
+func (a *Antenna) EvalCurrents(freq int64, wire Wire, ground Ground) (err error) {
+	var sim Simulator
+	if sim, err = engineSimulator(); err != nil {
+		return
+	}
+	defer sim.Close()
+	cr, ok := sim.(CurrentReader)
+	if !ok {
+		return fmt.Errorf("engine '%s' can't read back segment currents (use an external NEC2 program)", Cfg.Sim.Engine)
+	}
+	if err = a.setupSim(sim, freq, wire, ground); err != nil {
+		return
+	}
+	// currents and impedance don't depend on the pattern: single direction
+	if err = sim.Pattern(1, 1, 0, 0); err != nil {
+		return
+	}
+	if a.Perf.Gain, a.Perf.Z, err = sim.Results(); err != nil {
+		return
+	}
+	a.Perf.reset()
+
+	// currents of all NEC segments (in wire order): pick the center
+	// segment of each wire
+	var cur []complex128
+	if cur, err = cr.Currents(); err != nil {
+		return
+	}
+	a.Perf.Currents = make([]complex128, len(a.segs))
+	pos := 0
+	for i, seg := range a.segs {
+		n := a.numSegs(seg)
+		if pos+n > len(cur) {
+			return fmt.Errorf("incomplete segment currents (%d values)", len(cur))
+		}
+		a.Perf.Currents[i] = cur[pos+n/2]
+		pos += n
+	}
+	return
+}
+
+// CurrentColors returns a copy of the antenna (sharing the geometry) that
+// is rendered with colors showing the instantaneous segment currents at
+// the given phase (radians): Re(I·exp(jφ)) relative to the largest
+// current magnitude. Requires EvalCurrents.
+func (a *Antenna) CurrentColors(phase float64) *Antenna {
+	c := *a
+	var iMax float64
+	for _, i := range a.Perf.Currents {
+		iMax = max(iMax, cmplx.Abs(i))
+	}
+	if IsNull(iMax) {
+		return &c
+	}
+	rot := cmplx.Rect(1, phase)
+	c.colors = make([]*color.RGBA, len(a.Perf.Currents))
+	for k, i := range a.Perf.Currents {
+		c.colors[k] = CurrentColor(real(i*rot) / iMax)
+	}
+	return &c
+}
+
+// CurrentColor maps a relative current (-1..1) to a color: blue for
+// negative, gray for zero and red for positive values.
+func CurrentColor(v float64) *color.RGBA {
+	v = max(-1, min(1, v))
+	mix := func(from, to uint8) uint8 {
+		return uint8(math.Round(float64(from) + math.Abs(v)*(float64(to)-float64(from))))
+	}
+	if v < 0 {
+		return &color.RGBA{mix(128, 0), mix(128, 64), mix(128, 255), 255}
+	}
+	return &color.RGBA{mix(128, 255), mix(128, 32), mix(128, 0), 255}
+}
+
+// segment color for rendering (if set)
+func (a *Antenna) segColor(idx int) *color.RGBA {
+	if idx < len(a.colors) {
+		return a.colors[idx]
+	}
+	return nil
+}
+
+// reset performance values that are not computed by the evaluation
+func (p *Performance) reset() {
+	p.Eff = math.NaN()
+	p.Iso = math.NaN()
+	p.BW = math.NaN()
+	p.Ghoriz = math.NaN()
+	p.Gaz = math.NaN()
+	p.Gel = math.NaN()
+	p.Gsys = math.NaN()
+	p.Q = math.NaN()
+	p.Rp = nil
+	p.Currents = nil
+	p.FeedZ = nil
+}
+
 // Bulge specifies the number of segments involved in avoiding
 // wire intersections.
 const Bulge = 100
 
+// FixMode selects how FixGeometry resolves wire crossings
+type FixMode int
+
+// Crossing resolution modes
+const (
+	FixBridge FixMode = iota // lift crossing segments in z (wire bridges)
+	FixJumper                // keep the geometry planar (insulated jumpers)
+)
+
+// ParseFixMode returns the crossing resolution mode for a name
+// ("bridge" or "jumper").
+func ParseFixMode(s string) (mode FixMode, err error) {
+	switch s {
+	case "bridge", "":
+		mode = FixBridge
+	case "jumper":
+		mode = FixJumper
+	default:
+		err = fmt.Errorf("unknown crossing mode '%s'", s)
+	}
+	return
+}
+
+// Jumper is a wire crossing in the antenna plane that is built with an
+// insulated jumper (planar crossing resolution).
+type Jumper struct {
+	Pos  Vec3   // crossing point
+	Segs [2]int // indices of the crossing segments
+}
+
 // FixGeometry makes sure that an antenna geometry can be used for simulations
-// (e.g. avoiding wire intersections by "bridging" wire crossings).
-func (a *Antenna) FixGeometry(minD float64) {
+// (e.g. avoiding wire intersections by "bridging" wire crossings). In
+// FixJumper mode the geometry is kept planar and the crossings are only
+// recorded (see Jumpers); the simulation then treats crossing wires as
+// insulated from each other.
+func (a *Antenna) FixGeometry(minD float64, mode FixMode) {
+	if mode == FixJumper {
+		n := len(a.segs)
+		for i := 0; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				if p, cross := a.segs[i].Intersect(a.segs[j]); cross {
+					a.jumps = append(a.jumps, Jumper{Pos: p, Segs: [2]int{i, j}})
+				}
+			}
+		}
+		return
+	}
 	probs := CheckDistances(a.segs, minD)
 	regions := Regions(probs)
 	for _, r := range regions {
@@ -217,13 +610,54 @@ func (a *Antenna) FixGeometry(minD float64) {
 			} else {
 				n.start[2] += minD
 				n.end[2] += minD
+				a.lifted = append(a.lifted, i)
 			}
 		}
 	}
 }
 
+// Bridges returns the (sorted) indices of leg nodes whose segments were
+// lifted by FixGeometry to avoid wire intersections. A flat (2D) build
+// of the leg would short at these nodes. Only meaningful for antennas
+// created by BuildAntenna.
+func (a *Antenna) Bridges() (nodes []int) {
+	for _, i := range a.lifted {
+		if n, _, ok := a.LegNode(i); ok && !slices.Contains(nodes, n) {
+			nodes = append(nodes, n)
+		}
+	}
+	slices.Sort(nodes)
+	return
+}
+
+// Jumpers returns the wire crossings recorded by FixGeometry in FixJumper
+// mode.
+func (a *Antenna) Jumpers() []Jumper {
+	return a.jumps
+}
+
+// LegNode returns the index of the leg node for a segment of an antenna
+// created by BuildAntenna; 'left' is set for the mirrored leg. Feed line,
+// closing segment of loops, hats and elements are not part of a leg.
+func (a *Antenna) LegNode(seg int) (node int, left, ok bool) {
+	if seg < a.leg || seg >= a.leg+a.legs {
+		return
+	}
+	// leg segments alternate between right leg and mirrored left leg
+	return (seg - a.leg) / 2, (seg-a.leg)%2 == 1, true
+}
+
 // DumpNEC writes an antenna simulation card deck to writer.
 func (a *Antenna) DumpNEC(wrt io.Writer, spec *Specification, comments []string) {
+	a.dumpDeck(wrt, spec, comments, func() {
+		nTheta := int(180./Cfg.Sim.ThetaStep) + 1
+		nPhi := int(360./Cfg.Sim.PhiStep) + 1
+		fmt.Fprintf(wrt, "RP 0 %d %d 1000 0 0 %g %g 0 0\n", nTheta, nPhi, Cfg.Sim.ThetaStep, Cfg.Sim.PhiStep)
+	})
+}
+
+// write card deck; the final output request is written by 'out'
+func (a *Antenna) dumpDeck(wrt io.Writer, spec *Specification, comments []string, out func()) {
 	for _, cmt := range comments {
 		fmt.Fprintf(wrt, "CM %s\n", cmt)
 	}
@@ -237,16 +671,24 @@ func (a *Antenna) DumpNEC(wrt io.Writer, spec *Specification, comments []string)
 			a.dia/2,
 		)
 	}
-	volt := 1. // math.Sqrt(spec.FeedP * real(spec.FeedZ))
-
 	fmt.Fprintf(wrt, "GE %d\n", spec.Ground.Mode)
 	if !IsNull(spec.Wire.Inductance) {
 		fmt.Fprintf(wrt, "LD 2 0 0 0 0 %e 0\n", spec.Wire.Inductance)
 	}
 	if !IsNull(spec.Wire.Conductivity) {
-		fmt.Fprintf(wrt, "LD 5 0 0 0 %e\n", spec.Wire.Conductivity)
+		if Cfg.Sim.SkinEffect {
+			fmt.Fprintf(wrt, "LD 2 0 0 0 %e 0\n", spec.Wire.Resistance(spec.Source.Freq))
+		} else {
+			fmt.Fprintf(wrt, "LD 5 0 0 0 %e\n", spec.Wire.Conductivity)
+		}
+	}
+	for i, ex := range a.Excitations() {
+		if i == 0 {
+			fmt.Fprintf(wrt, "EX 0 %d 1 0 %f\n", ex.Seg+1, real(ex.Volts))
+			continue
+		}
+		fmt.Fprintf(wrt, "EX 0 %d 1 0 %f %f\n", ex.Seg+1, real(ex.Volts), imag(ex.Volts))
 	}
-	fmt.Fprintf(wrt, "EX 0 %d 1 0 %f\n", a.excite+1, volt)
 	f := float64(spec.Source.Freq) / 1e6
 	if spec.Source.Span > 0 {
 		fh := float64(spec.Source.Span) / 1e6
@@ -254,6 +696,6 @@ func (a *Antenna) DumpNEC(wrt io.Writer, spec *Specification, comments []string)
 	} else {
 		fmt.Fprintf(wrt, "FR 0 1 0 0 %f 0\n", f)
 	}
-	fmt.Fprintln(wrt, "RP 0 37 73 1000 0 0 5 5 0 0")
+	out()
 	fmt.Fprintln(wrt, "EN")
 }
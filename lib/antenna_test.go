@@ -22,6 +22,8 @@ package lib
 
 import (
 	"bytes"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -64,3 +66,242 @@ func TestAntenna(t *testing.T) {
 	se := ant.Perf.Rp.Spherical()
 	t.Logf("sqr error: %f", se)
 }
+
+func TestExcitations(t *testing.T) {
+	ant := NewAntenna("array")
+	ant.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	ant.Add(NewLine(NewVec3(-0.005, 0.3, 0), NewVec3(0.005, 0.3, 0)))
+	ant.AddExcitation(1, complex(0, 1))
+
+	ex := ant.Excitations()
+	if len(ex) != 2 || ex[0].Seg != 0 || ex[1].Seg != 1 || ex[1].Volts != complex(0, 1) {
+		t.Fatalf("unexpected excitations: %v", ex)
+	}
+	buf := new(bytes.Buffer)
+	ant.DumpNEC(buf, new(Specification), nil)
+	if n := strings.Count(buf.String(), "\nEX "); n != 2 {
+		t.Fatalf("expected 2 EX cards, got %d", n)
+	}
+}
+
+func TestElements(t *testing.T) {
+	spec := &Specification{
+		Wire:   Wire{Diameter: 0.002},
+		Ground: Ground{Height: 1},
+		Source: Source{Freq: 435000000},
+		Elements: []*Element{
+			{Offset: Vec3{0, -0.2, 0}, Nodes: []*Node{NewNode(0.1, 0, 0), NewNode(0.1, 0, 0)}},
+		},
+	}
+	nodes := []*Node{NewNode(0.01, 0, 0), NewNode(0.15, 0, 0)}
+	ant := BuildAntenna("yagi", spec, nodes)
+	if len(ant.segs) != 5+4 || ant.excite != 0 {
+		t.Fatalf("unexpected geometry: %d segments, feed at %d", len(ant.segs), ant.excite)
+	}
+	// reflector spans from -0.2 to 0.2 in x-direction (at y=-0.2, z=1)
+	for _, s := range ant.segs[5:] {
+		for _, p := range []Vec3{s.Start(), s.End()} {
+			if !IsNull(p[1]+0.2) || !IsNull(p[2]-1) || math.Abs(p[0]) > 0.2+1e-9 {
+				t.Fatalf("element segment out of place: %v", s)
+			}
+		}
+	}
+}
+
+func TestEvalStaged(t *testing.T) {
+	useIdealDipole(t)
+	spec := testSpec()
+	nodes := []*Node{NewNode(0.01, 0, 0), NewNode(0.15, 0, 0)}
+	ant := BuildAntenna("test", spec, nodes)
+
+	// rejected candidate: no pattern computed
+	ok, err := ant.EvalStaged(spec.Source.Freq, spec.Wire, spec.Ground, &Proxy{Accept: func(*Performance) bool { return false }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || ant.Perf.Gain == nil || ant.Perf.Rp != nil {
+		t.Fatal("rejected candidate fully evaluated")
+	}
+	// accepted candidate: pattern computed
+	if ok, err = ant.EvalStaged(spec.Source.Freq, spec.Wire, spec.Ground, &Proxy{Accept: func(*Performance) bool { return true }}); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || ant.Perf.Rp == nil {
+		t.Fatal("accepted candidate not fully evaluated")
+	}
+}
+
+// BenchmarkEvalStaged compares the evaluation of a rejected candidate
+// with a gain proxy and with an impedance proxy to a full evaluation
+// (needs a NEC2 engine).
+func BenchmarkEvalStaged(b *testing.B) {
+	if err := CheckEngine(); err != nil {
+		b.Skip(err)
+	}
+	spec := testSpec()
+	nodes := make([]*Node, 20)
+	for i := range nodes {
+		nodes[i] = NewNode(0.0085, 0.05, 0)
+	}
+	reject := func(*Performance) bool { return false }
+	for _, bc := range []struct {
+		name  string
+		proxy *Proxy
+	}{
+		{"full", nil},
+		{"gain", &Proxy{Accept: reject}},
+		{"impedance", &Proxy{Accept: reject, Impedance: true}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for range b.N {
+				ant := BuildAntenna("test", spec, nodes)
+				if _, err := ant.EvalStaged(spec.Source.Freq, spec.Wire, spec.Ground, bc.proxy); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestHatSpokes(t *testing.T) {
+	tip := NewLine(NewVec3(0.1, 0, 0), NewVec3(0.1, 0.2, 0))
+	spokes := HatSpokes(tip, 4, 0.05)
+	if len(spokes) != 4 {
+		t.Fatalf("%d spokes", len(spokes))
+	}
+	for i, s := range spokes {
+		if s.Start() != tip.End() || math.Abs(s.Length()-0.05) > 1e-12 {
+			t.Errorf("spoke #%d not attached to tip: %v", i, s)
+		}
+		if d := s.Dir(); math.Abs(d[1]) > 1e-12 {
+			t.Errorf("spoke #%d not perpendicular to tip: %v", i, s)
+		}
+	}
+	// hats of both legs are part of the antenna
+	spec := &Specification{
+		Source: Source{Freq: 435000000},
+		Feedpt: Feedpt{HatSpokes: 4, HatLength: 0.02},
+	}
+	ant := BuildAntenna("test", spec, []*Node{NewNode2D(0.01, 0), NewNode2D(0.01, 0.5)})
+	if n := len(ant.segs); n != 1+4+8 {
+		t.Errorf("%d segments", n)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	// closed geometry: tips are connected, no end hats
+	spec := &Specification{
+		Source: Source{Freq: 435000000},
+		Feedpt: Feedpt{HatSpokes: 4, HatLength: 0.02},
+		Loop:   true,
+	}
+	nodes := []*Node{NewNode2D(0.01, 0), NewNode2D(0.01, 0.5)}
+	ant := BuildAntenna("test", spec, nodes)
+	if n := len(ant.segs); n != 1+4+1 {
+		t.Errorf("%d segments", n)
+	}
+	tip := ant.segs[len(ant.segs)-1]
+	if tip.Start() != tip.End().MirrorX() {
+		t.Errorf("loop not closed: %v", tip)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	ant := NewAntenna("array")
+	ant.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	ant.Add(NewLine(NewVec3(-0.25, 0.3, 0.1), NewVec3(0.5, -0.1, 0.1)))
+
+	w, h, d := ant.Bounds().Extent()
+	if w != 0.75 || math.Abs(h-0.4) > 1e-12 || d != 0.1 {
+		t.Errorf("unexpected extent %f×%f×%f", w, h, d)
+	}
+}
+
+func TestEvalBaseline(t *testing.T) {
+	useIdealDipole(t)
+	spec := testSpec()
+	nodes := []*Node{NewNode2D(0.01, 0), NewNode2D(0.05, 0.8), NewNode2D(0.1, -1.2)}
+	perf, err := EvalBaseline(spec, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bent := BuildAntenna("test", spec, nodes)
+	if perf.Gain == nil || perf.Curv != 0 || math.Abs(perf.Len-bent.Perf.Len) > 1e-12 {
+		t.Errorf("baseline differs: curv=%f, len=%f/%f", perf.Curv, perf.Len, bent.Perf.Len)
+	}
+	if nodes[1].Theta != 0.8 {
+		t.Error("geometry changed")
+	}
+}
+
+func TestBridges(t *testing.T) {
+	spec := &Specification{Source: Source{Freq: 435000000}}
+	// straight leg: no bridges
+	var nodes []*Node
+	for range 40 {
+		nodes = append(nodes, NewNode2D(0.01, 0))
+	}
+	if b := BuildAntenna("test", spec, nodes).Bridges(); len(b) != 0 {
+		t.Fatalf("unexpected bridges: %v", b)
+	}
+	// leg crossing itself: right, up, left, down
+	nodes = nodes[:0]
+	for _, run := range []int{20, 10, 10, 20} {
+		for i := range run {
+			ang := 0.
+			if i == 0 && len(nodes) > 0 {
+				ang = math.Pi / 2
+			}
+			nodes = append(nodes, NewNode2D(0.01, ang))
+		}
+	}
+	b := BuildAntenna("test", spec, nodes).Bridges()
+	if len(b) == 0 {
+		t.Fatal("expected bridges")
+	}
+	for _, n := range b {
+		if n < 0 || n >= len(nodes) {
+			t.Fatalf("bridge index %d out of range", n)
+		}
+	}
+}
+
+func TestJumpers(t *testing.T) {
+	if _, err := ParseFixMode("tunnel"); err == nil {
+		t.Fatal("expected error for unknown crossing mode")
+	}
+	defer func(mode string) { Cfg.Sim.Crossings = mode }(Cfg.Sim.Crossings)
+	Cfg.Sim.Crossings = "jumper"
+
+	// leg crossing itself: right, up, left, down (crossing mirrored on
+	// the left leg)
+	spec := &Specification{Source: Source{Freq: 435000000}}
+	var nodes []*Node
+	for _, run := range []int{20, 10, 10, 20} {
+		for i := range run {
+			ang := 0.
+			if i == 0 && len(nodes) > 0 {
+				ang = math.Pi / 2
+			}
+			nodes = append(nodes, NewNode2D(0.01, ang))
+		}
+	}
+	ant := BuildAntenna("test", spec, nodes)
+	if b := ant.Bridges(); len(b) != 0 {
+		t.Fatalf("unexpected bridges: %v", b)
+	}
+	if z := ant.Bounds().Zmax; z != 0 {
+		t.Fatalf("geometry not planar: z=%f", z)
+	}
+	jumps := ant.Jumpers()
+	if len(jumps) != 2 {
+		t.Fatalf("expected 2 jumpers, got %d", len(jumps))
+	}
+	for _, j := range jumps {
+		n1, l1, ok1 := ant.LegNode(j.Segs[0])
+		n2, l2, ok2 := ant.LegNode(j.Segs[1])
+		if !ok1 || !ok2 || l1 != l2 || n1 >= 20 || n2 < 40 {
+			t.Fatalf("unexpected jumper: %v", j)
+		}
+	}
+}
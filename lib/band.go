@@ -0,0 +1,108 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Band is a named frequency band
+type Band struct {
+	Name string // band name (wavelength)
+	Low  int64  // lower band edge (Hz)
+	High int64  // upper band edge (Hz)
+}
+
+// Center frequency of band
+func (b Band) Center() int64 {
+	return (b.Low + b.High) / 2
+}
+
+// Bands is a list of amateur radio bands (IARU region 1) and the 868MHz
+// SRD band; the names are used for band directories in output layouts.
+var Bands = []Band{
+	{"160m", 1810000, 2000000},
+	{"80m", 3500000, 3800000},
+	{"60m", 5351500, 5366500},
+	{"40m", 7000000, 7200000},
+	{"30m", 10100000, 10150000},
+	{"20m", 14000000, 14350000},
+	{"17m", 18068000, 18168000},
+	{"15m", 21000000, 21450000},
+	{"12m", 24890000, 24990000},
+	{"10m", 28000000, 29700000},
+	{"6m", 50000000, 52000000},
+	{"4m", 70000000, 70500000},
+	{"2m", 144000000, 146000000},
+	{"70cm", 430000000, 440000000},
+	{"35cm", 866000000, 870000000},
+	{"23cm", 1240000000, 1300000000},
+}
+
+// GetBand returns a band by name.
+func GetBand(name string) (b Band, err error) {
+	for _, b = range Bands {
+		if b.Name == name {
+			return
+		}
+	}
+	err = fmt.Errorf("unknown band '%s'", name)
+	return
+}
+
+// BandName returns the name of the band containing the frequency; for
+// frequencies outside all bands the frequency in MHz is returned
+// (e.g. "433.92M").
+func BandName(freq int64) string {
+	for _, b := range Bands {
+		if freq >= b.Low && freq <= b.High {
+			return b.Name
+		}
+	}
+	return fmt.Sprintf("%gM", float64(freq)/1e6)
+}
+
+// OutputLayout expands a directory layout template: the placeholders
+// '{band}', '{model}', '{gen}', '{opt}' and '{mat}' are replaced by the
+// band name (see BandName), model, generator, optimization target and
+// wire material. Placeholder values are made safe as directory names.
+func OutputLayout(layout string, freq int64, model, gen, opt, mat string) string {
+	safe := func(s string) string {
+		if len(s) == 0 {
+			return "default"
+		}
+		return strings.Map(func(r rune) rune {
+			switch r {
+			case '/', '\\', ':', '=', ',', ' ':
+				return '_'
+			}
+			return r
+		}, s)
+	}
+	return strings.NewReplacer(
+		"{band}", safe(BandName(freq)),
+		"{model}", safe(model),
+		"{gen}", safe(gen),
+		"{opt}", safe(opt),
+		"{mat}", safe(mat),
+	).Replace(layout)
+}
@@ -0,0 +1,55 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import "testing"
+
+func TestBands(t *testing.T) {
+	for freq, name := range map[int64]string{
+		145000000: "2m",
+		435000000: "70cm",
+		868000000: "35cm",
+		14200000:  "20m",
+		433920000: "70cm",
+		27120000:  "27.12M",
+	} {
+		if got := BandName(freq); got != name {
+			t.Errorf("%d: expected band '%s', got '%s'", freq, name, got)
+		}
+	}
+	b, err := GetBand("70cm")
+	if err != nil || b.Center() != 435000000 {
+		t.Fatalf("unexpected band: %v (%v)", b, err)
+	}
+	if _, err = GetBand("11m"); err == nil {
+		t.Fatal("expected error for unknown band")
+	}
+}
+
+func TestOutputLayout(t *testing.T) {
+	dir := OutputLayout("{band}/{model}/{opt}/{gen}", 145000000, "bend2d", "v:ang=120", "Gmax=matched", "")
+	if dir != "2m/bend2d/Gmax_matched/v_ang_120" {
+		t.Fatalf("unexpected layout: %s", dir)
+	}
+	if dir = OutputLayout("{mat}", 145000000, "", "", "", ""); dir != "default" {
+		t.Fatalf("unexpected layout: %s", dir)
+	}
+}
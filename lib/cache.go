@@ -0,0 +1,337 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SimCache stores the results of simulations (keyed by a hash of all
+// simulator calls, i.e. geometry, wire, ground, frequency, excitation and
+// pattern resolution). Entries are kept in memory (least recently used
+// entries are dropped if the cache is full) and optionally in a directory
+// (to survive restarts).
+type SimCache struct {
+	lock    sync.Mutex
+	size    int                      // max. number of entries in memory
+	dir     string                   // directory for cache files (optional)
+	lru     *list.List               // entries (most recently used first)
+	entries map[string]*list.Element // entries by key
+	lookups int                      // number of lookups
+	hits    int                      // number of cache hits
+}
+
+// cached simulation results
+type cacheEntry struct {
+	Key     string      `json:"-"`
+	Gain    *Gain       `json:"gain"`
+	Z       [2]float64  `json:"z"`
+	Pattern [][]float64 `json:"pattern"` // nil: not read back
+}
+
+// NewSimCache creates a new cache for 'size' entries (in memory) and an
+// optional directory for persistent entries.
+func NewSimCache(size int, dir string) (c *SimCache, err error) {
+	if len(dir) > 0 {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return
+		}
+	}
+	c = &SimCache{
+		size:    size,
+		dir:     dir,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+	return
+}
+
+// Counters returns the number of lookups and cache hits
+func (c *SimCache) Counters() (lookups, hits int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lookups, c.hits
+}
+
+// get cached entry (nil if not cached)
+func (c *SimCache) get(key string) *cacheEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lookups++
+	if el, ok := c.entries[key]; ok {
+		c.hits++
+		c.lru.MoveToFront(el)
+		return el.Value.(*cacheEntry)
+	}
+	if len(c.dir) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(c.file(key))
+	if err != nil {
+		return nil
+	}
+	e := new(cacheEntry)
+	if err = json.Unmarshal(data, e); err != nil || e.Gain == nil {
+		return nil
+	}
+	e.Key = key
+	c.hits++
+	c.add(e)
+	return e
+}
+
+// put entry into cache
+func (c *SimCache) put(e *cacheEntry) (err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.add(e)
+	if len(c.dir) > 0 {
+		var data []byte
+		if data, err = json.Marshal(e); err != nil {
+			return
+		}
+		fname := c.file(e.Key)
+		if err = os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
+			return
+		}
+		err = os.WriteFile(fname, data, 0o644)
+	}
+	return
+}
+
+// add (or replace) entry in memory cache (drop least recently used
+// entries)
+func (c *SimCache) add(e *cacheEntry) {
+	if el, ok := c.entries[e.Key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.Key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.size {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*cacheEntry).Key)
+	}
+}
+
+// cache file for key
+func (c *SimCache) file(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+//----------------------------------------------------------------------
+
+// simulation cache (if enabled in the configuration)
+var (
+	simCache     *SimCache
+	simCacheOnce sync.Once
+	simCacheErr  error
+)
+
+// GetSimCache returns the simulation cache (nil if disabled)
+func GetSimCache() (*SimCache, error) {
+	if Cfg.Sim.Cache <= 0 {
+		return nil, nil
+	}
+	simCacheOnce.Do(func() {
+		simCache, simCacheErr = NewSimCache(Cfg.Sim.Cache, Cfg.Sim.CacheDir)
+	})
+	return simCache, simCacheErr
+}
+
+//----------------------------------------------------------------------
+
+// CachedSimulator serves the results of a simulation from a cache: all
+// calls are recorded (and hashed); only if the results are not cached,
+// the calls are replayed on a new instance of the wrapped simulator.
+// The radiation pattern is only read back (and added to the cache entry)
+// if it is requested, so rejected candidates of a two-stage evaluation
+// skip the readback.
+type CachedSimulator struct {
+	cache  *SimCache
+	newSim func() (Simulator, error) // wrapped simulator
+	hash   hash.Hash                 // hash over all calls
+	ops    []func(Simulator) error   // recorded calls
+	nTheta int                       // number of pattern elevation steps
+	nPhi   int                       // number of pattern azimuth steps
+	entry  *cacheEntry               // simulation results
+	sim    Simulator                 // wrapped simulator (if simulated)
+}
+
+// NewCachedSimulator wraps a simulator with a cache
+func NewCachedSimulator(cache *SimCache, newSim func() (Simulator, error)) *CachedSimulator {
+	s := &CachedSimulator{
+		cache:  cache,
+		newSim: newSim,
+		hash:   sha256.New(),
+	}
+	fmt.Fprintf(s.hash, "engine %s\n", Cfg.Sim.Engine)
+	return s
+}
+
+// record a simulator call
+func (s *CachedSimulator) record(op func(Simulator) error, format string, args ...any) error {
+	fmt.Fprintf(s.hash, format+"\n", args...)
+	s.ops = append(s.ops, op)
+	return nil
+}
+
+// Wire adds a straight wire
+func (s *CachedSimulator) Wire(tag, segs int, start, end Vec3, radius float64) error {
+	return s.record(func(sim Simulator) error {
+		return sim.Wire(tag, segs, start, end, radius)
+	}, "GW %d %d %v %v %v", tag, segs, start, end, radius)
+}
+
+// GroundComplete ends the geometry and sets the ground parameters
+func (s *CachedSimulator) GroundComplete(ground Ground) error {
+	return s.record(func(sim Simulator) error {
+		return sim.GroundComplete(ground)
+	}, "GE %+v", ground)
+}
+
+// Load sets the wire loss
+func (s *CachedSimulator) Load(wire Wire, freq int64) error {
+	return s.record(func(sim Simulator) error {
+		return sim.Load(wire, freq)
+	}, "LD %v %v %d %v", wire.Conductivity, wire.Inductance, freq, Cfg.Sim.SkinEffect)
+}
+
+// Frequency sets the simulation frequency
+func (s *CachedSimulator) Frequency(freq int64) error {
+	return s.record(func(sim Simulator) error {
+		return sim.Frequency(freq)
+	}, "FR %d", freq)
+}
+
+// Excite applies a voltage to a segment
+func (s *CachedSimulator) Excite(seg int, volts complex128) error {
+	return s.record(func(sim Simulator) error {
+		return sim.Excite(seg, volts)
+	}, "EX %d %v", seg, volts)
+}
+
+// Pattern requests the radiation pattern
+func (s *CachedSimulator) Pattern(nTheta, nPhi int, thetaStep, phiStep float64) error {
+	s.nTheta, s.nPhi = nTheta, nPhi
+	return s.record(func(sim Simulator) error {
+		return sim.Pattern(nTheta, nPhi, thetaStep, phiStep)
+	}, "RP %d %d %v %v", nTheta, nPhi, thetaStep, phiStep)
+}
+
+// Results returns the gain summary and the input impedance (from cache
+// or from a simulation)
+func (s *CachedSimulator) Results() (gain *Gain, z complex128, err error) {
+	if err = s.run(); err != nil {
+		return
+	}
+	g := *s.entry.Gain
+	return &g, complex(s.entry.Z[0], s.entry.Z[1]), nil
+}
+
+// Gain returns the pattern gain at the given pattern indices
+func (s *CachedSimulator) Gain(theta, phi int) (float64, error) {
+	if err := s.run(); err != nil {
+		return math.NaN(), err
+	}
+	if s.entry.Pattern == nil {
+		if err := s.pattern(); err != nil {
+			return math.NaN(), err
+		}
+	}
+	return s.entry.Pattern[theta][phi], nil
+}
+
+// Close releases the simulator resources
+func (s *CachedSimulator) Close() {
+	if s.sim != nil {
+		s.sim.Close()
+		s.sim = nil
+	}
+}
+
+// simulate replays the recorded calls on a new wrapped simulator
+func (s *CachedSimulator) simulate() (err error) {
+	if s.sim, err = s.newSim(); err != nil {
+		return
+	}
+	for _, op := range s.ops {
+		if err = op(s.sim); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// get results from cache or run the simulation
+func (s *CachedSimulator) run() (err error) {
+	if s.entry != nil {
+		return
+	}
+	key := hex.EncodeToString(s.hash.Sum(nil))
+	if s.entry = s.cache.get(key); s.entry != nil {
+		return
+	}
+	if err = s.simulate(); err != nil {
+		return
+	}
+	e := &cacheEntry{Key: key}
+	var z complex128
+	if e.Gain, z, err = s.sim.Results(); err != nil {
+		return
+	}
+	e.Z = [2]float64{real(z), imag(z)}
+	s.entry = e
+	return s.cache.put(e)
+}
+
+// read back the radiation pattern (simulate again if the cached entry
+// has no pattern) and update the cache entry
+func (s *CachedSimulator) pattern() (err error) {
+	if s.sim == nil {
+		if err = s.simulate(); err != nil {
+			return
+		}
+	}
+	// entries are shared: update a copy
+	e := *s.entry
+	e.Pattern = make([][]float64, s.nTheta)
+	for theta := range s.nTheta {
+		e.Pattern[theta] = make([]float64, s.nPhi)
+		for phi := range s.nPhi {
+			if e.Pattern[theta][phi], err = s.sim.Gain(theta, phi); err != nil {
+				return
+			}
+		}
+	}
+	s.entry = &e
+	return s.cache.put(&e)
+}
@@ -0,0 +1,110 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"testing"
+)
+
+func TestSimCache(t *testing.T) {
+	// count simulations
+	sims := 0
+	newSim := func() (Simulator, error) {
+		sims++
+		return new(fakeSim), nil
+	}
+	eval := func(cache *SimCache, length float64) *Antenna {
+		defer func(fn func() (Simulator, error)) { NewSimulator = fn }(NewSimulator)
+		NewSimulator = func() (Simulator, error) { return NewCachedSimulator(cache, newSim), nil }
+		ant := NewAntenna("test")
+		ant.Add(NewLine(NewVec3(-length, 0, 0), NewVec3(length, 0, 0)))
+		if err := ant.Eval(435000000, Wire{Diameter: 0.002}, Ground{}); err != nil {
+			t.Fatal(err)
+		}
+		return ant
+	}
+	cache, err := NewSimCache(2, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []float64{0.1, 0.2, 0.1, 0.3, 0.4, 0.1} {
+		ant := eval(cache, l)
+		if ant.Perf.Gain.Max != 2.15 || ant.Perf.Z != complex(73, 42) || ant.Perf.Rp.Max != 2.15 {
+			t.Fatalf("unexpected performance: %s", ant.Perf)
+		}
+	}
+	// 0.1 is evaluated once (second lookup from memory, third from disk)
+	if lookups, hits := cache.Counters(); lookups != 6 || hits != 2 || sims != 4 {
+		t.Errorf("lookups=%d, hits=%d, sims=%d", lookups, hits, sims)
+	}
+
+	// memory-only cache: dropped entries are simulated again
+	if cache, err = NewSimCache(1, ""); err != nil {
+		t.Fatal(err)
+	}
+	sims = 0
+	for _, l := range []float64{0.1, 0.1, 0.2, 0.1} {
+		eval(cache, l)
+	}
+	if lookups, hits := cache.Counters(); lookups != 4 || hits != 1 || sims != 3 {
+		t.Errorf("lookups=%d, hits=%d, sims=%d", lookups, hits, sims)
+	}
+}
+
+func TestSimCachePattern(t *testing.T) {
+	sims := 0
+	defer func(fn func() (Simulator, error)) { NewSimulator = fn }(NewSimulator)
+	cache, err := NewSimCache(4, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewSimulator = func() (Simulator, error) {
+		return NewCachedSimulator(cache, func() (Simulator, error) {
+			sims++
+			return new(fakeSim), nil
+		}), nil
+	}
+	ant := NewAntenna("test")
+	ant.Add(NewLine(NewVec3(-0.1, 0, 0), NewVec3(0.1, 0, 0)))
+
+	// rejected candidate: no pattern read back (and cached)
+	reject := &Proxy{Accept: func(*Performance) bool { return false }}
+	if _, err = ant.EvalStaged(435000000, Wire{Diameter: 0.002}, Ground{}, reject); err != nil {
+		t.Fatal(err)
+	}
+	for _, el := range cache.entries {
+		if el.Value.(*cacheEntry).Pattern != nil {
+			t.Error("pattern of rejected candidate read back")
+		}
+	}
+	// full evaluation: pattern from a new simulation, then from cache
+	for range 2 {
+		if err = ant.Eval(435000000, Wire{Diameter: 0.002}, Ground{}); err != nil {
+			t.Fatal(err)
+		}
+		if ant.Perf.Rp == nil || ant.Perf.Rp.Max != 2.15 {
+			t.Fatal("pattern missing")
+		}
+	}
+	if sims != 2 {
+		t.Errorf("%d simulations", sims)
+	}
+}
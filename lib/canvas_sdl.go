@@ -64,6 +64,10 @@ type SDLCanvas struct {
 	waiting atomic.Bool // pause rendering?
 	stepper atomic.Bool // single-step?
 	hint    string      // hint for display
+	theme   Theme       // colors used for rendering
+	scaled  bool        // draw scale bar and axes
+
+	keepOuts []*KeepOut // keep-out regions
 }
 
 // NewSDLCanvas creates a new SDL canvas for display
@@ -71,6 +75,7 @@ func NewSDLCanvas(width, height int, side float64) (c *SDLCanvas, err error) {
 	c = new(SDLCanvas)
 	c.taskCh = make(chan Task)
 	c.count = -1
+	c.theme = ThemeDefault
 	// create window
 	if c.win, c.cv, err = sdlcanvas.CreateWindow(width, height, "Antenna optimization"); err != nil {
 		return
@@ -105,6 +110,25 @@ func (c *SDLCanvas) SetHint(m string) {
 	c.hint = m
 }
 
+// SetTheme sets the colors used for rendering
+func (c *SDLCanvas) SetTheme(t Theme) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.theme = t
+}
+
+// SetScale enables drawing of a scale bar and XY axes
+func (c *SDLCanvas) SetScale(on bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.scaled = on
+}
+
+// SetKeepOuts sets the keep-out regions to be rendered
+func (c *SDLCanvas) SetKeepOuts(list []*KeepOut) {
+	c.keepOuts = list
+}
+
 // Run the canvas (new rendering begins)
 func (c *SDLCanvas) Run(cb Action) {
 
@@ -166,7 +190,8 @@ func (c *SDLCanvas) Run(cb Action) {
 		c.lock.Lock()
 
 		// clear screen
-		c.cv.SetFillStyle("#FFF")
+		bg := c.theme.Background
+		c.cv.SetFillStyle(bg.R, bg.G, bg.B)
 		c.cv.FillRect(0, 0, float64(c.cw), float64(c.ch))
 
 		// compute extend of antenna
@@ -178,32 +203,46 @@ func (c *SDLCanvas) Run(cb Action) {
 
 		y := 2*c.txtSize - c.h/2
 		if len(c.curr.Msg) > 0 {
-			c.Text(0, y, c.txtSize, c.curr.Msg, ClrBlack)
+			c.Text(0, y, c.txtSize, c.curr.Msg, c.theme.Text)
 		} else {
-			c.Text(0, y, c.txtSize, fmt.Sprintf("Step #%d", c.count), ClrBlack)
+			c.Text(0, y, c.txtSize, fmt.Sprintf("Step #%d", c.count), c.theme.Text)
+		}
+		if c.scaled {
+			// scale bar above the hint line
+			drawScale(c, c.curr.Ant.Bounds(), c.h/2-4*c.txtSize, c.txtSize, c.theme)
+		}
+		for _, k := range c.keepOuts {
+			k.Render(c, c.txtSize/12, c.theme.KeepOut)
 		}
 		for idx, seg := range c.curr.Ant.segs {
-			clr := ClrBlue
+			clr := c.theme.Wire
 			if idx == c.curr.Ant.excite {
-				clr = ClrRed
+				clr = c.theme.Excitation
+			}
+			if sc := c.curr.Ant.segColor(idx); sc != nil {
+				clr = sc
 			}
 			c.Line(seg.start[0], seg.start[1], seg.end[0], seg.end[1], c.curr.Ant.dia, clr)
 		}
 		if c.curr.Pos >= 0 {
 			p := c.curr.Ant.segs[2*c.curr.Pos+1].Start()
-			c.Circle(p[0], p[1], c.txtSize/6, 0, nil, ClrGreen)
-			c.Circle(-p[0], p[1], c.txtSize/6, 0, nil, ClrGreen)
+			c.Circle(p[0], p[1], c.txtSize/6, 0, nil, c.theme.Marker)
+			c.Circle(-p[0], p[1], c.txtSize/6, 0, nil, c.theme.Marker)
 		}
 		y += c.txtSize
-		c.Text(0, y, c.txtSize/2, c.curr.Ant.Perf.String(), ClrRed)
+		c.Text(0, y, c.txtSize/2, c.curr.Ant.Perf.String(), c.theme.Perf)
+		for i, perf := range c.curr.Ant.Edges {
+			y += c.txtSize / 2
+			c.Text(0, y, c.txtSize/2, []string{"f-: ", "f+: "}[i%2]+perf.String(), c.theme.Band)
+		}
 
 		y += c.txtSize
 		k := extend / c.curr.Ant.Lambda
 		info := fmt.Sprintf("%d segments, length: %.3fm (%.3f λ)", len(c.curr.Ant.segs), extend, k)
-		c.Text(0, y, c.txtSize/2, info, ClrBlack)
+		c.Text(0, y, c.txtSize/2, info, c.theme.Text)
 
 		y = c.h/2 - 2*c.txtSize
-		c.Text(0, y, c.txtSize/2, c.hint, ClrPink)
+		c.Text(0, y, c.txtSize/2, c.hint, c.theme.Band)
 
 		c.lock.Unlock()
 	})
@@ -22,6 +22,7 @@ package lib
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"image/color"
 	"os"
@@ -41,6 +42,8 @@ type SVGCanvas struct {
 	margin     int
 	txtSize    float64
 	buf        *bytes.Buffer
+	theme      Theme
+	scale      bool
 }
 
 // NewSVGCanvas creates a new SVG canvas
@@ -51,6 +54,7 @@ func NewSVGCanvas(_, _ int, _ float64) (*SVGCanvas, error) {
 	c.txtSize = 0.1
 	c.margin = int(0.1 / c.prec)
 	c.svg = svg.New(c.buf)
+	c.theme = ThemeDefault
 	return c, nil
 }
 
@@ -59,39 +63,57 @@ func (c *SVGCanvas) Run(cb Action) {}
 
 func (c *SVGCanvas) SetHint(m string) {}
 
+// SetTheme sets the colors used for rendering
+func (c *SVGCanvas) SetTheme(t Theme) {
+	c.theme = t
+}
+
+// SetScale enables drawing of a scale bar and XY axes
+func (c *SVGCanvas) SetScale(on bool) {
+	c.scale = on
+}
+
 // Show antenna on canvas
 func (c *SVGCanvas) Show(ant *Antenna, _ int, msg string) {
 
-	// compute bounding box and antenna length
-	box := NewBoundingBox()
-	length := 0.
-	for _, seg := range ant.segs {
-		length += seg.Length()
-		p := seg.Start()
-		box.Include(p)
-		p = seg.End()
-		box.Include(p)
-	}
+	// compute bounding box
+	box := ant.Bounds()
 	// width and height of SVG canvas
 	width := int((box.Xmax - box.Xmin) / c.prec)
 	height := int((box.Ymax - box.Ymin) / c.prec)
 	c.offX, c.offY = box.Xmin, box.Ymin
 
-	c.svg.Start(width+2*c.margin, height+2*c.margin)
+	// the view box allows scaling of the image (e.g. in SVGSheet)
+	w, h := width+2*c.margin, height+2*c.margin
+	c.svg.Start(w, h, fmt.Sprintf(`viewBox="0 0 %d %d"`, w, h))
+	if bg := c.theme.Background; bg != nil {
+		c.svg.Rect(0, 0, width+2*c.margin, height+2*c.margin, "fill:"+svgColor(bg))
+	}
 
+	if c.scale {
+		// scale bar in the (upper) margin
+		drawScale(c, box, box.Ymin-0.3*float64(c.margin)*c.prec, c.txtSize, c.theme)
+	}
 	y := box.Ymax + 2*c.txtSize
 	if len(msg) > 0 {
-		c.Text(0, y, c.txtSize, msg, ClrBlack)
+		c.Text(0, y, c.txtSize, msg, c.theme.Text)
 	}
 	for idx, seg := range ant.segs {
-		clr := ClrBlue
+		clr := c.theme.Wire
 		if idx == ant.excite {
-			clr = ClrRed
+			clr = c.theme.Excitation
+		}
+		if sc := ant.segColor(idx); sc != nil {
+			clr = sc
 		}
 		c.Line(seg.start[0], seg.start[1], seg.end[0], seg.end[1], ant.dia, clr)
 	}
 	y += c.txtSize
-	c.Text(0, y, c.txtSize/2, ant.Perf.String(), ClrRed)
+	c.Text(0, y, c.txtSize/2, ant.Perf.String(), c.theme.Perf)
+	for i, perf := range ant.Edges {
+		y += c.txtSize / 2
+		c.Text(0, y, c.txtSize/2, []string{"f-: ", "f+: "}[i%2]+perf.String(), c.theme.Band)
+	}
 	c.svg.End()
 }
 
@@ -99,7 +121,7 @@ func (c *SVGCanvas) Show(ant *Antenna, _ int, msg string) {
 func (c *SVGCanvas) Circle(x, y, r, w float64, clrBorder, clrFill *color.RGBA) {
 	fill := "none"
 	if clrFill != nil {
-		fill = fmt.Sprintf("#%02x%02x%02x", clrFill.R, clrFill.G, clrFill.B)
+		fill = svgColor(clrFill)
 	}
 	border := ""
 	if w > 0 && clrBorder != nil {
@@ -114,6 +136,9 @@ func (c *SVGCanvas) Circle(x, y, r, w float64, clrBorder, clrFill *color.RGBA) {
 // Text primitive
 func (c *SVGCanvas) Text(x, y, fs float64, s string, clr *color.RGBA) {
 	style := fmt.Sprintf("text-anchor:middle;font-size:%dpx", int(fs/c.prec))
+	if clr != nil {
+		style += ";fill:" + svgColor(clr)
+	}
 	cx, cy := c.xlate(x, y)
 	c.svg.Text(cx, cy, s, style)
 }
@@ -130,6 +155,11 @@ func (c *SVGCanvas) Line(x1, y1, x2, y2, w float64, clr *color.RGBA) {
 	c.svg.Line(cx1, cy1, cx2, cy2, style)
 }
 
+// SVG color specification
+func svgColor(clr *color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", clr.R, clr.G, clr.B)
+}
+
 // coordinate translation
 func (c *SVGCanvas) xlate(x, y float64) (int, int) {
 	return int((x-c.offX)/c.prec) + c.margin, int((y-c.offY)/c.prec) + c.margin
@@ -141,6 +171,11 @@ func (c *SVGCanvas) Close() (err error) {
 	return
 }
 
+// Bytes returns the SVG stream of the canvas
+func (c *SVGCanvas) Bytes() []byte {
+	return c.buf.Bytes()
+}
+
 // Dump canvas to file
 func (c *SVGCanvas) Dump(fName string) (err error) {
 	var f *os.File
@@ -151,3 +186,30 @@ func (c *SVGCanvas) Dump(fName string) (err error) {
 	_, err = f.Write(c.buf.Bytes())
 	return nil
 }
+
+// SVGSheet arranges SVG images (e.g. from SVGCanvas.Bytes) in a grid with
+// 'cols' columns (contact sheet). Each image is scaled to a square cell of
+// 'size' pixels and labeled below the cell.
+func SVGSheet(cells [][]byte, labels []string, cols, size int) []byte {
+	rows := (len(cells) + cols - 1) / cols
+	lh := size / 10
+	buf := new(bytes.Buffer)
+	s := svg.New(buf)
+	s.Start(cols*size, rows*(size+lh))
+	if bg := ThemeDefault.Background; bg != nil {
+		s.Rect(0, 0, cols*size, rows*(size+lh), "fill:"+svgColor(bg))
+	}
+	style := fmt.Sprintf("text-anchor:middle;font-size:%dpx", lh/2)
+	if clr := ThemeDefault.Text; clr != nil {
+		style += ";fill:" + svgColor(clr)
+	}
+	for i, cell := range cells {
+		x, y := (i%cols)*size, (i/cols)*(size+lh)
+		s.Image(x, y, size, size, "data:image/svg+xml;base64,"+base64.StdEncoding.EncodeToString(cell))
+		if i < len(labels) {
+			s.Text(x+size/2, y+size+2*lh/3, labels[i], style)
+		}
+	}
+	s.End()
+	return buf.Bytes()
+}
@@ -0,0 +1,122 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Coax is a coaxial cable type. The matched loss (dB per 100m) at a
+// frequency f (in MHz) is approximated by K1·√f + K2·f (conductor and
+// dielectric losses).
+type Coax struct {
+	Name string  // cable type
+	Z0   float64 // characteristic impedance (Ω)
+	K1   float64 // conductor loss coefficient
+	K2   float64 // dielectric loss coefficient
+}
+
+// Coaxes is the list of known cable types (fitted to typical datasheet
+// values between 100MHz and 1.3GHz)
+var Coaxes = []*Coax{
+	{"RG174", 50, 2.779, 0.0121},
+	{"RG58", 50, 1.4625, 0.01375},
+	{"RG8X", 50, 1.053, 0.0097},
+	{"RG213", 50, 0.643, 0.00566},
+	{"Aircell7", 50, 0.625, 0.00278},
+	{"LMR400", 50, 0.3816, 0.0015},
+	{"Ecoflex10", 50, 0.3806, 0.00162},
+}
+
+// GetCoax returns a cable type by (case-insensitive) name
+func GetCoax(name string) (c *Coax, err error) {
+	idx := slices.IndexFunc(Coaxes, func(c *Coax) bool {
+		return strings.EqualFold(c.Name, name)
+	})
+	if idx < 0 {
+		return nil, fmt.Errorf("unknown coax type '%s'", name)
+	}
+	return Coaxes[idx], nil
+}
+
+// MatchedLoss returns the loss (in dB per 100m) of a matched cable
+func (c *Coax) MatchedLoss(freq int64) float64 {
+	f := float64(freq) / 1e6
+	return c.K1*math.Sqrt(f) + c.K2*f
+}
+
+// Feedline is a coax cable of given type and length (in meters)
+type Feedline struct {
+	Cable  *Coax
+	Length float64
+}
+
+// ParseFeedline parses a feedline specification "<type>:<length>[m]"
+func ParseFeedline(s string) (fl *Feedline, err error) {
+	name, length, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid feedline '%s' (expected '<type>:<length>')", s)
+	}
+	fl = new(Feedline)
+	if fl.Cable, err = GetCoax(name); err != nil {
+		return nil, err
+	}
+	if fl.Length, err = strconv.ParseFloat(strings.TrimSuffix(length, "m"), 64); err != nil {
+		return nil, err
+	}
+	if fl.Length < 0 {
+		return nil, fmt.Errorf("negative feedline length '%s'", length)
+	}
+	return
+}
+
+// String returns a human-readable feedline specification
+func (fl *Feedline) String() string {
+	return fmt.Sprintf("%s:%gm", fl.Cable.Name, fl.Length)
+}
+
+// Loss (in dB) of the feedline at a frequency for an antenna with
+// impedance z: the matched loss plus the additional loss due to the
+// standing wave on a mismatched line and the mismatch loss at the
+// (transmitter) input of the line, assuming a transmitter designed
+// for the characteristic impedance of the cable (no tuner).
+func (fl *Feedline) Loss(freq int64, z complex128) float64 {
+	// matched loss (as power ratio)
+	a := math.Pow(10, fl.Cable.MatchedLoss(freq)*fl.Length/1000)
+	// reflection at the antenna and at the input of the line
+	rho := cmplx.Abs(ToReflection(z, complex(fl.Cable.Z0, 0)))
+	rhoIn := rho / a
+	// total line loss (including SWR loss) and input mismatch loss
+	line := 10 * math.Log10((a*a-rho*rho)/(a*(1-rho*rho)))
+	mismatch := -10 * math.Log10(1-rhoIn*rhoIn)
+	return line + mismatch
+}
+
+// SystemGain returns the gain of an antenna (with max. gain gmax and
+// impedance z) as delivered from the transmitter through the feedline.
+func (fl *Feedline) SystemGain(gmax float64, z complex128, freq int64) float64 {
+	return gmax - fl.Loss(freq, z)
+}
@@ -0,0 +1,55 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFeedline(t *testing.T) {
+	fl, err := ParseFeedline("rg213:30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fl.Cable.Name != "RG213" || fl.Length != 30 || fl.String() != "RG213:30m" {
+		t.Fatalf("unexpected feedline %v", fl)
+	}
+	// matched antenna: only the matched loss of the cable
+	freq := int64(145000000)
+	matched := fl.Cable.MatchedLoss(freq) * 0.3
+	if loss := fl.Loss(freq, 50); math.Abs(loss-matched) > 1e-9 {
+		t.Errorf("matched loss %f != %f", loss, matched)
+	}
+	// mismatched antenna: additional loss
+	z := complex(120, 80)
+	if loss := fl.Loss(freq, z); loss <= matched {
+		t.Errorf("mismatched loss %f <= %f", loss, matched)
+	}
+	if g := fl.SystemGain(5, z, freq); g != 5-fl.Loss(freq, z) {
+		t.Errorf("unexpected system gain %f", g)
+	}
+	for _, s := range []string{"RG213", "RG999:10m", "RG58:x", "RG58:-1"} {
+		if _, err = ParseFeedline(s); err == nil {
+			t.Errorf("invalid feedline '%s' accepted", s)
+		}
+	}
+}
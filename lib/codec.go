@@ -0,0 +1,213 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// File formats for geometry and track files. Plain (indented) JSON is the
+// default; gzipped JSON and binary (gob) files are much smaller for
+// geometries with many nodes and large sweeps.
+const (
+	FmtJSON = "json" // plain JSON ('.json')
+	FmtGzip = "gz"   // gzipped JSON ('.json.gz')
+	FmtBin  = "bin"  // binary encoding ('.bin')
+)
+
+// binMagic prefixes binary files (distinguishes them from JSON content)
+var binMagic = []byte("antgen:gob\n")
+
+// FileExts lists the file extensions of all supported formats
+var FileExts = []string{".json", ".json.gz", ".bin"}
+
+// FileExt returns the file extension for a file format
+func FileExt(format string) (ext string, err error) {
+	switch format {
+	case FmtJSON, "":
+		ext = ".json"
+	case FmtGzip:
+		ext = ".json.gz"
+	case FmtBin:
+		ext = ".bin"
+	default:
+		err = fmt.Errorf("unknown file format '%s'", format)
+	}
+	return
+}
+
+// FindFile returns the name of an existing file 'base' with one of the
+// supported file extensions.
+func FindFile(base string) (fName string, err error) {
+	for _, ext := range FileExts {
+		fName = base + ext
+		if _, err = os.Stat(fName); err == nil {
+			return
+		}
+	}
+	return "", fmt.Errorf("no file '%s.*' found", base)
+}
+
+// EncodeFile writes an object to file; the format is selected by the
+// file extension (see FileExts).
+func EncodeFile(fName string, obj any) (err error) {
+	var data []byte
+	switch {
+	case strings.HasSuffix(fName, ".bin"):
+		buf := bytes.NewBuffer(bytes.Clone(binMagic))
+		if err = gob.NewEncoder(buf).Encode(obj); err != nil {
+			return
+		}
+		data = buf.Bytes()
+	case strings.HasSuffix(fName, ".gz"):
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		if err = json.NewEncoder(zw).Encode(obj); err != nil {
+			return
+		}
+		if err = zw.Close(); err != nil {
+			return
+		}
+		data = buf.Bytes()
+	default:
+		if data, err = json.MarshalIndent(obj, "", "    "); err != nil {
+			return
+		}
+	}
+	return os.WriteFile(fName, data, 0644)
+}
+
+// CanonicalDigits is the number of decimal places of non-integer numbers
+// in canonical JSON output
+const CanonicalDigits = 9
+
+// CanonicalJSON encodes an object as indented JSON with all non-integer
+// numbers rounded to CanonicalDigits decimal places (and trailing zeros
+// removed). Fields keep the order of the struct definitions, so the output
+// is byte-identical for objects that differ only by tiny floating-point
+// deviations (e.g. on different platforms).
+func CanonicalJSON(obj any) (out []byte, err error) {
+	var data []byte
+	if data, err = json.Marshal(obj); err != nil {
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// nesting levels: object or array with number of tokens written
+	type level struct {
+		obj bool
+		n   int
+	}
+	var stack []*level
+	buf := new(bytes.Buffer)
+	for {
+		var tok json.Token
+		if tok, err = dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			return
+		}
+		// end of object or array
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			buf.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		// separator before keys and values
+		if n := len(stack); n > 0 {
+			l := stack[n-1]
+			switch {
+			case l.obj && l.n%2 == 1:
+				buf.WriteByte(':')
+			case l.n > 0:
+				buf.WriteByte(',')
+			}
+			l.n++
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			buf.WriteByte(byte(v))
+			stack = append(stack, &level{obj: v == '{'})
+		case json.Number:
+			buf.WriteString(canonicalNumber(v))
+		default:
+			// strings, booleans and null
+			var b []byte
+			if b, err = json.Marshal(v); err != nil {
+				return
+			}
+			buf.Write(b)
+		}
+	}
+	ind := new(bytes.Buffer)
+	if err = json.Indent(ind, buf.Bytes(), "", "    "); err != nil {
+		return
+	}
+	return ind.Bytes(), nil
+}
+
+// canonical representation of a JSON number
+func canonicalNumber(n json.Number) string {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		// integer
+		return s
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return s
+	}
+	s = strconv.FormatFloat(f, 'f', CanonicalDigits, 64)
+	s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// DecodeData reads an object from file content in any supported format
+// (detected from the content, so it works for data read from stdin).
+func DecodeData(data []byte, obj any) (err error) {
+	// gzipped content
+	if len(data) > 1 && data[0] == 0x1f && data[1] == 0x8b {
+		var zr *gzip.Reader
+		if zr, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return
+		}
+	}
+	// binary content
+	if bin, ok := bytes.CutPrefix(data, binMagic); ok {
+		return gob.NewDecoder(bytes.NewReader(bin)).Decode(obj)
+	}
+	return json.Unmarshal(data, obj)
+}
@@ -0,0 +1,112 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	dir := t.TempDir()
+	geo := &Geometry{
+		Cmts:  []string{"codec"},
+		Wire:  Wire{Diameter: 0.002, Material: "CuL"},
+		Nodes: []*Node{NewNode(0.01, 0.1, 0.2), NewNode2D(0.02, -0.3)},
+	}
+	for _, format := range []string{FmtJSON, FmtGzip, FmtBin} {
+		ext, err := FileExt(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := filepath.Join(dir, "geometry-"+format)
+		if err = EncodeFile(base+ext, geo); err != nil {
+			t.Fatal(err)
+		}
+		fName, err := FindFile(base)
+		if err != nil || fName != base+ext {
+			t.Fatalf("%s: file not found (%v)", format, err)
+		}
+		data, err := os.ReadFile(fName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(Geometry)
+		if err = DecodeData(data, out); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(out.Nodes) != len(geo.Nodes) || out.Wire != geo.Wire || out.Cmts[0] != geo.Cmts[0] {
+			t.Fatalf("%s: geometry differs: %+v", format, out)
+		}
+		for i, n := range out.Nodes {
+			if *n != *geo.Nodes[i] {
+				t.Errorf("%s: node %d differs", format, i)
+			}
+		}
+	}
+	if _, err := FileExt("xml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	mk := func(theta float64) *Geometry {
+		return &Geometry{
+			Cmts:    []string{"a<b", "canonical"},
+			Wire:    Wire{Diameter: 0.002, Material: "CuL"},
+			Height:  -0.0,
+			Nodes:   []*Node{NewNode(0.01, theta, 0), NewNode2D(0.02, -0.3)},
+			Pattern: &RadPattern{NPhi: 2, NTheta: 1, Values: [][]float64{{1.5, -2}}},
+		}
+	}
+	var a, b bytes.Buffer
+	x := 0.1 // not a constant expression: 0.1+0.2 != 0.3
+	if err := mk(x + 0.2).WriteCanonical(&a); err != nil {
+		t.Fatal(err)
+	}
+	if err := mk(0.3).WriteCanonical(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Fatalf("canonical output differs:\n%s\n%s", a.String(), b.String())
+	}
+	// same layout as the plain JSON encoding
+	exp, err := json.MarshalIndent(mk(0.3), "", "    ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), exp) {
+		t.Errorf("canonical output:\n%s\nexpected:\n%s", a.String(), string(exp))
+	}
+	for in, out := range map[string]string{
+		"0.30000000000000004": "0.3",
+		"-1e-12":              "0",
+		"1.25e-3":             "0.00125",
+		"42":                  "42",
+	} {
+		if s := canonicalNumber(json.Number(in)); s != out {
+			t.Errorf("%s: got %s, expected %s", in, s, out)
+		}
+	}
+}
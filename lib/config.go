@@ -34,11 +34,27 @@ type Simulation struct {
 	MinChange     float64 `json:"minChange"`     // progress check: min. change in target value
 	ProgressCheck int     `json:"progressCheck"` // number of steps between progress check
 	MinBend       float64 `json:"minBend"`       // min. bending angle (fraction of max. angle)
+	TieEps        float64 `json:"tieEps"`        // tolerance for equal target values (tie)
+	MaxSegs       int     `json:"maxSegs"`       // max. number of segments (0: no limit)
+	FileFormat    string  `json:"fileFormat"`    // format of geometry/track files [json,gz,bin]
 
 	// simulation-related constants (NEC2 simulation)
-	ExciteU   float64 `json:"exciteU"`   // excitation voltage
-	PhiStep   float64 `json:"phiStep"`   // azimut step (degree)
-	ThetaStep float64 `json:"thetaStep"` // elevation step (degree)
+	ExciteU    float64 `json:"exciteU"`    // excitation voltage (if no source power)
+	PhiStep    float64 `json:"phiStep"`    // azimut step (degree)
+	ThetaStep  float64 `json:"thetaStep"`  // elevation step (degree)
+	Efficiency bool    `json:"efficiency"` // compute efficiency (doubles simulations)
+	SkinEffect bool    `json:"skinEffect"` // wire loss from skin effect (else: conductivity)
+	TwoStage   bool    `json:"twoStage"`   // skip pattern/efficiency for rejected candidates
+	RefTheta   float64 `json:"refTheta"`   // reference direction for gain: Θ (degree)
+	RefPhi     float64 `json:"refPhi"`     // reference direction for gain: Φ (degree; <0: max.)
+	Takeoff    float64 `json:"takeoff"`    // elevation for azimuth-plane gain (degree)
+	MatchQ     float64 `json:"matchQ"`     // loaded Q of Pi/T matching networks (0: none)
+	AntQ       bool    `json:"antQ"`       // estimate antenna Q (two extra simulations)
+	QDelta     float64 `json:"qDelta"`     // relative frequency offset for Q estimate
+	Crossings  string  `json:"crossings"`  // resolution of wire crossings [bridge,jumper]
+	Engine     string  `json:"engine"`     // simulation engine [necpp,<external program>]
+	Cache      int     `json:"cache"`      // number of cached simulations (0: no cache)
+	CacheDir   string  `json:"cacheDir"`   // directory for persistent cache (optional)
 
 	// geometry-related constraints (NEC2 simulation)
 	WireMax      float64 `json:"wireMax"`      // max. wire diameter (in wavelength)
@@ -58,6 +74,8 @@ type RenderConfig struct {
 	Canvas string `json:"canvas"` // render engine/canvas
 	Width  int    `json:"width"`  // width of canvas (usually in pixels)
 	Height int    `json:"height"` // height of canvas (usually in pixels)
+	Theme  string `json:"theme"`  // color theme [default,dark,print]
+	Scale  bool   `json:"scale"`  // draw scale bar and XY axes
 }
 
 // Config for AntGen
@@ -67,6 +85,7 @@ type Config struct {
 	Mat     map[string]*Material `json:"material"`
 	Render  *RenderConfig        `json:"render"`
 	Plugins map[string]string    `json:"plugins"`
+	ZRanges map[string]string    `json:"zRanges"`
 }
 
 // Cfg is the globally-accessible configuration (pre-set)
@@ -108,11 +127,27 @@ var Cfg = &Config{
 		MinChange:     0.001,
 		ProgressCheck: 10,
 		MinBend:       0.01,
+		TieEps:        1e-9,
+		MaxSegs:       2000,
+		FileFormat:    FmtJSON,
 
 		// simulation-related constants (NEC2 simulation)
-		ExciteU:   1.0,
-		PhiStep:   5.0,
-		ThetaStep: 5.0,
+		ExciteU:    1.0,
+		PhiStep:    5.0,
+		ThetaStep:  5.0,
+		Efficiency: false,
+		SkinEffect: true,
+		TwoStage:   false,
+		RefTheta:   90,
+		RefPhi:     -1,
+		Takeoff:    10,
+		MatchQ:     0,
+		AntQ:       false,
+		QDelta:     0.001,
+		Crossings:  "bridge",
+		Engine:     "necpp",
+		Cache:      0,
+		CacheDir:   "",
 
 		// geometry-related constraints (NEC2 simulation)
 		WireMax:      0.008,
@@ -125,6 +160,8 @@ var Cfg = &Config{
 		Canvas: "sdl",
 		Width:  1024,
 		Height: 768,
+		Theme:  "default",
+		Scale:  false,
 	},
 	// wire materials
 	Mat: map[string]*Material{
@@ -143,6 +180,14 @@ var Cfg = &Config{
 	},
 	// no pre-defined plugins
 	Plugins: make(map[string]string),
+	// impedance range presets (filter expressions)
+	ZRanges: map[string]string{
+		"any":      "",
+		"resonant": "abs(Zi) < 1",
+		"good":     "Zr > 30 and Zr < 70 and abs(Zi) < 20",
+		"matched":  "Zr > 48 and Zr < 52 and abs(Zi) < 1",
+		"loss":     "Zr/sqrt(Zr*Zr+Zi*Zi) > 0.95",
+	},
 }
 
 // ReadConfig from file
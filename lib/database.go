@@ -22,12 +22,15 @@ package lib
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/cmplx"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -112,15 +115,25 @@ func (il *IndexList) Sorted() (out []Index) {
 
 // Row in the performance table
 type Row struct {
-	id    int64   // database record id
-	idx   Index   // record index (k, param)
-	gmax  float64 // maximum gain
-	gmean float64 // mean gain
-	sd    float64 // gain std. deviation
-	zr    float64 // antenna resistance
-	zi    float64 // antenna reactance
-	fdir  string  // file path
-	ftag  string  // file tag
+	id     int64   // database record id
+	idx    Index   // record index (k, param)
+	gmax   float64 // maximum gain
+	gmean  float64 // mean gain
+	sd     float64 // gain std. deviation
+	zr     float64 // antenna resistance
+	zi     float64 // antenna reactance
+	eff    float64 // radiation efficiency
+	iso    float64 // isotropy of radiation pattern
+	bw     float64 // relative SWR bandwidth
+	ghoriz float64 // gain in reference direction
+	gaz    float64 // peak gain in azimuth plane
+	gel    float64 // peak gain in elevation plane
+	gsys   float64 // gain including feedline losses
+	q      float64 // antenna Q
+	size   Vec3    // extent of bounding box
+	wlen   float64 // total wire length
+	fdir   string  // file path
+	ftag   string  // file tag
 }
 
 // Reference to database entry and related model file
@@ -151,13 +164,43 @@ func (r *Row) Value(name string) float64 {
 		return r.zr
 	case "Zi":
 		return r.zi
+	case "Eff":
+		return r.eff
+	case "Iso":
+		return r.iso
+	case "BW":
+		return r.bw
+	case "Ghoriz":
+		return r.ghoriz
+	case "Gaz":
+		return r.gaz
+	case "Gel":
+		return r.gel
+	case "Gsys":
+		return r.gsys
+	case "Q":
+		return r.q
+	case "SizeX":
+		return r.size[0]
+	case "SizeY":
+		return r.size[1]
+	case "SizeZ":
+		return r.size[2]
+	case "WireLen":
+		return r.wlen
 
 	// derived values
+	case "Extent":
+		// largest extent of bounding box
+		return max(r.size[0], r.size[1], r.size[2])
 	case "Geff":
 		// Gmax of a matched antenna
 		z := complex(r.zr, r.zi)
 		pf := real(z) / cmplx.Abs(z)
 		return r.gmax + 10*math.Log10(pf)
+	case "GBW":
+		// gain-bandwidth product (NaN if bandwidth is unknown)
+		return math.Pow(10, r.gmax/10) * r.bw
 	case "Loss":
 		// Loss due to unmatched antenna
 		z := complex(r.zr, r.zi)
@@ -171,25 +214,79 @@ func (r *Row) Value(name string) float64 {
 		pf := real(z) / cmplx.Abs(z)
 		return 10 * math.Log10(pf)
 	}
+	// custom expression
+	if e, err := PlotExpr(name); err == nil {
+		return e.Eval(r.Value)
+	}
 	return math.NaN()
 }
 
 // Record in the database
 type Record struct {
-	Freq   int64       // operating frequency
-	Wire   Wire        // wire spec
-	Gnd    Ground      // ground spec
-	Feedpt Feedpt      // feedpoint spec
-	K      float64     // k (dipole leg length)
-	Param  float64     // free parameter (generator)
-	Perf   Performance // final performance
-	Mdl    string      // antenna model
-	Gen    string      // antenna generator (initial geometry)
-	Opt    string      // optimizer
-	Seed   int64       // random seed
-	Stats  Stats       // optimization stats
-	Path   string      // relative path
-	Tag    string      // model tag
+	Freq    int64        `json:"freq"`           // operating frequency
+	Span    int64        `json:"span"`           // frequency span (band)
+	Wire    Wire         `json:"wire"`           // wire spec
+	Gnd     Ground       `json:"ground"`         // ground spec
+	Feedpt  Feedpt       `json:"feedpt"`         // feedpoint spec
+	K       float64      `json:"k"`              // k (dipole leg length)
+	Param   float64      `json:"-"`              // free parameter (generator)
+	Init    *Performance `json:"init,omitempty"` // initial performance (not in database)
+	Base    *Performance `json:"base,omitempty"` // straight baseline (not in database)
+	Run     *RunInfo     `json:"run,omitempty"`  // run environment (not in database)
+	Perf    Performance  `json:"perf"`           // final performance
+	Mdl     string       `json:"model"`          // antenna model
+	Gen     string       `json:"generator"`      // antenna generator (initial geometry)
+	Opt     string       `json:"optimizer"`      // optimizer
+	Seed    int64        `json:"seed"`           // random seed
+	GenSeed int64        `json:"genseed"`        // random seed (generator)
+	Stats   Stats        `json:"stats"`          // optimization stats
+	Path    string       `json:"path"`           // relative path
+	Tag     string       `json:"tag"`            // model tag
+	Track   []byte       `json:"-"`              // optimization track (JSON; optional)
+	Size    Vec3         `json:"-"`              // extent of bounding box (from model file)
+	WireLen float64      `json:"-"`              // total wire length (from model file)
+}
+
+// run environment of record (allocated on demand)
+func (r *Record) runInfo() *RunInfo {
+	if r.Run == nil {
+		r.Run = new(RunInfo)
+	}
+	return r.Run
+}
+
+// MarshalJSON encodes a record (free parameter only if defined)
+func (r *Record) MarshalJSON() ([]byte, error) {
+	type alias Record
+	out := struct {
+		*alias
+		Param *float64 `json:"param,omitempty"`
+	}{
+		alias: (*alias)(r),
+	}
+	if !math.IsNaN(r.Param) {
+		out.Param = &r.Param
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a record
+func (r *Record) UnmarshalJSON(data []byte) error {
+	type alias Record
+	in := struct {
+		*alias
+		Param *float64 `json:"param,omitempty"`
+	}{
+		alias: (*alias)(r),
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	r.Param = math.NaN()
+	if in.Param != nil {
+		r.Param = *in.Param
+	}
+	return nil
 }
 
 //----------------------------------------------------------------------
@@ -264,6 +361,17 @@ func (s *Set) Value(idx Index, name string) float64 {
 	return math.NaN()
 }
 
+// Best returns the row with the highest value of a named column (or nil
+// if the set is empty)
+func (s *Set) Best(name string) (best *Row) {
+	for _, r := range s.data {
+		if v := r.Value(name); best == nil || v > best.Value(name) {
+			best = r
+		}
+	}
+	return
+}
+
 // Values returns the values for named columns at a given index
 func (s *Set) Values(idx Index, names []string) map[string]float64 {
 	res := make(map[string]float64)
@@ -312,6 +420,18 @@ create table performance (
     SD      float not null,         -- gain std. deviation
     Zr      float not null,         -- antenna resistance
     Zi      float not null,         -- antenna reactance
+    eff     float default null,     -- radiation efficiency
+    iso     float default null,     -- isotropy of radiation pattern
+    bw      float default null,     -- relative SWR bandwidth
+    ghoriz  float default null,     -- gain in reference direction
+    q       float default null,     -- antenna Q
+    gaz     float default null,     -- peak gain in azimuth plane
+    gel     float default null,     -- peak gain in elevation plane
+    gsys    float default null,     -- gain including feedline losses
+    sizeX   float default null,     -- extent of bounding box (x)
+    sizeY   float default null,     -- extent of bounding box (y)
+    sizeZ   float default null,     -- extent of bounding box (z)
+    wirelen float default null,     -- total wire length
 	mdl     varchar(63) default '', -- model
 	opt     varchar(63) default '', -- optimization
 	gen     varchar(63) default '', -- generator
@@ -321,30 +441,172 @@ create table performance (
     mthds   integer default 0,      -- number of opt methods
     steps   integer default 0,      -- number of steps
     sims    integer default 0,      -- number of simulations
-    elapsed integer default 0       -- elapsed time in seconds
+    elapsed integer default 0,      -- elapsed time in seconds
+    track   blob default null,      -- optimization track (JSON)
+    setup   blob default null       -- ground and feedpoint spec (JSON)
 );
 create unique index idx_file on performance(fdir,ftag);
+create index idx_set on performance(fdir,k,param);
+create index idx_gmax on performance(Gmax);
+create index idx_gmean on performance(Gmean);
+create index idx_sd on performance(SD);
+create index idx_z on performance(Zr,Zi);
+create table meta (
+    key     varchar(31) primary key, -- metadata key
+    value   varchar(255) not null    -- metadata value
+);
 `
 
+// SchemaVersion is the current version of the database schema
+var SchemaVersion = len(migrations) + 1
+
+// schema migrations: each entry upgrades a database schema by one version
+// (adding a column to the performance table or creating indexes)
+var migrations = []struct {
+	column string // column added by migration (empty: no column)
+	stmt   string // migration statement
+}{
+	// version 2: radiation efficiency
+	{"eff", "alter table performance add column eff float default null"},
+	// version 3: optimization track
+	{"track", "alter table performance add column track blob default null"},
+	// version 4: isotropy of radiation pattern
+	{"iso", "alter table performance add column iso float default null"},
+	// version 5: relative SWR bandwidth
+	{"bw", "alter table performance add column bw float default null"},
+	// version 6: gain in reference direction
+	{"ghoriz", "alter table performance add column ghoriz float default null"},
+	// version 7: antenna Q
+	{"q", "alter table performance add column q float default null"},
+	// version 8: peak gain in azimuth plane
+	{"gaz", "alter table performance add column gaz float default null"},
+	// version 9: peak gain in elevation plane
+	{"gel", "alter table performance add column gel float default null"},
+	// version 10: gain including feedline losses
+	{"gsys", "alter table performance add column gsys float default null"},
+	// version 11: indexes for set queries and sorted performance values
+	{"", "create index if not exists idx_set on performance(fdir,k,param);" +
+		"create index if not exists idx_gmax on performance(Gmax);" +
+		"create index if not exists idx_gmean on performance(Gmean);" +
+		"create index if not exists idx_sd on performance(SD);" +
+		"create index if not exists idx_z on performance(Zr,Zi)"},
+	// version 12-15: antenna size (bounding box and wire length)
+	{"sizeX", "alter table performance add column sizeX float default null"},
+	{"sizeY", "alter table performance add column sizeY float default null"},
+	{"sizeZ", "alter table performance add column sizeZ float default null"},
+	{"wirelen", "alter table performance add column wirelen float default null"},
+	// version 16: ground and feedpoint spec
+	{"setup", "alter table performance add column setup blob default null"},
+}
+
 // Database for optimization results
 type Database struct {
 	inst *sql.DB
 }
 
-// Open SQLite3 database from file
+// Open SQLite3 database from file. New databases are initialized with
+// the current schema; older databases are migrated to it.
 func OpenDatabase(fname string) (db *Database, err error) {
 	db = new(Database)
-	if db.inst, err = sql.Open("sqlite3", fname); err == nil {
-		var num int64
-		row := db.inst.QueryRow("select count(*) from performance")
-		if err = row.Scan(&num); err != nil {
-			// initialize database
-			_, err = db.inst.Exec(ini)
+	if db.inst, err = sql.Open("sqlite3", fname); err != nil {
+		return
+	}
+	var num int64
+	row := db.inst.QueryRow("select count(*) from performance")
+	if err = row.Scan(&num); err != nil {
+		// initialize database
+		if _, err = db.inst.Exec(ini); err != nil {
+			return
 		}
+		err = db.setMeta()
+		return
 	}
+	err = db.migrate()
+	return
+}
+
+// Meta returns the value of a metadata entry
+func (db *Database) Meta(key string) (val string, err error) {
+	row := db.inst.QueryRow("select value from meta where key=?", key)
+	err = row.Scan(&val)
 	return
 }
 
+// set schema version and (antgen) build version in metadata
+func (db *Database) setMeta() (err error) {
+	stmt := "replace into meta(key,value) values(?,?)"
+	if _, err = db.inst.Exec(stmt, "schema", SchemaVersion); err != nil {
+		return
+	}
+	_, err = db.inst.Exec(stmt, "version", BuildVersion())
+	return
+}
+
+// migrate database schema to current version
+func (db *Database) migrate() (err error) {
+	// get schema version of database (no metadata: version 1)
+	version := 1
+	var n int
+	row := db.inst.QueryRow("select count(*) from sqlite_master where type='table' and name='meta'")
+	if err = row.Scan(&n); err != nil {
+		return
+	}
+	if n == 0 {
+		stmt := "create table meta (key varchar(31) primary key, value varchar(255) not null)"
+		if _, err = db.inst.Exec(stmt); err != nil {
+			return
+		}
+	} else {
+		var val string
+		switch val, err = db.Meta("schema"); {
+		case err == nil:
+			if version, err = strconv.Atoi(val); err != nil {
+				return
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			// metadata without schema version
+		default:
+			return
+		}
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("database schema %d newer than supported (%d)", version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return nil
+	}
+	// apply missing migrations
+	var tx *sql.Tx
+	if tx, err = db.inst.Begin(); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	for _, m := range migrations[version-1:] {
+		// databases without metadata may already have the column
+		if len(m.column) > 0 {
+			var n int
+			row := tx.QueryRow("select count(*) from pragma_table_info('performance') where name=?", m.column)
+			if err = row.Scan(&n); err != nil {
+				return
+			}
+			if n > 0 {
+				continue
+			}
+		}
+		if _, err = tx.Exec(m.stmt); err != nil {
+			return
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return db.setMeta()
+}
+
 // Close database
 func (db *Database) Close() error {
 	if db.inst == nil {
@@ -355,44 +617,159 @@ func (db *Database) Close() error {
 
 // Insert model parameters into database
 func (db *Database) Insert(rec *Record) error {
-	stmt := "replace into performance(fdir,ftag,mdl,gen,opt,seed,freq,mat,dia," +
-		"height,ground,gType,k,param,Gmax,Gmean,SD,Zr,Zi,mthds,steps,sims,elapsed)" +
-		" values(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
-	_, err := db.inst.Exec(stmt,
+	return insertRecord(func(args ...any) (sql.Result, error) {
+		return db.inst.Exec(insertStmt, args...)
+	}, rec)
+}
+
+// Batch of inserts in a single transaction (bulk import). The insert
+// statement is prepared once for all records of the batch.
+type Batch struct {
+	tx   *sql.Tx
+	stmt *sql.Stmt
+}
+
+// BeginBatch starts a batch of inserts. The inserts are only stored in
+// the database on Commit.
+func (db *Database) BeginBatch() (b *Batch, err error) {
+	b = new(Batch)
+	if b.tx, err = db.inst.Begin(); err != nil {
+		return
+	}
+	if b.stmt, err = b.tx.Prepare(insertStmt); err != nil {
+		b.tx.Rollback()
+	}
+	return
+}
+
+// Insert model parameters in batch
+func (b *Batch) Insert(rec *Record) error {
+	return insertRecord(b.stmt.Exec, rec)
+}
+
+// Commit all inserts of the batch
+func (b *Batch) Commit() error {
+	b.stmt.Close()
+	return b.tx.Commit()
+}
+
+// Rollback discards all inserts of the batch
+func (b *Batch) Rollback() error {
+	b.stmt.Close()
+	return b.tx.Rollback()
+}
+
+// statement to insert (or replace) model parameters
+const insertStmt = "replace into performance(fdir,ftag,mdl,gen,opt,seed,freq,mat,dia," +
+	"height,ground,gType,k,param,Gmax,Gmean,SD,Zr,Zi,eff,iso,bw,ghoriz,q,gaz,gel,gsys,sizeX,sizeY,sizeZ,wirelen," +
+	"mthds,steps,sims,elapsed,track,setup) values(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+
+// insert model parameters (with the insert statement executed by 'exec')
+func insertRecord(exec func(args ...any) (sql.Result, error), rec *Record) error {
+	setup, err := json.Marshal(&recordSetup{rec.Gnd, rec.Feedpt})
+	if err != nil {
+		return err
+	}
+	_, err = exec(
 		rec.Path, rec.Tag, rec.Mdl, rec.Gen, rec.Opt, rec.Seed, rec.Freq,
 		rec.Wire.Material, rec.Wire.Diameter, rec.Gnd.Height, rec.Gnd.Mode,
 		rec.Gnd.Type, rec.K, rec.Param, rec.Perf.Gain.Max, rec.Perf.Gain.Mean,
-		rec.Perf.Gain.SD, real(rec.Perf.Z), imag(rec.Perf.Z), rec.Stats.NumMthds,
+		rec.Perf.Gain.SD, real(rec.Perf.Z), imag(rec.Perf.Z), nullable(rec.Perf.Eff), nullable(rec.Perf.Iso),
+		nullable(rec.Perf.BW), nullable(rec.Perf.Ghoriz), nullable(rec.Perf.Q),
+		nullable(rec.Perf.Gaz), nullable(rec.Perf.Gel), nullable(rec.Perf.Gsys),
+		nullable(rec.Size[0]), nullable(rec.Size[1]), nullable(rec.Size[2]), nullable(rec.WireLen), rec.Stats.NumMthds,
 		rec.Stats.NumSteps, rec.Stats.NumSims, int(rec.Stats.Elapsed.Seconds()),
+		rec.Track, setup,
 	)
 	return err
 }
 
+// ground and feedpoint spec of a record (as stored in the database)
+type recordSetup struct {
+	Gnd    Ground `json:"ground"`
+	Feedpt Feedpt `json:"feedpt"`
+}
+
+// Track returns the optimization track of a model with given tag and
+// the specification (operating frequency, ground and feedpoint) it was
+// optimized for; the wire is part of the track. If the model directory
+// is empty, the tag must be unique in the database. Records stored
+// without ground and feedpoint spec use the ground mode, type and height
+// of the record and the default feedpoint.
+func (db *Database) Track(fdir, ftag string) (spec *Specification, track *TrackList, err error) {
+	stmt := "select freq,height,ground,gType,setup,track from performance where ftag=?"
+	args := []any{ftag}
+	if len(fdir) > 0 {
+		stmt += " and fdir=?"
+		args = append(args, fdir)
+	}
+	var rows *sql.Rows
+	if rows, err = db.inst.Query(stmt, args...); err != nil {
+		return
+	}
+	defer rows.Close()
+	var setup, data []byte
+	spec = new(Specification)
+	num := 0
+	for rows.Next() {
+		spec.Feedpt = Cfg.Def.Feedpt
+		if err = rows.Scan(&spec.Source.Freq, &spec.Ground.Height, &spec.Ground.Mode, &spec.Ground.Type, &setup, &data); err != nil {
+			return
+		}
+		num++
+	}
+	switch {
+	case num == 0:
+		err = fmt.Errorf("no model with tag '%s'", ftag)
+	case num > 1:
+		err = fmt.Errorf("tag '%s' not unique (specify model directory)", ftag)
+	case len(data) == 0:
+		err = fmt.Errorf("no track stored for model '%s'", ftag)
+	default:
+		if len(setup) > 0 {
+			rs := new(recordSetup)
+			if err = json.Unmarshal(setup, rs); err != nil {
+				return
+			}
+			spec.Ground, spec.Feedpt = rs.Gnd, rs.Feedpt
+		}
+		track = new(TrackList)
+		err = DecodeData(data, track)
+	}
+	return
+}
+
+// Model returns the performance record (and operating frequency) of the
+// model with given directory and tag.
+func (db *Database) Model(fdir, ftag string) (r *Row, freq int64, err error) {
+	stmt := "select " + rowColumns + ",freq from performance where fdir=? and ftag=?"
+	if r, err = scanRow(db.inst.QueryRow(stmt, fdir, ftag), &freq); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("no model '%s/%s'", fdir, ftag)
+		}
+		return nil, 0, err
+	}
+	return
+}
+
 // Set returns a set of performance records for a given directory
 func (db *Database) Set(fdir string, filter Index) (set *Set, err error) {
 	// perform query
-	tpl := "select id,k,param,Gmax,Gmean,SD,Zr,Zi,ftag from performance where fdir='%s' order by k,param asc"
-	stmt := fmt.Sprintf(tpl, fdir)
+	stmt := "select " + rowColumns + " from performance where fdir=? order by k,param asc"
 	var rows *sql.Rows
-	if rows, err = db.inst.Query(stmt); err != nil {
+	if rows, err = db.inst.Query(stmt, fdir); err != nil {
 		return
 	}
 	defer rows.Close()
 
 	// read data
 	set = NewSet()
-	var param sql.NullFloat64
 	for rows.Next() {
 		// read record from database
-		r := new(Row)
-		if err = rows.Scan(&r.id, &r.idx.k, &param, &r.gmax, &r.gmean, &r.sd, &r.zr, &r.zi, &r.ftag); err != nil {
+		var r *Row
+		if r, err = scanRow(rows); err != nil {
 			return
 		}
-		r.idx.param = math.NaN()
-		if param.Valid {
-			r.idx.param = param.Float64
-		}
-		r.fdir = fdir
 		// check if record matches filter
 		if filter.Match(r.idx) {
 			// add record to set
@@ -435,6 +812,33 @@ func (db *Database) ListPlotSets() (sets map[string]*PlotSet, err error) {
 	return
 }
 
+// ListColumns lists the columns with distinct values available in Distinct
+var ListColumns = []string{"fdir", "mdl", "gen", "opt", "mat", "freq"}
+
+// Distinct returns the (sorted) distinct values of a column in the database
+// (see ListColumns).
+func (db *Database) Distinct(column string) (list []string, err error) {
+	if !slices.Contains(ListColumns, column) {
+		return nil, fmt.Errorf("can't list '%s'", column)
+	}
+	stmt := "select distinct(" + column + ") from performance order by " + column + " asc"
+	var rows *sql.Rows
+	if rows, err = db.inst.Query(stmt); err != nil {
+		return
+	}
+	defer rows.Close()
+	var val sql.NullString
+	for rows.Next() {
+		if err = rows.Scan(&val); err != nil {
+			return
+		}
+		if val.Valid {
+			list = append(list, val.String)
+		}
+	}
+	return
+}
+
 // VarLists returns a list of (unique) 'k' and 'param' values for a dataset.
 // If 'set' is empty, the values represent parameters in the whole database.
 func (db *Database) VarLists(set string) (kList, pList []float64, err error) {
@@ -450,11 +854,13 @@ func (db *Database) VarLists(set string) (kList, pList []float64, err error) {
 // the whole database.
 func (db *Database) varList(set, par string) (list []float64, err error) {
 	clause := ""
+	var args []any
 	if len(set) > 0 {
-		clause = fmt.Sprintf("where fdir = '%s'", set)
+		clause = "where fdir = ?"
+		args = append(args, set)
 	}
 	stmt := fmt.Sprintf("select distinct(%s) from performance %s order by %s asc", par, clause, par)
-	rows, err := db.inst.Query(stmt)
+	rows, err := db.inst.Query(stmt, args...)
 	if err != nil {
 		return
 	}
@@ -470,10 +876,11 @@ func (db *Database) varList(set, par string) (list []float64, err error) {
 	return
 }
 
-// GetRows from the database with given where clause and ordering
-func (db *Database) GetRows(clause, order string) (list []*Row, err error) {
+// GetRows from the database with given where clause (and its arguments)
+// and ordering
+func (db *Database) GetRows(clause, order string, args ...any) (list []*Row, err error) {
 	// assemble query statement
-	stmt := "select Gmax,Gmean,SD,Zr,Zi,fdir,ftag from performance"
+	stmt := "select " + rowColumns + " from performance"
 	if len(clause) > 0 {
 		stmt += " where " + clause
 	}
@@ -482,15 +889,15 @@ func (db *Database) GetRows(clause, order string) (list []*Row, err error) {
 	}
 	// perform query
 	var rows *sql.Rows
-	if rows, err = db.inst.Query(stmt); err != nil {
+	if rows, err = db.inst.Query(stmt, args...); err != nil {
 		return
 	}
 	defer rows.Close()
 
 	// assemble result list
 	for rows.Next() {
-		r := new(Row)
-		if err = rows.Scan(&r.gmax, &r.gmean, &r.sd, &r.zr, &r.zi, &r.fdir, &r.ftag); err != nil {
+		var r *Row
+		if r, err = scanRow(rows); err != nil {
 			return
 		}
 		list = append(list, r)
@@ -498,6 +905,43 @@ func (db *Database) GetRows(clause, order string) (list []*Row, err error) {
 	return
 }
 
+// columns of the performance table read into a Row (see scanRow)
+const rowColumns = "id,k,param,Gmax,Gmean,SD,Zr,Zi,eff,iso,bw,ghoriz,q,gaz,gel,gsys," +
+	"sizeX,sizeY,sizeZ,wirelen,fdir,ftag"
+
+// scanRow reads a Row from a query result (columns 'rowColumns' followed
+// by the 'extra' columns); NULL values are mapped to NaN.
+func scanRow(res interface{ Scan(dest ...any) error }, extra ...any) (r *Row, err error) {
+	r = new(Row)
+	var param, eff, iso, bw, ghoriz, q, gaz, gel, gsys sql.NullFloat64
+	var sx, sy, sz, wlen sql.NullFloat64
+	dest := []any{&r.id, &r.idx.k, &param, &r.gmax, &r.gmean, &r.sd, &r.zr, &r.zi, &eff, &iso, &bw, &ghoriz, &q, &gaz, &gel, &gsys,
+		&sx, &sy, &sz, &wlen, &r.fdir, &r.ftag}
+	if err = res.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	r.size = Vec3{nanable(sx), nanable(sy), nanable(sz)}
+	r.wlen = nanable(wlen)
+	r.idx.param = nanable(param)
+	r.eff, r.iso, r.bw = nanable(eff), nanable(iso), nanable(bw)
+	r.ghoriz, r.q = nanable(ghoriz), nanable(q)
+	r.gaz, r.gel, r.gsys = nanable(gaz), nanable(gel), nanable(gsys)
+	return
+}
+
+// nullable maps NaN values to NULL in the database
+func nullable(v float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: v, Valid: !math.IsNaN(v)}
+}
+
+// nanable maps NULL values from the database to NaN
+func nanable(v sql.NullFloat64) float64 {
+	if !v.Valid {
+		return math.NaN()
+	}
+	return v.Float64
+}
+
 // DbStats holds database statistics
 type DbStats struct {
 	NumAnt   int64  // number of antennas
@@ -522,3 +966,44 @@ func (db *Database) Stats() (stats *DbStats) {
 	stats.Duration = FormatDuration(stats.Elapsed)
 	return
 }
+
+// GroupStats holds statistics for a group of database records
+type GroupStats struct {
+	Count    int64   // number of antennas
+	AvgGmax  float64 // average maximum gain
+	AvgSteps float64 // average number of optimization steps
+	AvgSims  float64 // average number of simulations
+	BestGmax float64 // best maximum gain
+	BestRef  string  // reference to best antenna (fdir/ftag)
+}
+
+// StatsGroups lists the columns records can be grouped by in StatsBy
+var StatsGroups = []string{"mdl", "gen", "opt", "mat", "freq"}
+
+// StatsBy returns database statistics grouped by the values of a column
+// (see StatsGroups).
+func (db *Database) StatsBy(column string) (stats map[string]*GroupStats, err error) {
+	if !slices.Contains(StatsGroups, column) {
+		return nil, fmt.Errorf("can't group by '%s'", column)
+	}
+	// N.B.: SQLite takes bare columns (fdir, ftag) from the row with max(Gmax)
+	stmt := "select " + column + ",count(*),avg(Gmax),avg(steps),avg(sims),max(Gmax),fdir,ftag" +
+		" from performance group by " + column
+	var rows *sql.Rows
+	if rows, err = db.inst.Query(stmt); err != nil {
+		return
+	}
+	defer rows.Close()
+
+	stats = make(map[string]*GroupStats)
+	for rows.Next() {
+		var key, fdir, ftag string
+		gs := new(GroupStats)
+		if err = rows.Scan(&key, &gs.Count, &gs.AvgGmax, &gs.AvgSteps, &gs.AvgSims, &gs.BestGmax, &fdir, &ftag); err != nil {
+			return
+		}
+		gs.BestRef = fdir + "/" + ftag
+		stats[key] = gs
+	}
+	return
+}
@@ -0,0 +1,331 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestDatabaseInsert(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rec := &Record{
+		Freq:   435000000,
+		Wire:   Wire{Diameter: 0.002, Material: "CuL"},
+		Gnd:    Ground{Height: 10, Mode: 1, Type: 2, Epse: 13, Sig: 0.005},
+		Feedpt: Feedpt{Gap: 0.01, Extension: 0.002, HatSpokes: 4, HatLength: 0.05},
+		K:      0.25,
+		Param:  math.NaN(),
+		Perf: Performance{
+			Gain:   &Gain{Max: 2.15, Mean: -1, SD: 3},
+			Z:      complex(73, 42),
+			Eff:    math.NaN(),
+			Iso:    math.NaN(),
+			BW:     0.05,
+			Ghoriz: math.NaN(),
+			Q:      12.5,
+		},
+		Mdl:     "bend2d",
+		Path:    "70cm",
+		Tag:     "1000",
+		Track:   []byte(`{"segL":0.01,"num":2,"track":[]}`),
+		Size:    Vec3{0.3, 0.1, 0},
+		WireLen: 0.34,
+	}
+	if err = db.Insert(rec); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Stats().NumAnt; n != 1 {
+		t.Fatalf("%d records in database", n)
+	}
+	rows, err := db.GetRows("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Value("Gmax") != 2.15 || rows[0].Value("Zi") != 42 {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+	if !math.IsNaN(rows[0].Value("Eff")) || rows[0].Value("Q") != 12.5 || rows[0].Value("BW") != 0.05 {
+		t.Errorf("nullable values not read: eff=%g, Q=%g, BW=%g",
+			rows[0].Value("Eff"), rows[0].Value("Q"), rows[0].Value("BW"))
+	}
+	spec, track, err := db.Track("70cm", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Source.Freq != rec.Freq || track.Num != 2 {
+		t.Errorf("unexpected track %d/%v", spec.Source.Freq, *track)
+	}
+	if spec.Ground != rec.Gnd || spec.Feedpt != rec.Feedpt {
+		t.Errorf("setup not restored: %+v/%+v", spec.Ground, spec.Feedpt)
+	}
+	row, freq, err := db.Model("70cm", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freq != rec.Freq || row.Value("Gmax") != 2.15 || row.Value("Q") != 12.5 || row.Value("Extent") != 0.3 {
+		t.Errorf("unexpected model %d/%v", freq, *row)
+	}
+	if _, _, err = db.Model("70cm", "../1000"); err == nil {
+		t.Error("unknown model found")
+	}
+	// set names are query parameters (no SQL injection)
+	kList, _, err := db.VarLists("nope' or '1'='1")
+	if err != nil || len(kList) != 0 {
+		t.Errorf("unknown set has values: %v (%v)", kList, err)
+	}
+	set, err := db.Set("nope' or '1'='1", NewIndex(math.NaN(), math.NaN()))
+	if err != nil || len(set.data) != 0 {
+		t.Errorf("unknown set has rows (%v)", err)
+	}
+	if set, err = db.Set("70cm", NewIndex(math.NaN(), math.NaN())); err != nil || len(set.data) != 1 {
+		t.Fatalf("set not found (%v)", err)
+	}
+	if r := set.data[0]; r.Value("SizeY") != 0.1 || r.Value("WireLen") != 0.34 {
+		t.Errorf("size not read: %v/%g", r.size, r.wlen)
+	}
+	// bound query arguments
+	if rows, err = db.GetRows("fdir = ?", "", "nope' or '1'='1"); err != nil || len(rows) != 0 {
+		t.Errorf("unknown set has rows (%v)", err)
+	}
+	if rows, err = db.GetRows("fdir = ? and (Gmax > 2)", "", "70cm"); err != nil || len(rows) != 1 {
+		t.Errorf("set not found (%v)", err)
+	}
+}
+
+func TestDatabaseBatch(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	insert := func(tags ...string) *Batch {
+		b, err := db.BeginBatch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tag := range tags {
+			rec := &Record{
+				Param: math.NaN(),
+				Perf: Performance{
+					Gain: &Gain{Max: 1},
+					Eff:  math.NaN(), Iso: math.NaN(), BW: math.NaN(), Ghoriz: math.NaN(), Q: math.NaN(),
+				},
+				Path: "2m",
+				Tag:  tag,
+			}
+			if err = b.Insert(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return b
+	}
+	// discarded batch
+	if err = insert("a", "b").Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Stats().NumAnt; n != 0 {
+		t.Fatalf("%d records after rollback", n)
+	}
+	// committed batch (with replaced record)
+	if err = insert("a", "b", "a").Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Stats().NumAnt; n != 2 {
+		t.Fatalf("%d records after commit", n)
+	}
+}
+
+func TestDatabaseStatsBy(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i, gen := range []string{"stroll", "stroll", "v[ang=120]"} {
+		rec := &Record{
+			Wire:  Wire{Material: "CuL"},
+			Param: math.NaN(),
+			Perf: Performance{
+				Gain: &Gain{Max: float64(i + 1)},
+				Eff:  math.NaN(), Iso: math.NaN(), BW: math.NaN(), Ghoriz: math.NaN(), Q: math.NaN(),
+			},
+			Gen:   gen,
+			Stats: Stats{NumSims: 10 * (i + 1)},
+			Path:  "2m",
+			Tag:   string(rune('a' + i)),
+		}
+		if err = db.Insert(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats, err := db.StatsBy("gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := stats["stroll"]
+	if len(stats) != 2 || gs == nil || gs.Count != 2 {
+		t.Fatalf("unexpected groups %v", stats)
+	}
+	if gs.AvgGmax != 1.5 || gs.AvgSims != 15 || gs.BestGmax != 2 || gs.BestRef != "2m/b" {
+		t.Errorf("unexpected group stats %+v", *gs)
+	}
+	if _, err = db.StatsBy("fdir;drop table performance"); err == nil {
+		t.Error("invalid column accepted")
+	}
+	list, err := db.Distinct("gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0] != "stroll" || list[1] != "v[ang=120]" {
+		t.Errorf("unexpected distinct values %v", list)
+	}
+	if _, err = db.Distinct("fdir from performance;--"); err == nil {
+		t.Error("invalid column accepted")
+	}
+	set, err := db.Set("2m", NewIndex(math.NaN(), math.NaN()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best := set.Best("Gmax"); best == nil || best.Value("Gmax") != 3 {
+		t.Errorf("unexpected best row %v", best)
+	}
+}
+
+func TestDatabaseIndexes(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "test.db")
+	db, err := OpenDatabase(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// downgrade to schema without indexes
+	if _, err = db.inst.Exec("drop index idx_set; drop index idx_gmax; update meta set value='10' where key='schema'"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// migration restores the indexes
+	if db, err = OpenDatabase(fname); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	row := db.inst.QueryRow("select count(*) from sqlite_master where type='index' and name in ('idx_set','idx_gmax')")
+	if err = row.Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("%d of 2 indexes after migration", n)
+	}
+}
+
+func TestDatabaseMeta(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "test.db")
+	for _, stmt := range []string{
+		"drop table meta",                     // database without metadata
+		"delete from meta where key='schema'", // metadata without schema version
+	} {
+		db, err := OpenDatabase(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.inst.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		// migration (re-)creates the schema version
+		if db, err = OpenDatabase(fname); err != nil {
+			t.Fatalf("%s: %s", stmt, err)
+		}
+		if val, err := db.Meta("schema"); err != nil || val != strconv.Itoa(SchemaVersion) {
+			t.Errorf("%s: schema version '%s' (%v)", stmt, val, err)
+		}
+		db.Close()
+	}
+}
+
+// Query performance on a large database (100k models in 100 sets) with and
+// without indexes:
+//
+//	go test -run - -bench Database ./lib
+func BenchmarkDatabaseQuery(b *testing.B) {
+	db, err := OpenDatabase(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	batch, err := db.BeginBatch()
+	if err != nil {
+		b.Fatal(err)
+	}
+	rnd := Randomizer(1000)
+	for i := range 100000 {
+		rec := &Record{
+			K:     0.25 + float64(i%1000)/1000,
+			Param: math.NaN(),
+			Perf: Performance{
+				Gain: &Gain{Max: 10 * rnd.Float64(), Mean: -5 * rnd.Float64(), SD: 5 * rnd.Float64()},
+				Z:    complex(200*rnd.Float64(), 400*rnd.Float64()-200),
+				Eff:  math.NaN(), Iso: math.NaN(), BW: math.NaN(), Ghoriz: math.NaN(), Q: math.NaN(),
+			},
+			Path: fmt.Sprintf("set%d", i%100),
+			Tag:  strconv.Itoa(i),
+		}
+		if err = batch.Insert(rec); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err = batch.Commit(); err != nil {
+		b.Fatal(err)
+	}
+	queries := func(mode string) {
+		b.Run("set/"+mode, func(b *testing.B) {
+			for i := range b.N {
+				if _, err := db.Set(fmt.Sprintf("set%d", i%100), NewIndex(math.NaN(), math.NaN())); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("best/"+mode, func(b *testing.B) {
+			for range b.N {
+				if _, err := db.GetRows("Zr>=45 and Zr<=55 and abs(Zi)<5", "Gmax desc"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	queries("indexed")
+	if _, err = db.inst.Exec("drop index idx_set; drop index idx_gmax; drop index idx_z"); err != nil {
+		b.Fatal(err)
+	}
+	queries("scan")
+}
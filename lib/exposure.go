@@ -0,0 +1,73 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"math"
+)
+
+// Exposure is a far-field estimate of the RF exposure compliance distance
+// in the direction of maximum gain. The field strength at distance d of an
+// antenna radiating power P with (linear) gain G is E = sqrt(30·P·G)/d.
+// The estimate assumes that all source power is radiated (no mismatch or
+// wire losses), so it errs on the safe side; it is only valid outside the
+// reactive and radiating near field (see FarField).
+type Exposure struct {
+	Limit    float64 // field strength limit (V/m)
+	Power    float64 // source power (W)
+	Gain     float64 // maximum gain (dBi)
+	Dist     float64 // minimum safe distance (m)
+	FarField float64 // start of far-field region (m)
+}
+
+// EvalExposure estimates the minimum distance for a field strength limit
+// (in V/m) for an antenna of given size (largest dimension) at wavelength
+// lambda.
+func EvalExposure(power, gain, size, lambda, limit float64) (e *Exposure, err error) {
+	if limit <= 0 || power <= 0 {
+		err = fmt.Errorf("exposure: invalid limit (%g V/m) or power (%g W)", limit, power)
+		return
+	}
+	e = &Exposure{
+		Limit:    limit,
+		Power:    power,
+		Gain:     gain,
+		Dist:     math.Sqrt(30*power*math.Pow(10, gain/10)) / limit,
+		FarField: max(2*size*size/lambda, lambda),
+	}
+	return
+}
+
+// Valid returns true if the safe distance lies in the far-field region.
+func (e *Exposure) Valid() bool {
+	return e.Dist >= e.FarField
+}
+
+// Info returns comment lines for the exposure estimate.
+func (e *Exposure) Info() (lines []string) {
+	lines = append(lines, fmt.Sprintf("Exposure: d=%.2fm for E<=%gV/m (P=%gW, Gmax=%.2fdBi, far field from %.2fm)",
+		e.Dist, e.Limit, e.Power, e.Gain, e.FarField))
+	if !e.Valid() {
+		lines = append(lines, "Exposure: WARN: distance is in the near field; far-field estimate not reliable")
+	}
+	return
+}
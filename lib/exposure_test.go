@@ -0,0 +1,52 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExposure(t *testing.T) {
+	// 100W into an isotropic radiator, 28V/m: sqrt(3000)/28 = 1.956m
+	e, err := EvalExposure(100, 0, 0.3, 0.69, 28)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(e.Dist-1.956) > 0.001 {
+		t.Fatalf("unexpected distance %f", e.Dist)
+	}
+	if !e.Valid() || len(e.Info()) != 1 {
+		t.Fatalf("unexpected far-field check: %v", e.Info())
+	}
+	// 3dB more gain: distance grows by sqrt(2)
+	e2, _ := EvalExposure(100, 10*math.Log10(2), 0.3, 0.69, 28)
+	if math.Abs(e2.Dist/e.Dist-math.Sqrt2) > 1e-9 {
+		t.Fatalf("unexpected gain scaling: %f", e2.Dist/e.Dist)
+	}
+	// low power: safe distance inside the near field
+	if e, _ = EvalExposure(0.01, 0, 0.3, 0.69, 28); e.Valid() || len(e.Info()) != 2 {
+		t.Fatalf("expected near-field warning: %v", e.Info())
+	}
+	if _, err = EvalExposure(100, 0, 0.3, 0.69, 0); err == nil {
+		t.Fatal("expected error for invalid limit")
+	}
+}
@@ -0,0 +1,272 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed arithmetic expression over named values. Supported are
+// numbers, identifiers, the operators '+', '-', '*', '/' and '^' (power),
+// parentheses and the functions abs, sqrt, exp, ln and log10.
+type Expr struct {
+	src  string   // expression source
+	root exprNode // parsed expression
+	vars []string // list of referenced identifiers
+}
+
+// ParseExpr parses an expression string.
+func ParseExpr(s string) (e *Expr, err error) {
+	p := &exprParser{src: s}
+	if p.toks, err = exprLexer.scan(s); err != nil {
+		err = p.fail("%s", err)
+		return
+	}
+	p.next()
+	e = &Expr{src: s}
+	if e.root, err = p.expr(); err != nil {
+		return
+	}
+	if p.tok != tokEnd {
+		err = p.fail("unexpected '%s'", p.val)
+		return
+	}
+	e.vars = p.vars
+	return
+}
+
+// String returns the expression source
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Vars returns the list of identifiers referenced in the expression
+func (e *Expr) Vars() []string {
+	return e.vars
+}
+
+// Eval evaluates the expression with values of identifiers provided
+// by a callback.
+func (e *Expr) Eval(vals func(string) float64) float64 {
+	return e.root.eval(vals)
+}
+
+//----------------------------------------------------------------------
+// expression tree
+//----------------------------------------------------------------------
+
+type exprNode interface {
+	eval(vals func(string) float64) float64
+}
+
+type exprNum float64
+
+func (n exprNum) eval(func(string) float64) float64 { return float64(n) }
+
+type exprVar string
+
+func (v exprVar) eval(vals func(string) float64) float64 { return vals(string(v)) }
+
+type exprNeg struct{ x exprNode }
+
+func (n *exprNeg) eval(vals func(string) float64) float64 { return -n.x.eval(vals) }
+
+type exprOp struct {
+	op   byte
+	l, r exprNode
+}
+
+func (o *exprOp) eval(vals func(string) float64) float64 {
+	l, r := o.l.eval(vals), o.r.eval(vals)
+	switch o.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	case '/':
+		return l / r
+	case '^':
+		return math.Pow(l, r)
+	}
+	return math.NaN()
+}
+
+// functions available in expressions
+var exprFuncs = map[string]func(float64) float64{
+	"abs":   math.Abs,
+	"sqrt":  math.Sqrt,
+	"exp":   math.Exp,
+	"ln":    math.Log,
+	"log10": math.Log10,
+}
+
+type exprFunc struct {
+	f func(float64) float64
+	x exprNode
+}
+
+func (f *exprFunc) eval(vals func(string) float64) float64 { return f.f(f.x.eval(vals)) }
+
+//----------------------------------------------------------------------
+// recursive-descent parser
+//----------------------------------------------------------------------
+
+type exprParser struct {
+	src  string   // expression source
+	toks []token  // tokens of expression
+	pos  int      // index of next token
+	tok  int      // type of current token
+	val  string   // current token
+	vars []string // referenced identifiers
+}
+
+// fail returns a parser error
+func (p *exprParser) fail(format string, args ...any) error {
+	return fmt.Errorf("expression '%s': %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token
+func (p *exprParser) next() {
+	t := p.toks[p.pos]
+	p.tok, p.val = t.kind, t.val
+	if t.kind != tokEnd {
+		p.pos++
+	}
+}
+
+// expr := term { ('+'|'-') term }
+func (p *exprParser) expr() (n exprNode, err error) {
+	if n, err = p.term(); err != nil {
+		return
+	}
+	for p.tok == tokOp && (p.val == "+" || p.val == "-") {
+		op := p.val[0]
+		p.next()
+		var r exprNode
+		if r, err = p.term(); err != nil {
+			return
+		}
+		n = &exprOp{op, n, r}
+	}
+	return
+}
+
+// term := unary { ('*'|'/') unary }
+func (p *exprParser) term() (n exprNode, err error) {
+	if n, err = p.unary(); err != nil {
+		return
+	}
+	for p.tok == tokOp && (p.val == "*" || p.val == "/") {
+		op := p.val[0]
+		p.next()
+		var r exprNode
+		if r, err = p.unary(); err != nil {
+			return
+		}
+		n = &exprOp{op, n, r}
+	}
+	return
+}
+
+// unary := '-' unary | power
+func (p *exprParser) unary() (n exprNode, err error) {
+	if p.tok == tokOp && p.val == "-" {
+		p.next()
+		if n, err = p.unary(); err != nil {
+			return
+		}
+		n = &exprNeg{n}
+		return
+	}
+	return p.power()
+}
+
+// power := primary [ '^' unary ]
+func (p *exprParser) power() (n exprNode, err error) {
+	if n, err = p.primary(); err != nil {
+		return
+	}
+	if p.tok == tokOp && p.val == "^" {
+		p.next()
+		var r exprNode
+		if r, err = p.unary(); err != nil {
+			return
+		}
+		n = &exprOp{'^', n, r}
+	}
+	return
+}
+
+// primary := number | ident | func '(' expr ')' | '(' expr ')'
+func (p *exprParser) primary() (n exprNode, err error) {
+	switch p.tok {
+	case tokNum:
+		// numbers are validated by the lexer
+		v, _ := strconv.ParseFloat(p.val, 64)
+		n = exprNum(v)
+		p.next()
+	case tokIdent:
+		name := p.val
+		p.next()
+		if p.tok == tokOp && p.val == "(" {
+			f, ok := exprFuncs[strings.ToLower(name)]
+			if !ok {
+				err = p.fail("unknown function '%s'", name)
+				return
+			}
+			var x exprNode
+			if x, err = p.group(); err != nil {
+				return
+			}
+			n = &exprFunc{f, x}
+			return
+		}
+		p.vars = append(p.vars, name)
+		n = exprVar(name)
+	case tokOp:
+		if p.val == "(" {
+			return p.group()
+		}
+		err = p.fail("unexpected '%s'", p.val)
+	default:
+		err = p.fail("unexpected end")
+	}
+	return
+}
+
+// group := '(' expr ')'
+func (p *exprParser) group() (n exprNode, err error) {
+	p.next()
+	if n, err = p.expr(); err != nil {
+		return
+	}
+	if p.tok != tokOp || p.val != ")" {
+		err = p.fail("missing ')'")
+		return
+	}
+	p.next()
+	return
+}
@@ -0,0 +1,58 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	vals := map[string]float64{"Gmax": 3, "Loss": -0.5, "k": 0.25}
+	get := func(name string) float64 { return vals[name] }
+	for _, tc := range []struct {
+		s string
+		v float64
+	}{
+		{"Gmax - 10*Loss", 8},
+		{"-Gmax^2", -9},
+		{"2^3^2", 512},
+		{"(Gmax+1)/4", 1},
+		{"log10(100) + abs(Loss)", 2.5},
+		{"1.5e1*k", 3.75},
+	} {
+		e, err := ParseExpr(tc.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := e.Eval(get); math.Abs(v-tc.v) > 1e-12 {
+			t.Errorf("%s: got %f, expected %f", tc.s, v, tc.v)
+		}
+	}
+	for _, s := range []string{"", "Gmax +", "(Gmax", "foo(2)", "Gmax ! 2"} {
+		if _, err := ParseExpr(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+	if _, err := PlotExpr("Gmax - Foo"); err == nil {
+		t.Error("unknown identifier not detected")
+	}
+}
@@ -0,0 +1,180 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"slices"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ParetoFront returns the points of a list that are not dominated by
+// another point: no other point has a smaller (or equal) X and a larger Y.
+// The front is sorted by increasing X (and Y).
+func ParetoFront(pts plotter.XYs) (front plotter.XYs) {
+	sorted := slices.Clone(pts)
+	slices.SortFunc(sorted, func(a, b plotter.XY) int {
+		if a.X == b.X {
+			// larger value first (dominates the others)
+			return cmp.Compare(b.Y, a.Y)
+		}
+		return cmp.Compare(a.X, b.X)
+	})
+	best := math.Inf(-1)
+	for _, pt := range sorted {
+		if pt.Y > best {
+			front = append(front, pt)
+			best = pt.Y
+		}
+	}
+	return
+}
+
+// FrontierPoint is a model on the gain-vs-size frontier
+type FrontierPoint struct {
+	Size  float64 // antenna size
+	Value float64 // performance value (gain)
+	Row   *Row    // model
+}
+
+// Frontier computes the best performance value achievable at a given
+// antenna size: the size range of the models is divided into buckets and
+// the best model in each bucket is selected. Of those, only models that
+// improve on all smaller models (Pareto front) are returned (sorted by
+// size). Size and target are plot values or expressions (like "Extent" or
+// "SizeX*SizeY"); models without a size (or value) are skipped.
+func Frontier(rows []*Row, size, target string, buckets int) (front []*FrontierPoint, err error) {
+	for _, name := range []string{size, target} {
+		if !isPlotValue(name) {
+			err = fmt.Errorf("unknown plot value '%s'", name)
+			return
+		}
+	}
+	if buckets < 1 {
+		err = fmt.Errorf("invalid number of buckets (%d)", buckets)
+		return
+	}
+	// collect models with size and value
+	var pts []*FrontierPoint
+	sMin, sMax := math.Inf(1), math.Inf(-1)
+	for _, r := range rows {
+		s, v := r.Value(size), r.Value(target)
+		if math.IsNaN(s) || math.IsNaN(v) || math.IsInf(s, 0) {
+			continue
+		}
+		pts = append(pts, &FrontierPoint{Size: s, Value: v, Row: r})
+		sMin, sMax = min(sMin, s), max(sMax, s)
+	}
+	if len(pts) == 0 {
+		err = fmt.Errorf("no models with '%s' and '%s'", size, target)
+		return
+	}
+	// best model per bucket
+	best := make([]*FrontierPoint, buckets)
+	width := (sMax - sMin) / float64(buckets)
+	for _, pt := range pts {
+		i := buckets - 1
+		if width > 0 {
+			i = min(int((pt.Size-sMin)/width), buckets-1)
+		}
+		if best[i] == nil || pt.Value > best[i].Value {
+			best[i] = pt
+		}
+	}
+	// Pareto front of bucket winners
+	var xy plotter.XYs
+	idx := make(map[plotter.XY]*FrontierPoint)
+	for _, pt := range best {
+		if pt == nil {
+			continue
+		}
+		p := plotter.XY{X: pt.Size, Y: pt.Value}
+		xy = append(xy, p)
+		idx[p] = pt
+	}
+	for _, p := range ParetoFront(xy) {
+		front = append(front, idx[p])
+	}
+	return
+}
+
+// PlotFrontier renders the frontier as a line plot (value over size) on
+// top of a scatter plot of all models.
+func PlotFrontier(rows []*Row, front []*FrontierPoint, size, target, format string) (out string, err error) {
+	p := plot.New()
+	p.Title.Text = target + " vs. " + size
+	p.X.Label.Text = size
+	p.Y.Label.Text = target
+
+	// all models
+	var all plotter.XYs
+	for _, r := range rows {
+		s, v := r.Value(size), r.Value(target)
+		if math.IsNaN(s) || math.IsNaN(v) || math.IsInf(s, 0) {
+			continue
+		}
+		all = append(all, plotter.XY{X: s, Y: v})
+	}
+	var sc *plotter.Scatter
+	if sc, err = plotter.NewScatter(all); err != nil {
+		return
+	}
+	sc.GlyphStyle.Color = color.RGBA{R: 160, G: 160, B: 160, A: 255}
+	sc.GlyphStyle.Radius = vg.Points(1.5)
+	p.Add(sc)
+	p.Legend.Add("models", sc)
+
+	// frontier
+	data := make(plotter.XYs, len(front))
+	for i, pt := range front {
+		data[i] = plotter.XY{X: pt.Size, Y: pt.Value}
+	}
+	var line *plotter.Line
+	var pts *plotter.Scatter
+	if line, pts, err = plotter.NewLinePoints(data); err != nil {
+		return
+	}
+	_, line.LineStyle = PlotStyle(0)
+	pts.GlyphStyle.Color = line.LineStyle.Color
+	pts.GlyphStyle.Shape = draw.CircleGlyph{}
+	p.Add(line, pts)
+	p.Legend.Add("frontier", line, pts)
+
+	var wrt io.WriterTo
+	if wrt, err = p.WriterTo(18*vg.Centimeter, 18*vg.Centimeter, format); err != nil {
+		return
+	}
+	buf := new(bytes.Buffer)
+	if _, err = wrt.WriteTo(buf); err != nil {
+		return
+	}
+	out = buf.String()
+	return
+}
@@ -0,0 +1,93 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+func TestParetoFront(t *testing.T) {
+	pts := plotter.XYs{
+		{X: 3, Y: 5}, {X: 1, Y: 2}, {X: 2, Y: 1}, {X: 2, Y: 4},
+		{X: 4, Y: 4}, {X: 1, Y: 1}, {X: 5, Y: 6}, {X: 2, Y: 3},
+	}
+	want := plotter.XYs{{X: 1, Y: 2}, {X: 2, Y: 4}, {X: 3, Y: 5}, {X: 5, Y: 6}}
+	front := ParetoFront(pts)
+	if len(front) != len(want) {
+		t.Fatalf("front mismatch: %v", front)
+	}
+	for i, pt := range front {
+		if pt != want[i] {
+			t.Errorf("point %d: got %v, want %v", i, pt, want[i])
+		}
+	}
+}
+
+func TestFrontier(t *testing.T) {
+	// models: span (SizeX) and gain
+	var rows []*Row
+	for i, v := range [][2]float64{
+		{0.10, 0.5}, {0.12, 1.0}, {0.15, 0.2}, {0.25, 1.5}, {0.28, 1.1},
+		{0.40, 1.2}, {0.55, 2.1}, {0.60, 1.9}, {0.95, 2.0}, {1.00, 2.2},
+		{math.NaN(), 9}, // no size information
+	} {
+		rows = append(rows, &Row{
+			id:   int64(i),
+			gmax: v[1],
+			size: Vec3{v[0], 0, 0},
+			wlen: math.NaN(),
+		})
+	}
+	front, err := Frontier(rows, "Extent", "Gmax", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bucket winners (width 0.18): 1.5@0.25, 1.2@0.40, 2.1@0.55, 2.2@1.00
+	// (fourth bucket is empty); 1.2@0.40 is dominated by 1.5@0.25
+	want := []int64{3, 6, 9}
+	if len(front) != len(want) {
+		t.Fatalf("frontier mismatch: %d points", len(front))
+	}
+	for i, pt := range front {
+		if pt.Row.id != want[i] {
+			t.Errorf("point %d: got model %d, want %d", i, pt.Row.id, want[i])
+		}
+	}
+	// plot frontier
+	out, err := PlotFrontier(rows, front, "Extent", "Gmax", "svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "<svg") {
+		t.Error("no SVG output")
+	}
+	// errors
+	if _, err = Frontier(rows, "WireLen", "Gmax", 5); err == nil {
+		t.Error("no error for missing wire lengths")
+	}
+	if _, err = Frontier(rows, "Size", "Gmax", 5); err == nil {
+		t.Error("no error for unknown size")
+	}
+}
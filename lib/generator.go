@@ -21,7 +21,7 @@
 package lib
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -52,21 +52,22 @@ type Generator interface {
 	Volatile() bool
 }
 
-// list of implemented generators (by name)
-var gens map[string]Generator
+// list of implemented generators (constructors by name)
+var gens map[string]func() Generator
 
 // register implemented generators.
 func init() {
-	set := func(g Generator) {
-		gens[g.Name()] = g
+	set := func(newGen func() Generator) {
+		gens[newGen().Name()] = newGen
 	}
-	gens = make(map[string]Generator)
-	set(new(GenStraight))
-	set(new(GenV))
-	set(new(GenWalk))
-	set(new(GenStroll))
-	set(new(GenTrespass))
-	set(new(GenGeo))
+	gens = make(map[string]func() Generator)
+	set(func() Generator { return new(GenStraight) })
+	set(func() Generator { return new(GenV) })
+	set(func() Generator { return new(GenWalk) })
+	set(func() Generator { return new(GenStroll) })
+	set(func() Generator { return new(GenTrespass) })
+	set(func() Generator { return new(GenGeo) })
+	set(func() Generator { return new(GenLoop) })
 }
 
 // GetGenerator by name
@@ -81,14 +82,124 @@ func GetGenerator(name string, lambda float64) (g Generator, err error) {
 		err = g.Init(param, lambda)
 		return
 	}
-	var ok bool
-	if g, ok = gens[s[0]]; !ok {
+	newGen, ok := gens[s[0]]
+	if !ok {
 		return nil, fmt.Errorf("unknown generator '%s'", name)
 	}
+	g = newGen()
 	err = g.Init(param, lambda)
 	return
 }
 
+// KindLoop is the kind of antenna with closed geometry (loop)
+const KindLoop = "loop"
+
+// IsClosed returns true if a generator creates closed geometries (loops)
+func IsClosed(g Generator) bool {
+	if c, ok := g.(interface{ Closed() bool }); ok {
+		return c.Closed()
+	}
+	return false
+}
+
+//----------------------------------------------------------------------
+
+// smoothing parameters for randomized generators: "smooth=<range>[x<passes>]"
+// and "pin" (keep first and last node unchanged)
+type smoothing struct {
+	rng    int  // smoothing range (number of nodes)
+	passes int  // number of smoothing passes
+	pin    bool // preserve end nodes
+}
+
+// parse a generator parameter (ignore non-smoothing parameters)
+func (s *smoothing) parse(kv []string) (err error) {
+	switch kv[0] {
+	case "smooth":
+		if len(kv) != 2 {
+			return errors.New("smooth: missing range")
+		}
+		v := strings.SplitN(kv[1], "x", 2)
+		if s.rng, err = strconv.Atoi(v[0]); err != nil {
+			return
+		}
+		s.passes = 1
+		if len(v) > 1 {
+			if s.passes, err = strconv.Atoi(v[1]); err != nil {
+				return
+			}
+		}
+	case "pin":
+		s.pin = true
+	}
+	return
+}
+
+// apply smoothing to nodes
+func (s *smoothing) apply(nodes []*Node) []*Node {
+	return Smooth2DN(nodes, s.rng, s.passes, s.pin)
+}
+
+// parse taper parameter "taper=<val>" (-1 ≤ val ≤ 1)
+func parseTaper(kv []string) (t float64, err error) {
+	if len(kv) != 2 {
+		err = errors.New("taper: missing value")
+		return
+	}
+	if t, err = strconv.ParseFloat(kv[1], 64); err != nil {
+		return
+	}
+	if t < -1 || t > 1 {
+		err = fmt.Errorf("taper: value %f out of range [-1,1]", t)
+	}
+	return
+}
+
+// growth holds the common parameters of the randomized generators
+// (walk, stroll, trespass): smoothing and taper.
+type growth struct {
+	lambda float64
+	smooth smoothing
+	taper  float64
+	params string
+}
+
+// init parses the common generator parameters
+func (g *growth) init(params string, lambda float64) error {
+	g.lambda = lambda
+	g.params = params
+	g.smooth = smoothing{}
+	g.taper = 0
+	for _, p := range strings.Split(params, ",") {
+		v := strings.SplitN(p, "=", 2)
+		if err := g.smooth.parse(v); err != nil {
+			return err
+		}
+		if v[0] == "taper" {
+			var err error
+			if g.taper, err = parseTaper(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Taper returns the scale of the max. bending angle for node 'i' of
+// 'num' nodes: a positive taper reduces bending towards the tip (the
+// last node is scaled by 1-taper), a negative taper reduces bending
+// towards the feed point.
+func Taper(taper float64, i, num int) float64 {
+	if num < 2 {
+		return 1
+	}
+	x := float64(i) / float64(num-1)
+	if taper < 0 {
+		return 1 + taper*(1-x)
+	}
+	return 1 - taper*x
+}
+
 //----------------------------------------------------------------------
 
 // BendMax returns the max. bending angle between two segments of given
@@ -114,7 +225,7 @@ func (g *GenStraight) Init(params string, lambda float64) error {
 func (g *GenStraight) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
 	nodes := make([]*Node, num)
 	for i := range num {
-		nodes[i] = NewNode(segL, 0, 0)
+		nodes[i] = NewNode2D(segL, 0)
 	}
 	return nodes
 }
@@ -191,7 +302,7 @@ func (g *GenV) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
 		} else if g.end && i >= num-rnum {
 			ang = -dAng
 		}
-		nodes[i] = NewNode(segL, ang, 0)
+		nodes[i] = NewNode2D(segL, ang)
 	}
 	return nodes
 }
@@ -219,27 +330,12 @@ func (g *GenV) Volatile() bool {
 // GenWalk grows a line (dipole leg) by moving in one direction, so the
 // maximum direction vector of a segment is ±½π.
 type GenWalk struct {
-	lambda float64
-	rng    int
-	params string
+	growth
 }
 
 // Init generator with given parameters
 func (g *GenWalk) Init(params string, lambda float64) error {
-	g.lambda = lambda
-	g.params = params
-	for _, p := range strings.Split(params, ",") {
-		v := strings.SplitN(p, "=", 2)
-		switch v[0] {
-		case "smooth":
-			k, err := strconv.Atoi(v[1])
-			if err != nil {
-				return err
-			}
-			g.rng = int(k)
-		}
-	}
-	return nil
+	return g.init(params, lambda)
 }
 
 // Nodes returns the initial antenna geometry made from 'num' segments
@@ -249,17 +345,14 @@ func (g *GenWalk) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
 	nodes := make([]*Node, num)
 	dir := 0.
 	for i := range num {
-		ang := 2 * (rnd.Float64() - 0.5) * bendMax
+		ang := 2 * (rnd.Float64() - 0.5) * bendMax * Taper(g.taper, i, num)
 		if math.Abs(dir+ang) > RectAng {
 			ang = -ang
 		}
-		nodes[i] = NewNode(segL, ang, 0)
+		nodes[i] = NewNode2D(segL, ang)
 		dir += ang
 	}
-	if g.rng > 0 {
-		nodes = Smooth2D(nodes, g.rng)
-	}
-	return nodes
+	return g.smooth.apply(nodes)
 }
 
 // Info about generator
@@ -285,27 +378,12 @@ func (g *GenWalk) Volatile() bool {
 // GenStroll grows a line (dipole leg) by moving in any direction but
 // bounded by to positive x-coordinates.
 type GenStroll struct {
-	lambda float64
-	rng    int
-	params string
+	growth
 }
 
 // Init generator with given parameters
 func (g *GenStroll) Init(params string, lambda float64) error {
-	g.lambda = lambda
-	g.params = params
-	for _, p := range strings.Split(params, ",") {
-		v := strings.SplitN(p, "=", 2)
-		switch v[0] {
-		case "smooth":
-			k, err := strconv.Atoi(v[1])
-			if err != nil {
-				return err
-			}
-			g.rng = int(k)
-		}
-	}
-	return nil
+	return g.init(params, lambda)
 }
 
 // Nodes returns the initial antenna geometry made from 'num' segments
@@ -316,7 +394,7 @@ func (g *GenStroll) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
 	dir := 0.
 	x := 0.
 	for i := range num {
-		ang := 2 * (rnd.Float64() - 0.5) * bendMax
+		ang := 2 * (rnd.Float64() - 0.5) * bendMax * Taper(g.taper, i, num)
 		xn := x + segL*math.Cos(dir+ang)
 		if xn < 4*segL && InRange(dir, RectAng, 3*RectAng) {
 			mDir := (3 * segL / (xn - segL)) * math.Pi / 6
@@ -326,14 +404,11 @@ func (g *GenStroll) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
 				ang = -ang
 			}
 		}
-		nodes[i] = NewNode(segL, ang, 0)
+		nodes[i] = NewNode2D(segL, ang)
 		x = xn
 		dir = math.Mod(CircAng+dir+ang, CircAng)
 	}
-	if g.rng > 0 {
-		return Smooth2D(nodes, g.rng)
-	}
-	return nodes
+	return g.smooth.apply(nodes)
 }
 
 // Info about generator
@@ -377,7 +452,7 @@ func (g *GenGeo) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
 		log.Fatal(err)
 	}
 	geo := new(Geometry)
-	if err = json.Unmarshal(body, &geo); err != nil {
+	if err = DecodeData(body, geo); err != nil {
 		log.Fatal(err)
 	}
 
@@ -404,45 +479,96 @@ func (g *GenGeo) Volatile() bool {
 // GenTrespass grows a line (dipole leg) by moving in any direction
 // and widthout bounds.
 type GenTrespass struct {
-	lambda float64
-	rng    int
-	params string
+	growth
 }
 
 // Init generator with given parameters
 func (g *GenTrespass) Init(params string, lambda float64) error {
+	return g.init(params, lambda)
+}
+
+// Nodes returns the initial antenna geometry made from 'num' segments
+// of equal length 'segL'.
+func (g *GenTrespass) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
+	bendMax := BendMax(Cfg.Sim.MinRadius*g.lambda, segL)
+	nodes := make([]*Node, num)
+	dir := 0.
+	for i := range num {
+		ang := 2 * (rnd.Float64() - 0.5) * bendMax * Taper(g.taper, i, num)
+		nodes[i] = NewNode2D(segL, ang)
+		dir += ang
+	}
+	return g.smooth.apply(nodes)
+}
+
+// Info about generator
+func (g *GenTrespass) Info() string {
+	if len(g.params) > 0 {
+		return fmt.Sprintf("%s[%s]", g.Name(), g.params)
+	}
+	return g.Name()
+}
+
+// Name of generator
+func (g *GenTrespass) Name() string {
+	return "trespass"
+}
+
+// Volatile returns true if the generator is randomized
+func (g *GenTrespass) Volatile() bool {
+	return true
+}
+
+//----------------------------------------------------------------------
+
+// GenLoop returns a loop antenna: each leg traces one half of a regular
+// polygon (approximating a circle); the ends of the legs are connected
+// when the antenna is built.
+type GenLoop struct {
+	lambda float64 // wavelength
+	circ   float64 // circumference (in wavelength); 0 = derived from leg
+	params string  // supplied parameters
+}
+
+// Init generator with given parameters
+func (g *GenLoop) Init(params string, lambda float64) (err error) {
 	g.lambda = lambda
+	g.circ = 0
 	g.params = params
 	for _, p := range strings.Split(params, ",") {
 		v := strings.SplitN(p, "=", 2)
 		switch v[0] {
-		case "smooth":
-			k, err := strconv.Atoi(v[1])
-			if err != nil {
-				return err
+		case "circ":
+			if len(v) != 2 {
+				return errors.New("loop: missing circumference value")
+			}
+			if g.circ, err = strconv.ParseFloat(v[1], 64); err != nil {
+				return
 			}
-			g.rng = int(k)
 		}
 	}
 	return nil
 }
 
 // Nodes returns the initial antenna geometry made from 'num' segments
-// of equal length 'segL'.
-func (g *GenTrespass) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
-	bendMax := BendMax(Cfg.Sim.MinRadius*g.lambda, segL)
+// of equal length 'segL'. If a circumference is specified, the number of
+// segments is derived from it.
+func (g *GenLoop) Nodes(num int, segL float64, rnd *rand.Rand) []*Node {
+	// the polygon consists of the feed segment, two legs and the
+	// closing segment
+	if g.circ > 0 {
+		num = max(1, int(math.Round(g.circ*g.lambda/segL))/2-1)
+	}
+	ang := CircAng / float64(2*num+2)
 	nodes := make([]*Node, num)
-	dir := 0.
 	for i := range num {
-		ang := 2 * (rnd.Float64() - 0.5) * bendMax
-		nodes[i] = NewNode(segL, ang, 0)
-		dir += ang
+		nodes[i] = NewNode2D(segL, ang)
 	}
 	return nodes
 }
 
 // Info about generator
-func (g *GenTrespass) Info() string {
+func (g *GenLoop) Info() string {
 	if len(g.params) > 0 {
 		return fmt.Sprintf("%s[%s]", g.Name(), g.params)
 	}
@@ -450,11 +576,16 @@ func (g *GenTrespass) Info() string {
 }
 
 // Name of generator
-func (g *GenTrespass) Name() string {
-	return "trespass"
+func (g *GenLoop) Name() string {
+	return "loop"
 }
 
 // Volatile returns true if the generator is randomized
-func (g *GenTrespass) Volatile() bool {
+func (g *GenLoop) Volatile() bool {
+	return false
+}
+
+// Closed returns true as the generator creates a loop geometry
+func (g *GenLoop) Closed() bool {
 	return true
 }
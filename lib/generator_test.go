@@ -36,3 +36,18 @@ func TestLuaGenerator(t *testing.T) {
 	}
 	g.Nodes(num, segL, rnd)
 }
+
+func TestGetGeneratorInstances(t *testing.T) {
+	// each call returns a new generator (no shared parameters)
+	g1, err := GetGenerator("v:ang=100", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := GetGenerator("v:ang=120", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g1.Info() != "v[ang=100]" || g2.Info() != "v[ang=120]" {
+		t.Errorf("shared generator: %s / %s", g1.Info(), g2.Info())
+	}
+}
@@ -21,22 +21,106 @@
 package lib
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"sort"
 )
 
 // Geometry of 2D-bended antenna
 type Geometry struct {
-	Cmts   []string `json:"comments"` // optimization info/comments
-	Wire   Wire     `json:"wire"`     // wire parameters
-	Feedpt Feedpt   `json:"feedpt"`   // feed point parameters
-	Height float64  `json:"height"`   // height of antenna
-	Nodes  []*Node  `json:"nodes"`    // node list
+	Cmts   []string `json:"comments"`       // optimization info/comments
+	Wire   Wire     `json:"wire"`           // wire parameters
+	Feedpt Feedpt   `json:"feedpt"`         // feed point parameters
+	Height float64  `json:"height"`         // height of antenna
+	Nodes  []*Node  `json:"nodes"`          // node list
+	Loop   bool     `json:"loop,omitempty"` // closed geometry (loop)
+
+	Elements []*Element  `json:"elements,omitempty"` // parasitic elements (optional)
+	Pattern  *RadPattern `json:"pattern,omitempty"`  // radiation pattern (optional)
+}
+
+// legacyGeometry is the older geometry format of 2D antennas: 'num'
+// segments of equal length 'segL' with a bending angle for each segment.
+type legacyGeometry struct {
+	Num   int       `json:"num"`
+	SegL  float64   `json:"segL"`
+	Bends []float64 `json:"bends"`
+}
+
+// UnmarshalJSON reads a geometry; files in the older (num,segL,bends)
+// format are converted to a node list.
+func (geo *Geometry) UnmarshalJSON(data []byte) (err error) {
+	type plain Geometry
+	if err = json.Unmarshal(data, (*plain)(geo)); err != nil || len(geo.Nodes) > 0 {
+		return
+	}
+	old := new(legacyGeometry)
+	if err = json.Unmarshal(data, old); err != nil {
+		return
+	}
+	if old.Num != len(old.Bends) {
+		return fmt.Errorf("geometry: %d bends for %d segments", len(old.Bends), old.Num)
+	}
+	geo.Nodes = make([]*Node, old.Num)
+	for i, ang := range old.Bends {
+		geo.Nodes[i] = NewNode2D(old.SegL, ang)
+	}
+	return
+}
+
+// WriteCanonical writes the geometry as canonical JSON (see CanonicalJSON)
+func (geo *Geometry) WriteCanonical(w io.Writer) (err error) {
+	var data []byte
+	if data, err = CanonicalJSON(geo); err != nil {
+		return
+	}
+	_, err = w.Write(data)
+	return
+}
+
+// Kind of antenna described by the geometry
+func (geo *Geometry) Kind() string {
+	if geo.Loop {
+		return KindLoop
+	}
+	return "geo"
+}
+
+// Element is an additional, unfed (parasitic) wire element like a reflector
+// or director. The element is symmetric to its center (at 'Offset' relative
+// to the center of the driven element); the node list describes one half
+// of the element (in the XY plane) starting at the center, the other half
+// is mirrored.
+type Element struct {
+	Offset Vec3    `json:"offset"` // position of element center
+	Nodes  []*Node `json:"nodes"`  // geometry of one half of the element
+}
+
+// ReadElements reads a list of parasitic elements from a JSON file.
+func ReadElements(fName string) (list []*Element, err error) {
+	var data []byte
+	if data, err = os.ReadFile(fName); err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &list); err != nil {
+		return
+	}
+	for i, el := range list {
+		if len(el.Nodes) == 0 {
+			err = fmt.Errorf("element #%d: no nodes", i+1)
+			return
+		}
+	}
+	return
 }
 
 //----------------------------------------------------------------------
 
+// Smooth2D distributes the bending angle of each node over its
+// neighbours (range 'rng') with exponentially decreasing weights.
 func Smooth2D(nodes []*Node, rng int) (out []*Node) {
 	if rng < 1 {
 		return nodes
@@ -44,7 +128,7 @@ func Smooth2D(nodes []*Node, rng int) (out []*Node) {
 	num := len(nodes)
 	out = make([]*Node, num)
 	for i := range out {
-		out[i] = NewNode(nodes[i].Length, 0, 0)
+		out[i] = NewNode2D(nodes[i].Length, 0)
 	}
 	for i, n := range nodes {
 		ang := n.Theta
@@ -68,6 +152,28 @@ func Smooth2D(nodes []*Node, rng int) (out []*Node) {
 	return
 }
 
+// Smooth2DN applies Smooth2D iteratively ('passes' times). If 'pinEnds'
+// is set, the first node (feed end) and the last node (tip) keep their
+// bending angles; only the inner nodes are smoothed.
+func Smooth2DN(nodes []*Node, rng, passes int, pinEnds bool) []*Node {
+	num := len(nodes)
+	if rng < 1 || (pinEnds && num < 3) {
+		return nodes
+	}
+	for range passes {
+		if !pinEnds {
+			nodes = Smooth2D(nodes, rng)
+			continue
+		}
+		out := make([]*Node, 0, num)
+		out = append(out, NewNode(nodes[0].Length, nodes[0].Theta, nodes[0].Phi))
+		out = append(out, Smooth2D(nodes[1:num-1], rng)...)
+		last := nodes[num-1]
+		nodes = append(out, NewNode(last.Length, last.Theta, last.Phi))
+	}
+	return nodes
+}
+
 //----------------------------------------------------------------------
 
 type BoundingBox struct {
@@ -77,7 +183,7 @@ type BoundingBox struct {
 }
 
 func NewBoundingBox() *BoundingBox {
-	limit := math.MaxFloat32
+	limit := math.MaxFloat64
 	return &BoundingBox{
 		Xmin: limit,
 		Xmax: -limit,
@@ -88,6 +194,15 @@ func NewBoundingBox() *BoundingBox {
 	}
 }
 
+// NewBoundingBoxOf returns the bounding box of a list of points
+func NewBoundingBoxOf(pts []Vec3) *BoundingBox {
+	b := NewBoundingBox()
+	for _, p := range pts {
+		b.Include(p)
+	}
+	return b
+}
+
 func (b *BoundingBox) Include(v Vec3) {
 	b.Xmin = min(v[0], b.Xmin)
 	b.Xmax = max(v[0], b.Xmax)
@@ -97,6 +212,11 @@ func (b *BoundingBox) Include(v Vec3) {
 	b.Zmax = max(v[2], b.Zmax)
 }
 
+// Extent of the box (width, height and depth)
+func (b *BoundingBox) Extent() (w, h, d float64) {
+	return b.Xmax - b.Xmin, b.Ymax - b.Ymin, b.Zmax - b.Zmin
+}
+
 //----------------------------------------------------------------------
 
 // Node in a 3D geometry (relative vector)
@@ -115,6 +235,20 @@ func NewNode(len, theta, phi float64) (n *Node) {
 	}
 }
 
+// NewNode2D creates a new node in the XY plane (bending angle 'ang')
+func NewNode2D(len, ang float64) (n *Node) {
+	return NewNode(len, ang, 0)
+}
+
+// CloneNodes returns a deep copy of a list of nodes
+func CloneNodes(nodes []*Node) (out []*Node) {
+	out = make([]*Node, len(nodes))
+	for i, n := range nodes {
+		out[i] = NewNode(n.Length, n.Theta, n.Phi)
+	}
+	return
+}
+
 // Dir returns the direction of the node as vector
 func (n *Node) Dir() (v Vec3) {
 	v[2] = math.Sin(n.Phi)
@@ -318,7 +452,7 @@ func (li *Line) Intersect(lj *Line) (p Vec3, cross bool) {
 		(d(2, 1, 2, 1)*d(4, 3, 4, 3) - d(4, 3, 2, 1)*d(4, 3, 2, 1))
 	if t1 > 0 && t1 < 1 {
 		t2 := (d(1, 3, 4, 3) + t1*d(4, 3, 2, 1)) / d(4, 3, 4, 3)
-		if t2 > 0 && t1 < 1 {
+		if t2 > 0 && t2 < 1 {
 			p = pt[0].Add(pt[1].Sub(pt[0]).Mult(t1))
 			cross = true
 			return
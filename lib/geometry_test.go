@@ -20,7 +20,12 @@
 
 package lib
 
-import "testing"
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+)
 
 func TestSmooth(t *testing.T) {
 	g, err := GetGenerator("stroll", 2)
@@ -33,3 +38,130 @@ func TestSmooth(t *testing.T) {
 	rnd := Randomizer(19031962)
 	g.Nodes(373, 0.004, rnd)
 }
+
+func TestSmooth2DN(t *testing.T) {
+	rnd := Randomizer(19031962)
+	nodes := make([]*Node, 50)
+	sum := 0.
+	for i := range nodes {
+		nodes[i] = NewNode(0.01, rnd.Float64()-0.5, 0)
+		sum += nodes[i].Theta
+	}
+	out := Smooth2DN(nodes, 3, 2, true)
+	if len(out) != len(nodes) {
+		t.Fatal("node count mismatch")
+	}
+	if out[0].Theta != nodes[0].Theta || out[49].Theta != nodes[49].Theta {
+		t.Fatal("end nodes not preserved")
+	}
+	sumOut := 0.
+	for _, n := range out {
+		sumOut += n.Theta
+	}
+	if !IsNull(sum - sumOut) {
+		t.Fatalf("total bending changed: %f != %f", sumOut, sum)
+	}
+}
+
+func TestTrackScale(t *testing.T) {
+	tl := &TrackList{
+		SegL: 0.01,
+		Num:  3,
+		Track: []*Change{
+			{Pos: 1, Theta: 0.1},
+			{Pos: TRK_MARK},
+			{Pos: TRK_SCALE, Scale: 1.1},
+			{Pos: TRK_SCALE, Scale: 0.5},
+		},
+	}
+	nodes := tl.Nodes()
+	for _, n := range nodes {
+		if !IsNull(n.Length - 0.0055) {
+			t.Fatalf("unexpected length %f", n.Length)
+		}
+	}
+	if !IsNull(nodes[1].Theta - 0.1) {
+		t.Fatal("bend not applied")
+	}
+}
+
+func TestBoundingBoxPoint(t *testing.T) {
+	p := NewVec3(0.25, -1.5, 3e40)
+	box := NewBoundingBox()
+	box.Include(p)
+	if box.Xmin != p[0] || box.Xmax != p[0] ||
+		box.Ymin != p[1] || box.Ymax != p[1] ||
+		box.Zmin != p[2] || box.Zmax != p[2] {
+		t.Errorf("box %v does not match point %v", *box, p)
+	}
+	if box.Xmax-box.Xmin != 0 || box.Ymax-box.Ymin != 0 || box.Zmax-box.Zmin != 0 {
+		t.Error("box of single point has non-zero volume")
+	}
+}
+
+func TestNewNode2D(t *testing.T) {
+	n := NewNode2D(0.5, math.Pi/2)
+	if n.Phi != 0 || *n != *NewNode(0.5, math.Pi/2, 0) {
+		t.Errorf("unexpected node %v", *n)
+	}
+	if d := n.Dir(); !IsNull(d[0]) || !IsNull(d[1]-1) || d[2] != 0 {
+		t.Errorf("direction %v not in XY plane", d)
+	}
+}
+
+func TestGeometryRoundTrip(t *testing.T) {
+	spec := &Specification{
+		K:      0.25,
+		Wire:   Wire{Diameter: 0.002, Material: "CuL"},
+		Source: Source{Freq: 435000000},
+	}
+	mdl := new(ModelDipole)
+	if _, err := mdl.Init("", spec, nil); err != nil {
+		t.Fatal(err)
+	}
+	mdl.Nodes = make([]*Node, mdl.Num)
+	for i := range mdl.Nodes {
+		mdl.Nodes[i] = NewNode(mdl.SegL, 0.01*float64(i), -0.02*float64(i))
+	}
+	data, err := json.Marshal(mdl.Geometry([]string{"test"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo := new(Geometry)
+	if err = json.Unmarshal(data, &geo); err != nil {
+		t.Fatal(err)
+	}
+	if geo.Wire != spec.Wire || geo.Feedpt != spec.Feedpt || geo.Kind() != "geo" {
+		t.Errorf("geometry parameters differ: %+v", geo)
+	}
+	if len(geo.Nodes) != len(mdl.Nodes) {
+		t.Fatalf("%d nodes read, %d written", len(geo.Nodes), len(mdl.Nodes))
+	}
+	for i, n := range geo.Nodes {
+		if *n != *mdl.Nodes[i] {
+			t.Errorf("node %d: %v != %v", i, *n, *mdl.Nodes[i])
+		}
+	}
+}
+
+func TestGeometryLegacy(t *testing.T) {
+	body, err := os.ReadFile("../docs/examples/geometry-120-685.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo := new(Geometry)
+	if err = json.Unmarshal(body, &geo); err != nil {
+		t.Fatal(err)
+	}
+	if len(geo.Nodes) != 58 {
+		t.Fatalf("%d nodes read", len(geo.Nodes))
+	}
+	for _, n := range geo.Nodes {
+		if !IsNull(n.Length-geo.Nodes[0].Length) || n.Phi != 0 {
+			t.Fatalf("unexpected node %v", *n)
+		}
+	}
+	if err = json.Unmarshal([]byte(`{"num":2,"segL":0.1,"bends":[0]}`), new(Geometry)); err == nil {
+		t.Error("bend count mismatch not detected")
+	}
+}
@@ -21,8 +21,10 @@
 package lib
 
 import (
+	"fmt"
 	"math"
 	"math/cmplx"
+	"strings"
 )
 
 // Matcher between impedances.
@@ -83,12 +85,249 @@ func Zmatch(Zs, Zl complex128) (Z complex128, m *Matcher) {
 	return
 }
 
+// Component of a matching network (inductor or capacitor)
+type Component struct {
+	Kind  string  // "L" (inductor) or "C" (capacitor)
+	Value float64 // inductance (H) or capacitance (F)
+}
+
+// NewComponent returns the inductor (positive reactance) or capacitor
+// (negative reactance) with reactance x at given frequency.
+func NewComponent(x, freq float64) Component {
+	w := 2 * math.Pi * freq
+	if x < 0 {
+		return Component{Kind: "C", Value: -1 / (w * x)}
+	}
+	return Component{Kind: "L", Value: x / w}
+}
+
+// Reactance of the component at given frequency
+func (c Component) Reactance(freq float64) float64 {
+	w := 2 * math.Pi * freq
+	if c.Kind == "C" {
+		return -1 / (w * c.Value)
+	}
+	return w * c.Value
+}
+
+// String returns a human-readable component value
+func (c Component) String() string {
+	unit := "H"
+	if c.Kind == "C" {
+		unit = "F"
+	}
+	return fmt.Sprintf("%s=%s%s", c.Kind, FormatNumber(c.Value, 4), unit)
+}
+
+// LNetwork is a L-match with fixed component values (as built) that
+// can be evaluated at any frequency.
+type LNetwork struct {
+	AtSource bool      // placement of shunt element
+	Shunt    Component // shunt element
+	Series   Component // series element
+}
+
+// Network returns the L-match network with component values computed
+// for the given frequency. Component values of the low-pass (Cp/Ls) or
+// high-pass (Lp/Cs) variant that turn out negative are realized as the
+// complementary component with the same reactance at that frequency.
+func (m *Matcher) Network(freq float64, highPass bool) *LNetwork {
+	w := 2 * math.Pi * freq
+	var xp, xr float64
+	if highPass {
+		Lp, Cs := m.HighPass(freq)
+		xp, xr = w*Lp, -1/(w*Cs)
+	} else {
+		Cp, Ls := m.LowPass(freq)
+		xp, xr = -1/(w*Cp), w*Ls
+	}
+	return &LNetwork{
+		AtSource: m.AtSource,
+		Shunt:    NewComponent(xp, freq),
+		Series:   NewComponent(xr, freq),
+	}
+}
+
+// Input impedance of the network terminated with load Zl at given frequency
+func (n *LNetwork) Input(freq float64, Zl complex128) complex128 {
+	Zp := complex(0, n.Shunt.Reactance(freq))
+	Zr := complex(0, n.Series.Reactance(freq))
+	if n.AtSource {
+		Z := Zl + Zr
+		return (Z * Zp) / (Z + Zp)
+	}
+	return (Zl*Zp)/(Zl+Zp) + Zr
+}
+
+// MatchNet is a three-element (Pi or T) matching network between a source
+// and a load impedance with a given loaded Q. Elements are listed from
+// source to load: shunt-series-shunt (Pi) or series-shunt-series (T).
+// Reactive parts of source and load are absorbed by the outer elements.
+type MatchNet struct {
+	Topology string     // "Pi" or "T"
+	Q        float64    // loaded Q of the network
+	lp, hp   [3]float64 // element reactances (low-pass and high-pass)
+}
+
+// LowPass element values at given frequency (shunt C, series L unless
+// absorbing source/load reactance requires the complementary element)
+func (m *MatchNet) LowPass(freq float64) []Component {
+	return m.components(m.lp, freq)
+}
+
+// HighPass element values at given frequency (shunt L, series C unless
+// absorbing source/load reactance requires the complementary element)
+func (m *MatchNet) HighPass(freq float64) []Component {
+	return m.components(m.hp, freq)
+}
+
+// Reactances of the network elements (low-pass or high-pass variant)
+func (m *MatchNet) Reactances(highPass bool) []float64 {
+	if highPass {
+		return m.hp[:]
+	}
+	return m.lp[:]
+}
+
+// convert reactances to components
+func (m *MatchNet) components(x [3]float64, freq float64) (list []Component) {
+	for _, xe := range x {
+		list = append(list, NewComponent(xe, freq))
+	}
+	return
+}
+
+// Input impedance of the network (with given components) terminated with
+// load Zl at given frequency.
+func (m *MatchNet) Input(comps []Component, freq float64, Zl complex128) complex128 {
+	x := func(i int) complex128 {
+		return complex(0, comps[i].Reactance(freq))
+	}
+	par := func(a, b complex128) complex128 {
+		return (a * b) / (a + b)
+	}
+	if m.Topology == "T" {
+		return x(0) + par(x(1), x(2)+Zl)
+	}
+	return par(x(0), x(1)+par(x(2), Zl))
+}
+
+// ZmatchPi computes a Pi network (shunt-series-shunt) matching the load
+// impedance Zl to the source impedance Zs with loaded Q. The virtual
+// resistance in the middle of the network is R = max(Rs,Rl)/(Q²+1) (with
+// parallel-equivalent resistances Rs and Rl); Q must be large enough for
+// R to be smaller than both.
+func ZmatchPi(Zs, Zl complex128, Q float64) (m *MatchNet, err error) {
+	Ys, Yl := 1/Zs, 1/Zl
+	Rs, Rl := 1/real(Ys), 1/real(Yl)
+	Rv := max(Rs, Rl) / (Q*Q + 1)
+	if !(Rv < min(Rs, Rl)) || real(Ys) <= 0 || real(Yl) <= 0 {
+		err = fmt.Errorf("Pi network: Q=%g too small (min. %.3f)", Q, math.Sqrt(max(Rs, Rl)/min(Rs, Rl)-1))
+		return
+	}
+	Q1, Q2 := math.Sqrt(Rs/Rv-1), math.Sqrt(Rl/Rv-1)
+	m = &MatchNet{Topology: "Pi", Q: Q}
+	for i, sgn := range []float64{1, -1} {
+		// shunt susceptances (capacitive for low-pass) and series reactance
+		B1 := sgn*Q1/Rs + imag(Ys)
+		X2 := sgn * Rv * (Q1 + Q2)
+		B3 := sgn*Q2/Rl - imag(Yl)
+		x := [3]float64{-1 / B1, X2, -1 / B3}
+		if i == 0 {
+			m.lp = x
+		} else {
+			m.hp = x
+		}
+	}
+	return
+}
+
+// ZmatchT computes a T network (series-shunt-series) matching the load
+// impedance Zl to the source impedance Zs with loaded Q. The virtual
+// resistance in the middle of the network is R = min(Rs,Rl)·(Q²+1); Q must
+// be large enough for R to be larger than both Rs and Rl.
+func ZmatchT(Zs, Zl complex128, Q float64) (m *MatchNet, err error) {
+	Rs, Rl := real(Zs), real(Zl)
+	Rv := min(Rs, Rl) * (Q*Q + 1)
+	if !(Rv > max(Rs, Rl)) || Rs <= 0 || Rl <= 0 {
+		err = fmt.Errorf("T network: Q=%g too small (min. %.3f)", Q, math.Sqrt(max(Rs, Rl)/min(Rs, Rl)-1))
+		return
+	}
+	Q1, Q2 := math.Sqrt(Rv/Rs-1), math.Sqrt(Rv/Rl-1)
+	m = &MatchNet{Topology: "T", Q: Q}
+	for i, sgn := range []float64{1, -1} {
+		// series reactances (inductive for low-pass) and shunt susceptance
+		X1 := sgn*Q1*Rs + imag(Zs)
+		B2 := sgn * (Q1 + Q2) / Rv
+		X3 := sgn*Q2*Rl - imag(Zl)
+		x := [3]float64{X1, -1 / B2, X3}
+		if i == 0 {
+			m.lp = x
+		} else {
+			m.hp = x
+		}
+	}
+	return
+}
+
+// MatchInfo returns a human-readable description of the Pi and T matching
+// networks (low-pass and high-pass) for given impedances, frequency and Q.
+func MatchInfo(Zs, Zl complex128, freq, Q float64) (lines []string) {
+	for _, fn := range []func(Zs, Zl complex128, Q float64) (*MatchNet, error){ZmatchPi, ZmatchT} {
+		m, err := fn(Zs, Zl, Q)
+		if err != nil {
+			lines = append(lines, err.Error())
+			continue
+		}
+		for _, v := range []struct {
+			name  string
+			comps []Component
+		}{{"LP", m.LowPass(freq)}, {"HP", m.HighPass(freq)}} {
+			list := make([]string, len(v.comps))
+			for i, c := range v.comps {
+				list[i] = c.String()
+			}
+			lines = append(lines, fmt.Sprintf("%s network (Q=%g, %s): %s", m.Topology, Q, v.name, strings.Join(list, ", ")))
+		}
+	}
+	return
+}
+
+// AntennaQ estimates the Q of an antenna from its impedance z at frequency
+// f and the impedances zLo and zHi at f-δ and f+δ (Yaghjian/Best):
+//
+//	Q ≈ sqrt((f·R')² + (f·X' + |X|)²) / 2R
+//
+// with derivatives R', X' (with respect to frequency) approximated by
+// central differences. The implied relative 2:1 SWR bandwidth of the
+// (matched) antenna is 1/(Q·√2).
+func AntennaQ(zLo, z, zHi complex128, freq, delta float64) (q, bw float64) {
+	dR := (real(zHi) - real(zLo)) / (2 * delta)
+	dX := (imag(zHi) - imag(zLo)) / (2 * delta)
+	q = math.Hypot(freq*dR, freq*dX+math.Abs(imag(z))) / (2 * real(z))
+	bw = 1 / (q * math.Sqrt2)
+	return
+}
+
+// SWR of impedance z at source impedance zs
+func SWR(z, zs complex128) float64 {
+	g := cmplx.Abs(ToReflection(z, zs))
+	return (1 + g) / (1 - g)
+}
+
 // ToReflection computes the complex reflection factor between Z and Z0.
 // The value is within a unit circle in the complex plane (Smith chart).
 func ToReflection(z, z0 complex128) complex128 {
 	return (z - z0) / (z + z0)
 }
 
+// PowerReflection computes the power wave reflection factor between a
+// load Z and a (complex) source impedance Zs; it is zero for a conjugate
+// match (Z = Zs*).
+func PowerReflection(z, zs complex128) complex128 {
+	return (z - cmplx.Conj(zs)) / (z + zs)
+}
+
 // FromReflection computes the impedance Z if a reference impedance Z0 and
 // a complex reflection (Smith chart coordinate) are given.
 func FromReflection(g, z0 complex128) complex128 {
@@ -21,6 +21,8 @@
 package lib
 
 import (
+	"math"
+	"math/cmplx"
 	"testing"
 )
 
@@ -39,3 +41,67 @@ func TestMatch(t *testing.T) {
 	Cs, Lp := matcher.HighPass(f)
 	t.Logf("Cs=%sF, Lp=%sH\n", FormatNumber(Cs, 4), FormatNumber(Lp, 4))
 }
+
+func TestMatchNetwork(t *testing.T) {
+	Zs := complex(50, 0)
+	f := 145000000.
+	for _, Zl := range []complex128{complex(5, 0), complex(200, 0)} {
+		_, m := Zmatch(Zs, Zl)
+		for _, hp := range []bool{false, true} {
+			n := m.Network(f, hp)
+			if s := SWR(n.Input(f, Zl), Zs); math.Abs(s-1) > 1e-6 {
+				t.Errorf("Zl=%v, hp=%v: SWR=%f at design frequency", Zl, hp, s)
+			}
+			if s := SWR(n.Input(1.1*f, Zl), Zs); s < 1.01 {
+				t.Errorf("Zl=%v, hp=%v: SWR=%f off design frequency", Zl, hp, s)
+			}
+		}
+	}
+}
+
+func TestMatchPiT(t *testing.T) {
+	f := 7100000.
+	for _, c := range [][2]complex128{
+		{complex(50, 0), complex(5, 0)},
+		{complex(50, 0), complex(12, -40)},
+		{complex(50, 10), complex(300, 80)},
+	} {
+		Zs, Zl := c[0], c[1]
+		for _, fn := range []func(Zs, Zl complex128, Q float64) (*MatchNet, error){ZmatchPi, ZmatchT} {
+			m, err := fn(Zs, Zl, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, comps := range [][]Component{m.LowPass(f), m.HighPass(f)} {
+				if Z := m.Input(comps, f, Zl); cmplx.Abs(Z-Zs) > 1e-6 {
+					t.Errorf("%s: Zl=%v: Zin=%v (comps %v)", m.Topology, Zl, Z, comps)
+				}
+			}
+		}
+	}
+	if _, err := ZmatchPi(complex(50, 0), complex(5, 0), 1); err == nil {
+		t.Error("Pi: Q too small not detected")
+	}
+	if _, err := ZmatchT(complex(50, 0), complex(5, 0), 1); err == nil {
+		t.Error("T: Q too small not detected")
+	}
+}
+
+func TestAntennaQ(t *testing.T) {
+	// series RLC circuit: Q = ωL/R
+	R, L, C := 50., 10e-6, 1e-12
+	f0 := 1 / (2 * math.Pi * math.Sqrt(L*C))
+	Z := func(f float64) complex128 {
+		w := 2 * math.Pi * f
+		return complex(R, w*L-1/(w*C))
+	}
+	delta := f0 * 1e-4
+	q, bw := AntennaQ(Z(f0-delta), Z(f0), Z(f0+delta), f0, delta)
+	want := 2 * math.Pi * f0 * L / R
+	if math.Abs(q-want)/want > 1e-3 {
+		t.Errorf("Q=%f, expected %f", q, want)
+	}
+	if math.Abs(bw*q*math.Sqrt2-1) > 1e-9 {
+		t.Errorf("BW=%f inconsistent with Q=%f", bw, q)
+	}
+}
@@ -0,0 +1,141 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+)
+
+//----------------------------------------------------------------------
+
+// KeepOut is a region in the XY plane (obstacle) that must not be
+// entered by the antenna wire. Regions are either rectangles (lower-left
+// corner at (X,Y), size W×H) or circles (center at (X,Y), radius R).
+type KeepOut struct {
+	Kind string  `json:"kind"` // "rect" or "circle"
+	X    float64 `json:"x"`    // corner or center (x-coordinate)
+	Y    float64 `json:"y"`    // corner or center (y-coordinate)
+	W    float64 `json:"w"`    // width (rect)
+	H    float64 `json:"h"`    // height (rect)
+	R    float64 `json:"r"`    // radius (circle)
+}
+
+// KeepOutAware is implemented by types that handle keep-out regions
+type KeepOutAware interface {
+	SetKeepOuts(list []*KeepOut)
+}
+
+// ReadKeepOuts reads a list of keep-out regions from a JSON file
+func ReadKeepOuts(fName string) (list []*KeepOut, err error) {
+	var data []byte
+	if data, err = os.ReadFile(fName); err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &list); err != nil {
+		return
+	}
+	for i, k := range list {
+		switch k.Kind {
+		case "rect":
+			if k.W <= 0 || k.H <= 0 {
+				err = fmt.Errorf("keep-out #%d: invalid rectangle size", i+1)
+			}
+		case "circle":
+			if k.R <= 0 {
+				err = fmt.Errorf("keep-out #%d: invalid circle radius", i+1)
+			}
+		default:
+			err = fmt.Errorf("keep-out #%d: unknown kind '%s'", i+1, k.Kind)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Hits returns true if a line (projected onto the XY plane) enters
+// the keep-out region.
+func (k *KeepOut) Hits(l *Line) bool {
+	switch k.Kind {
+	case "rect":
+		return k.clip(l.start, l.end)
+	case "circle":
+		return distance2D(l.start, l.end, NewVec3(k.X, k.Y, 0)) < k.R
+	}
+	return false
+}
+
+// clip a line against the rectangle (Liang-Barsky); returns true if
+// a part of the line lies inside the rectangle.
+func (k *KeepOut) clip(s, e Vec3) bool {
+	dx, dy := e[0]-s[0], e[1]-s[1]
+	p := []float64{-dx, dx, -dy, dy}
+	q := []float64{s[0] - k.X, k.X + k.W - s[0], s[1] - k.Y, k.Y + k.H - s[1]}
+	t0, t1 := 0., 1.
+	for i := range 4 {
+		if IsNull(p[i]) {
+			if q[i] < 0 {
+				return false
+			}
+			continue
+		}
+		t := q[i] / p[i]
+		if p[i] < 0 {
+			t0 = max(t0, t)
+		} else {
+			t1 = min(t1, t)
+		}
+		if t0 > t1 {
+			return false
+		}
+	}
+	return true
+}
+
+// distance2D between a line from s to e and a point p in the XY plane
+func distance2D(s, e, p Vec3) float64 {
+	dx, dy := e[0]-s[0], e[1]-s[1]
+	t := 0.
+	if l2 := dx*dx + dy*dy; !IsNull(l2) {
+		t = ((p[0]-s[0])*dx + (p[1]-s[1])*dy) / l2
+		t = max(0, min(1, t))
+	}
+	return math.Hypot(s[0]+t*dx-p[0], s[1]+t*dy-p[1])
+}
+
+// Render keep-out region on canvas
+func (k *KeepOut) Render(c Canvas, w float64, clr *color.RGBA) {
+	switch k.Kind {
+	case "rect":
+		x1, y1, x2, y2 := k.X, k.Y, k.X+k.W, k.Y+k.H
+		c.Line(x1, y1, x2, y1, w, clr)
+		c.Line(x2, y1, x2, y2, w, clr)
+		c.Line(x2, y2, x1, y2, w, clr)
+		c.Line(x1, y2, x1, y1, w, clr)
+	case "circle":
+		c.Circle(k.X, k.Y, k.R, w, clr, nil)
+	}
+}
@@ -0,0 +1,47 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import "testing"
+
+func TestKeepOut(t *testing.T) {
+	rect := &KeepOut{Kind: "rect", X: 0.1, Y: 0.1, W: 0.1, H: 0.1}
+	circ := &KeepOut{Kind: "circle", X: 0.3, Y: 0, R: 0.05}
+	for i, tc := range []struct {
+		l    *Line
+		rect bool
+		circ bool
+	}{
+		{NewLine(NewVec3(0, 0, 0), NewVec3(0.4, 0, 0)), false, true},
+		{NewLine(NewVec3(0, 0.15, 0), NewVec3(0.4, 0.15, 0)), true, false},
+		{NewLine(NewVec3(0.12, 0.12, 0), NewVec3(0.13, 0.13, 0)), true, false},
+		{NewLine(NewVec3(0, 0.3, 0), NewVec3(0.3, 0.04, 0)), true, true},
+		{NewLine(NewVec3(0.25, 0.2, 0), NewVec3(0.3, 0.02, 0)), false, true},
+		{NewLine(NewVec3(0, 0.3, 0), NewVec3(0.3, 0.3, 0)), false, false},
+	} {
+		if rect.Hits(tc.l) != tc.rect {
+			t.Errorf("#%d: rect hit mismatch", i)
+		}
+		if circ.Hits(tc.l) != tc.circ {
+			t.Errorf("#%d: circle hit mismatch", i)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token types
+const (
+	tokEnd   = iota // end of source
+	tokNum          // number
+	tokIdent        // identifier or keyword
+	tokOp           // operator
+	tokText         // quoted text
+)
+
+// token of an expression
+type token struct {
+	kind int    // token type
+	val  string // token value
+}
+
+// lexer splits the source of an expression into tokens. Numbers and
+// identifiers are common to all grammars; the valid operators and the
+// use of quoted text (” is an escaped quote) depend on the grammar.
+type lexer struct {
+	ops  []string // valid operators (longer operators first)
+	text bool     // quoted text allowed
+}
+
+// lexers for arithmetic expressions and filter expressions
+var (
+	exprLexer = &lexer{
+		ops: []string{"+", "-", "*", "/", "^", "(", ")"},
+	}
+	whereLexer = &lexer{
+		ops: []string{
+			"<=", ">=", "!=", "<>",
+			"+", "-", "*", "/", "(", ")", "<", ">", "=",
+		},
+		text: true,
+	}
+)
+
+// scan splits the source into tokens; the list always ends with a
+// token of type tokEnd.
+func (l *lexer) scan(s string) (toks []token, err error) {
+	src := []rune(s)
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case unicode.IsDigit(c) || c == '.':
+			for i < len(src) && (unicode.IsDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && unicode.IsDigit(src[i]) {
+					i++
+				}
+			}
+			num := string(src[start:i])
+			if _, err = strconv.ParseFloat(num, 64); err != nil {
+				err = fmt.Errorf("invalid number '%s'", num)
+				return
+			}
+			toks = append(toks, token{tokNum, num})
+		case unicode.IsLetter(c) || c == '_':
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '_') {
+				i++
+			}
+			toks = append(toks, token{tokIdent, string(src[start:i])})
+		case c == '\'' && l.text:
+			// quoted text ('' is an escaped quote)
+			var txt strings.Builder
+			for i++; ; i++ {
+				if i == len(src) {
+					err = fmt.Errorf("unterminated text")
+					return
+				}
+				if src[i] == '\'' {
+					if i+1 < len(src) && src[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+				txt.WriteRune(src[i])
+			}
+			i++
+			toks = append(toks, token{tokText, txt.String()})
+		default:
+			op := ""
+			for _, o := range l.ops {
+				if strings.HasPrefix(string(src[i:]), o) {
+					op = o
+					break
+				}
+			}
+			if len(op) == 0 {
+				err = fmt.Errorf("invalid character '%c'", c)
+				return
+			}
+			i += len(op)
+			toks = append(toks, token{tokOp, op})
+		}
+	}
+	toks = append(toks, token{tokEnd, ""})
+	return
+}
@@ -0,0 +1,56 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	toks, err := whereLexer.scan("abs(Zi)<=1.5e1 and\tmdl <> 'it''s'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []token{
+		{tokIdent, "abs"}, {tokOp, "("}, {tokIdent, "Zi"}, {tokOp, ")"},
+		{tokOp, "<="}, {tokNum, "1.5e1"}, {tokIdent, "and"}, {tokIdent, "mdl"},
+		{tokOp, "<>"}, {tokText, "it's"}, {tokEnd, ""},
+	}
+	if !slices.Equal(toks, want) {
+		t.Errorf("got %v, want %v", toks, want)
+	}
+	// operators and text depend on the grammar
+	for _, tc := range []struct {
+		l  *lexer
+		in string
+	}{
+		{exprLexer, "Gmax < 3"},
+		{exprLexer, "'x'"},
+		{whereLexer, "Gmax^2"},
+		{whereLexer, "mdl = 'x"},
+		{whereLexer, "1.2.3"},
+	} {
+		if toks, err = tc.l.scan(tc.in); err == nil {
+			t.Errorf("%s: accepted as %v", tc.in, toks)
+		}
+	}
+}
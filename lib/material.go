@@ -23,6 +23,7 @@ package lib
 import (
 	"fmt"
 	"log"
+	"math"
 )
 
 // MaterialProperties returns material properties for label
@@ -49,3 +50,25 @@ func GetWire(mat string, dia float64) Wire {
 		Inductance:   L,
 	}
 }
+
+// permeability of free space (H/m)
+const mu0 = 4e-7 * math.Pi
+
+// Resistance returns the RF resistance of a wire per unit length (Ω/m)
+// at a given frequency (skin effect): the current flows in a layer of
+// skin depth δ = 1/√(π·f·μ0·σ) below the surface. If the skin depth
+// exceeds the radius (or the frequency is zero), the DC resistance is
+// returned.
+func (w Wire) Resistance(freq int64) float64 {
+	if IsNull(w.Conductivity) {
+		return 0
+	}
+	r := w.Diameter / 2
+	area := math.Pi * r * r
+	if freq > 0 {
+		if delta := 1 / math.Sqrt(math.Pi*float64(freq)*mu0*w.Conductivity); delta < r {
+			area -= math.Pi * (r - delta) * (r - delta)
+		}
+	}
+	return 1 / (w.Conductivity * area)
+}
@@ -20,7 +20,10 @@
 
 package lib
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestMaterialProps(t *testing.T) {
 	for mat := range Cfg.Mat {
@@ -33,3 +36,18 @@ func TestMaterialProps(t *testing.T) {
 		t.Logf("  Inductance  = %e H/m", i)
 	}
 }
+
+func TestSkinEffect(t *testing.T) {
+	w := Wire{Diameter: 0.002, Conductivity: 5.96e7}
+	// DC resistance
+	dc := 1 / (w.Conductivity * math.Pi * 1e-6)
+	if r := w.Resistance(0); math.Abs(r-dc)/dc > 1e-9 {
+		t.Errorf("DC: got %e, expected %e", r, dc)
+	}
+	// RF resistance: surface resistance over circumference
+	f := 435e6
+	rf := math.Sqrt(math.Pi*f*mu0/w.Conductivity) / (math.Pi * w.Diameter)
+	if r := w.Resistance(int64(f)); math.Abs(r-rf)/rf > 0.01 {
+		t.Errorf("RF: got %e, expected %e", r, rf)
+	}
+}
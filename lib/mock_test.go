@@ -0,0 +1,99 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegrals(t *testing.T) {
+	for _, c := range []struct{ x, si, ci float64 }{
+		{0.5, 0.493107418, -0.177784079},
+		{1, 0.946083070, 0.337403923},
+		{10, 1.658347594, -0.045456433},
+	} {
+		if si := sinIntegral(c.x); math.Abs(si-c.si) > 1e-6 {
+			t.Errorf("Si(%g) = %.9f, want %.9f", c.x, si, c.si)
+		}
+		if ci := cosIntegral(c.x); math.Abs(ci-c.ci) > 1e-6 {
+			t.Errorf("Ci(%g) = %.9f, want %.9f", c.x, ci, c.ci)
+		}
+	}
+}
+
+// useIdealDipole switches to the analytic evaluator for the duration
+// of a test (no NEC2 engine needed).
+func useIdealDipole(t testing.TB) {
+	fn := DefaultEval
+	DefaultEval = EvalIdealDipole
+	t.Cleanup(func() { DefaultEval = fn })
+}
+
+// testSpec returns the specification used by evaluation tests: enamelled
+// copper wire in free space at 435MHz.
+func testSpec() *Specification {
+	return &Specification{
+		Wire:   GetWire("CuL", 0.002),
+		Ground: Ground{Type: -1},
+		Source: Source{Freq: 435000000},
+	}
+}
+
+func TestIdealDipole(t *testing.T) {
+	useIdealDipole(t)
+
+	spec := &Specification{
+		Wire:   Wire{Diameter: 0.0001},
+		Source: Source{Freq: 435000000},
+		Feedpt: Feedpt{Gap: 0.01},
+	}
+	// straight half-wave dipole
+	leg := (spec.Source.Lambda()/2 - spec.Feedpt.Gap) / 2
+	nodes := []*Node{NewNode2D(leg/2, 0), NewNode2D(leg/2, 0)}
+	ant := BuildAntenna("test", spec, nodes)
+	if err := ant.Eval(spec.Source.Freq, spec.Wire, spec.Ground); err != nil {
+		t.Fatal(err)
+	}
+	if g := ant.Perf.Gain.Max; math.Abs(g-2.15) > 0.01 {
+		t.Errorf("Gmax = %f dBi", g)
+	}
+	if z := ant.Perf.Z; math.Abs(real(z)-73.1) > 0.5 || math.Abs(imag(z)-42.5) > 0.5 {
+		t.Errorf("Z = %s", FormatImpedance(z, 2))
+	}
+	if rp := ant.Perf.Rp; rp == nil || math.Abs(rp.Max-ant.Perf.Gain.Max) > 0.01 {
+		t.Error("radiation pattern missing or inconsistent")
+	}
+
+	// bent dipole: lower gain
+	nodes[1] = NewNode2D(leg/2, 1)
+	bent := BuildAntenna("test", spec, nodes)
+	if err := bent.Eval(spec.Source.Freq, spec.Wire, spec.Ground); err != nil {
+		t.Fatal(err)
+	}
+	cmp, err := NewComparator("Gmax", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sign, _ := cmp.Compare(ant.Perf, bent.Perf); sign != 1 {
+		t.Errorf("bent dipole not worse: %s / %s", ant.Perf, bent.Perf)
+	}
+}
@@ -21,12 +21,17 @@
 package lib
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 )
 
+// ErrNoImprovement is returned by Model.Optimize (together with the
+// unchanged geometry) if no optimization step was accepted.
+var ErrNoImprovement = errors.New("no improvement")
+
 // Callback when optimization improves
 type Callback func(ant *Antenna, pos int, msg string)
 
@@ -35,18 +40,24 @@ type Model interface {
 	// Init model with antenna parameters and generator.
 	Init(params string, spec *Specification, gen Generator) (side float64, err error)
 
-	// Prepare initial geometry
+	// Prepare initial geometry (seed for generator randomization)
 	Prepare(seed int64, cb Callback) (ant *Antenna, err error)
 
 	// Optimize antenna geometry based on random seed and comparator
-	// (to evaluate progress during optimization)
-	Optimize(seed int64, iter int, cmp *Comparator, cb Callback) (ant *Antenna, stats Stats, err error)
+	// (to evaluate progress during optimization). If the seed is the same
+	// as for Prepare, the optimizer continues with the same randomizer.
+	// If the context is canceled, the optimization stops and the best
+	// geometry so far is returned with the partial statistics and the
+	// context error. If no optimization step was accepted, the initial
+	// geometry is returned with ErrNoImprovement.
+	Optimize(ctx context.Context, seed int64, iter int, cmp *Comparator, cb Callback) (ant *Antenna, stats Stats, err error)
 
 	// Info about the model (parameters)
 	Info() string
 
 	// Finalize model after optimization (write track and geometry files).
-	Finalize(tag, outDir, outPrf string, cmts []string)
+	// The radiation pattern (if not nil) is stored in the geometry file.
+	Finalize(tag, outDir, outPrf string, cmts []string, rp *RadPattern)
 }
 
 //----------------------------------------------------------------------
@@ -62,6 +73,10 @@ type ModelDipole struct {
 	SegL  float64 // segment length
 
 	Track []*Change // list of changes
+
+	KeepOuts []*KeepOut // regions the wire must avoid
+
+	warnings []string // warnings about model setup
 }
 
 // Init base model
@@ -72,6 +87,9 @@ func (mdl *ModelDipole) Init(params string, spec *Specification, gen Generator)
 	// NEC2: wire << lambda / 2π
 	lambda := spec.Source.Lambda()
 	if a := Cfg.Sim.WireMax * lambda; spec.Wire.Diameter > a {
+		mdl.warnings = append(mdl.warnings, fmt.Sprintf(
+			"wire diameter clamped from %.3fmm to %.3fmm (wireMax=%gλ)",
+			1000*spec.Wire.Diameter, 1000*a, Cfg.Sim.WireMax))
 		spec.Wire.Diameter = a
 	}
 	// compute segment length with lower bound
@@ -81,25 +99,122 @@ func (mdl *ModelDipole) Init(params string, spec *Specification, gen Generator)
 	// init model parameters
 	span := 2*spec.K*lambda - spec.Feedpt.Gap
 	num := int(span / dx)
+	// limit number of segments (longer segments, reduced accuracy)
+	maxSegs := Cfg.Sim.MaxSegs
+	if maxSegs > 0 && num > maxSegs {
+		mdl.warnings = append(mdl.warnings, fmt.Sprintf(
+			"%d segments exceed limit of %d - segment length increased (reduced accuracy)",
+			num, maxSegs))
+		num = maxSegs
+	}
+	// odd number of segments if the feed point gap is a segment
+	parity := 0
 	if IsNull(spec.Feedpt.Gap) {
-		if num%2 == 0 {
+		parity = 1
+	}
+	if num%2 != parity {
+		if maxSegs > 0 && num == maxSegs {
+			num--
+		} else {
 			num++
 		}
+	}
+	if IsNull(spec.Feedpt.Gap) {
 		spec.Feedpt.Gap = span / float64(num)
-	} else {
-		if num%2 == 1 {
-			num++
-		}
 	}
 	mdl.SegL = span / float64(num)
 	mdl.Num = num / 2
 	side = float64(mdl.Num) * mdl.SegL
 	mdl.Kind = fmt.Sprintf("%.3f λ dipole", 2*spec.K)
+	if spec.Loop = IsClosed(gen); spec.Loop {
+		mdl.Kind = fmt.Sprintf("%.3f λ %s", 2*spec.K, KindLoop)
+	}
+	return
+}
+
+// Warner is implemented by models that report warnings about their setup
+// (e.g. adjusted parameters).
+type Warner interface {
+	Warnings() []string
+}
+
+// Warnings about the model setup
+func (mdl *ModelDipole) Warnings() []string {
+	return mdl.warnings
+}
+
+// Size of an optimization problem
+type Size struct {
+	Num  int     // number of segments (per leg)
+	SegL float64 // segment length
+	DOF  int     // degrees of freedom (changeable parameters)
+}
+
+// Sized is implemented by models that can report their problem size.
+type Sized interface {
+	Size() Size
+}
+
+// Size returns the problem size of the model (one bend angle per segment)
+func (mdl *ModelDipole) Size() Size {
+	return Size{Num: mdl.Num, SegL: mdl.SegL, DOF: mdl.Num}
+}
+
+// Shaped is implemented by models that can report their current geometry.
+type Shaped interface {
+	Geometry(cmts []string, rp *RadPattern) *Geometry
+}
+
+// Tracker is implemented by models that record the changes of the geometry.
+type Tracker interface {
+	LastChange() *Change
+}
+
+// LastChange returns the most recent change of the geometry (or nil)
+func (mdl *ModelDipole) LastChange() *Change {
+	if n := len(mdl.Track); n > 0 {
+		return mdl.Track[n-1]
+	}
+	return nil
+}
+
+// SetKeepOuts sets the list of regions the wire must avoid
+func (mdl *ModelDipole) SetKeepOuts(list []*KeepOut) {
+	mdl.KeepOuts = list
+}
+
+// Blocked returns true if a segment of a leg (or its mirror image on
+// the other leg) enters a keep-out region.
+func (mdl *ModelDipole) Blocked(seg *Line) bool {
+	mirror := NewLine(seg.start.MirrorX(), seg.end.MirrorX())
+	for _, k := range mdl.KeepOuts {
+		if k.Hits(seg) || k.Hits(mirror) {
+			return true
+		}
+	}
+	return false
+}
+
+// Geometry of the current model state (as written to geometry files)
+func (mdl *ModelDipole) Geometry(cmts []string, rp *RadPattern) (geo *Geometry) {
+	geo = new(Geometry)
+	geo.Cmts = cmts
+	geo.Wire = mdl.Spec.Wire
+	geo.Feedpt = mdl.Spec.Feedpt
+	geo.Height = mdl.Spec.Ground.Height
+	geo.Nodes = mdl.Nodes
+	geo.Loop = mdl.Spec.Loop
+	geo.Elements = mdl.Spec.Elements
+	geo.Pattern = rp
 	return
 }
 
 // Finalize model (write track and geometry files)
-func (mdl *ModelDipole) Finalize(tag, outDir, outPrf string, cmts []string) {
+func (mdl *ModelDipole) Finalize(tag, outDir, outPrf string, cmts []string, rp *RadPattern) {
+	ext, err := FileExt(Cfg.Sim.FileFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
 	if len(mdl.Track) > 0 {
 		// write track file
 		o := new(TrackList)
@@ -108,30 +223,29 @@ func (mdl *ModelDipole) Finalize(tag, outDir, outPrf string, cmts []string) {
 		o.Track = mdl.Track
 		o.Wire = mdl.Spec.Wire
 		o.Height = mdl.Spec.Ground.Height
+		o.Loop = mdl.Spec.Loop
 		o.Cmts = cmts
 
-		data, err := json.MarshalIndent(o, "", "    ")
-		if err != nil {
+		fName := fmt.Sprintf("%s/%strack-%s%s", outDir, outPrf, tag, ext)
+		if err = EncodeFile(fName, o); err != nil {
 			log.Fatal(err)
 		}
-		fName := fmt.Sprintf("%s/%strack-%s.json", outDir, outPrf, tag)
-		if err = os.WriteFile(fName, data, 0644); err != nil {
+	}
+	// write current geometry file (plain JSON in canonical form)
+	fName := fmt.Sprintf("%s/%sgeometry-%s%s", outDir, outPrf, tag, ext)
+	geo := mdl.Geometry(cmts, rp)
+	if ext == ".json" {
+		var f *os.File
+		if f, err = os.Create(fName); err != nil {
 			log.Fatal(err)
 		}
+		if err = geo.WriteCanonical(f); err == nil {
+			err = f.Close()
+		}
+	} else {
+		err = EncodeFile(fName, geo)
 	}
-	// write current geometry file
-	geo := new(Geometry)
-	geo.Cmts = cmts
-	geo.Wire = mdl.Spec.Wire
-	geo.Feedpt = mdl.Spec.Feedpt
-	geo.Height = mdl.Spec.Ground.Height
-	geo.Nodes = mdl.Nodes
-	data, err := json.MarshalIndent(geo, "", "    ")
 	if err != nil {
 		log.Fatal(err)
 	}
-	fName := fmt.Sprintf("%s/%sgeometry-%s.json", outDir, outPrf, tag)
-	if err = os.WriteFile(fName, data, 0644); err != nil {
-		log.Fatal(err)
-	}
 }
@@ -22,6 +22,7 @@ package lib
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -32,22 +33,66 @@ import (
 	"time"
 )
 
+// RunInfo records the environment of an optimization run (for
+// reproducing results)
+type RunInfo struct {
+	Cmdline string `json:"cmdline"` // command line
+	Config  string `json:"config"`  // effective simulation configuration (JSON)
+	Library string `json:"library"` // NEC2 library (module@version) or program
+}
+
+// NECModule is the Go module of the NEC2 library binding
+const NECModule = "github.com/ctdk/go-libnecpp"
+
+// NewRunInfo captures the command line, the current simulation
+// configuration and the version of the NEC2 library binding.
+func NewRunInfo(args []string) (run *RunInfo, err error) {
+	var cfg []byte
+	if cfg, err = json.Marshal(Cfg.Sim); err != nil {
+		return
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if i == 0 {
+			// program name without path
+			arg = filepath.Base(arg)
+		}
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	run = &RunInfo{
+		Cmdline: strings.Join(quoted, " "),
+		Config:  string(cfg),
+		Library: NECModule + "@" + ModuleVersion(NECModule),
+	}
+	if e := Cfg.Sim.Engine; e != "" && e != "necpp" {
+		// external NEC2 program
+		run.Library = e
+	}
+	return
+}
+
 // GenMdlParams assembles model parameters as list of strings.
-// The output is parsable with ParseMdlParams().
+// The output is parsable with ParseMdlParams(). The performance of the
+// straight baseline dipole ('base') and the run environment ('run') are
+// optional (nil).
 func GenMdlParams(
 	param float64,
 	spec *Specification,
-	ini, perf *Performance,
+	ini, perf, base *Performance,
 	mdl, gen, opt string,
-	seed int64,
+	seed, genSeed int64,
 	tag string,
 	total Stats,
+	run *RunInfo,
 ) (cmts []string) {
 
 	// specification (source, wire, ground)
-	cmts = append(cmts, ">>>>> Source: freq:Zr:Zi")
-	cmt := fmt.Sprintf("Source: %d:%f:%f",
-		spec.Source.Freq, spec.Source.Z.R, spec.Source.Z.X,
+	cmts = append(cmts, ">>>>> Source: freq:Zr:Zi:span")
+	cmt := fmt.Sprintf("Source: %d:%f:%f:%d",
+		spec.Source.Freq, spec.Source.Z.R, spec.Source.Z.X, spec.Source.Span,
 	)
 	cmts = append(cmts, cmt)
 	cmts = append(cmts, ">>>>> Wire: dia:material:conductivity:inductance")
@@ -55,8 +100,10 @@ func GenMdlParams(
 		spec.Wire.Diameter, spec.Wire.Material, spec.Wire.Conductivity, spec.Wire.Inductance,
 	)
 	cmts = append(cmts, cmt)
-	cmts = append(cmts, ">>>>> Feedpoint: gap:extension")
-	cmt = fmt.Sprintf("Feedpoint: %.3f:%.3f", spec.Feedpt.Gap, spec.Feedpt.Extension)
+	cmts = append(cmts, ">>>>> Feedpoint: gap:extension:hatSpokes:hatLength")
+	cmt = fmt.Sprintf("Feedpoint: %.3f:%.3f:%d:%.3f",
+		spec.Feedpt.Gap, spec.Feedpt.Extension, spec.Feedpt.HatSpokes, spec.Feedpt.HatLength,
+	)
 	cmts = append(cmts, cmt)
 	cmts = append(cmts, ">>>>> Ground: height:mode:type:nradl:epse:sig")
 	cmt = fmt.Sprintf("Ground: %.3f:%d:%d:%d:%f:%f",
@@ -78,6 +125,10 @@ func GenMdlParams(
 	cmts = append(cmts, ">>>>> Mode: model:generator:seed:optimizer")
 	cmt = fmt.Sprintf("Mode: %s:%s:%d:%s", mdl, gen, seed, opt)
 	cmts = append(cmts, cmt)
+	if genSeed != seed {
+		cmts = append(cmts, ">>>>> Genseed: seed")
+		cmts = append(cmts, fmt.Sprintf("Genseed: %d", genSeed))
+	}
 
 	// initial performance
 	cmts = append(cmts, ">>>>> Init: Gmax:Gmean:SD:Zr:Zi")
@@ -95,6 +146,62 @@ func GenMdlParams(
 	)
 	cmts = append(cmts, cmt)
 
+	// straight dipole with same wire length (if computed)
+	if base != nil {
+		cmts = append(cmts, ">>>>> Baseline: Gmax:Gmean:SD:Zr:Zi:SWR")
+		cmt = fmt.Sprintf("Baseline: %f:%f:%f:%f:%f:%f",
+			base.Gain.Max, base.Gain.Mean, base.Gain.SD,
+			real(base.Z), imag(base.Z), SWR(base.Z, spec.Source.Impedance()),
+		)
+		cmts = append(cmts, cmt)
+	}
+
+	// radiation efficiency (if computed)
+	if !math.IsNaN(perf.Eff) {
+		cmts = append(cmts, ">>>>> Efficiency: eff")
+		cmts = append(cmts, fmt.Sprintf("Efficiency: %f", perf.Eff))
+	}
+
+	// isotropy of radiation pattern (if computed)
+	if !math.IsNaN(perf.Iso) {
+		cmts = append(cmts, ">>>>> Isotropy: iso")
+		cmts = append(cmts, fmt.Sprintf("Isotropy: %f", perf.Iso))
+	}
+
+	// relative SWR bandwidth (if computed)
+	if !math.IsNaN(perf.BW) {
+		cmts = append(cmts, ">>>>> Bandwidth: bw")
+		cmts = append(cmts, fmt.Sprintf("Bandwidth: %f", perf.BW))
+	}
+
+	// gain in reference direction (if computed)
+	if !math.IsNaN(perf.Ghoriz) {
+		cmts = append(cmts, ">>>>> Ghoriz: gain")
+		cmts = append(cmts, fmt.Sprintf("Ghoriz: %f", perf.Ghoriz))
+	}
+
+	// peak gains in azimuth and elevation plane (if computed)
+	if !math.IsNaN(perf.Gaz) {
+		cmts = append(cmts, ">>>>> Gaz: gain")
+		cmts = append(cmts, fmt.Sprintf("Gaz: %f", perf.Gaz))
+	}
+	if !math.IsNaN(perf.Gel) {
+		cmts = append(cmts, ">>>>> Gel: gain")
+		cmts = append(cmts, fmt.Sprintf("Gel: %f", perf.Gel))
+	}
+
+	// gain including feedline losses (if computed)
+	if !math.IsNaN(perf.Gsys) {
+		cmts = append(cmts, ">>>>> Gsys: gain")
+		cmts = append(cmts, fmt.Sprintf("Gsys: %f", perf.Gsys))
+	}
+
+	// antenna Q (if computed)
+	if !math.IsNaN(perf.Q) {
+		cmts = append(cmts, ">>>>> Q: q")
+		cmts = append(cmts, fmt.Sprintf("Q: %f", perf.Q))
+	}
+
 	// statistics
 	cmts = append(cmts, ">>>>> Stats: Mthds:Steps:Sims:Elapsed")
 	cmt = fmt.Sprintf("Stats: %d:%d:%d:%d",
@@ -102,22 +209,79 @@ func GenMdlParams(
 	)
 	cmts = append(cmts, cmt)
 
+	// run environment (values may contain colons)
+	if run != nil {
+		cmts = append(cmts, ">>>>> Cmdline: args")
+		cmts = append(cmts, "Cmdline: "+run.Cmdline)
+		cmts = append(cmts, ">>>>> Config: simulation")
+		cmts = append(cmts, "Config: "+run.Config)
+		cmts = append(cmts, ">>>>> Library: module@version")
+		cmts = append(cmts, "Library: "+run.Library)
+	}
 	return
 }
 
+// minimum number of values per parameter line
+var paramVals = map[string]int{
+	"Source":     3,
+	"Wire":       4,
+	"Feedpoint":  2,
+	"Ground":     3,
+	"Param":      3,
+	"Mode":       4,
+	"Genseed":    1,
+	"Init":       5,
+	"Result":     5,
+	"Baseline":   6,
+	"Efficiency": 1,
+	"Isotropy":   1,
+	"Bandwidth":  1,
+	"Ghoriz":     1,
+	"Gaz":        1,
+	"Gel":        1,
+	"Gsys":       1,
+	"Q":          1,
+	"Stats":      4,
+}
+
 // ParseMdlParams from model file (extract performance parameters)
 func ParseMdlParams(cmts []string) (p *Record, ok bool, err error) {
 	p = new(Record)
+	p.Perf.Eff = math.NaN()
+	p.Perf.Iso = math.NaN()
+	p.Perf.BW = math.NaN()
+	p.Perf.Ghoriz = math.NaN()
+	p.Perf.Gaz = math.NaN()
+	p.Perf.Gel = math.NaN()
+	p.Perf.Gsys = math.NaN()
+	p.Perf.Q = math.NaN()
+	p.Size = Vec3{math.NaN(), math.NaN(), math.NaN()}
+	p.WireLen = math.NaN()
 	found := 0
-	for _, line := range cmts {
+	var line string
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("invalid parameter line '%s': %w", line, err)
+		}
+	}()
+	for _, line = range cmts {
 		kind, vals := SplitParam(line)
+		if n, known := paramVals[kind]; known && len(vals) < n {
+			err = fmt.Errorf("%d values found, %d expected", len(vals), n)
+			return
+		}
 		switch kind {
 
-		// >>>>> Source: freq:Zr:Zi
+		// >>>>> Source: freq:Zr:Zi[:span]
 		case "Source":
 			if p.Freq, err = strconv.ParseInt(vals[0], 10, 64); err != nil {
 				return
 			}
+			if len(vals) > 3 {
+				if p.Span, err = strconv.ParseInt(vals[3], 10, 64); err != nil {
+					return
+				}
+			}
 			found++
 
 		// >>>>> Wire: dia:material:conductivity:inductance
@@ -134,7 +298,7 @@ func ParseMdlParams(cmts []string) (p *Record, ok bool, err error) {
 			}
 			found++
 
-		// >>>>> Feedpoint: gap:extension
+		// >>>>> Feedpoint: gap:extension[:hatSpokes:hatLength]
 		case "Feedpoint":
 			if p.Feedpt.Gap, err = strconv.ParseFloat(vals[0], 64); err != nil {
 				return
@@ -142,9 +306,17 @@ func ParseMdlParams(cmts []string) (p *Record, ok bool, err error) {
 			if p.Feedpt.Extension, err = strconv.ParseFloat(vals[1], 64); err != nil {
 				return
 			}
+			if len(vals) > 3 {
+				if p.Feedpt.HatSpokes, err = strconv.Atoi(vals[2]); err != nil {
+					return
+				}
+				if p.Feedpt.HatLength, err = strconv.ParseFloat(vals[3], 64); err != nil {
+					return
+				}
+			}
 			found++
 
-		// >>>>> Ground: height:mode:type:...
+		// >>>>> Ground: height:mode:type:nradl:epse:sig
 		case "Ground":
 			if p.Gnd.Height, err = strconv.ParseFloat(vals[0], 64); err != nil {
 				return
@@ -155,6 +327,17 @@ func ParseMdlParams(cmts []string) (p *Record, ok bool, err error) {
 			if p.Gnd.Type, err = strconv.Atoi(vals[2]); err != nil {
 				return
 			}
+			if len(vals) > 5 {
+				if p.Gnd.NRadl, err = strconv.Atoi(vals[3]); err != nil {
+					return
+				}
+				if p.Gnd.Epse, err = strconv.ParseFloat(vals[4], 64); err != nil {
+					return
+				}
+				if p.Gnd.Sig, err = strconv.ParseFloat(vals[5], 64); err != nil {
+					return
+				}
+			}
 			found++
 
 		// >>>>> Param: k:param:tag
@@ -179,30 +362,85 @@ func ParseMdlParams(cmts []string) (p *Record, ok bool, err error) {
 				return
 			}
 			p.Opt = vals[3]
+			if p.GenSeed == 0 {
+				p.GenSeed = p.Seed
+			}
 			found++
 
+		// >>>>> Genseed: seed
+		case "Genseed":
+			if p.GenSeed, err = strconv.ParseInt(vals[0], 10, 64); err != nil {
+				return
+			}
+
+		// >>>>> Init: Gmax:Gmean:SD:Zr:Zi
+		case "Init":
+			p.Init = &Performance{Eff: math.NaN(), Iso: math.NaN(), BW: math.NaN(), Ghoriz: math.NaN(), Gaz: math.NaN(), Gel: math.NaN(), Gsys: math.NaN(), Q: math.NaN()}
+			if err = parsePerf(p.Init, vals); err != nil {
+				return
+			}
+
 		// >>>>> Result: Gmax:Gmean:SD:Zr:Zi
 		case "Result":
-			p.Perf.Gain = new(Gain)
+			if err = parsePerf(&p.Perf, vals); err != nil {
+				return
+			}
+			found++
 
-			if p.Perf.Gain.Max, err = strconv.ParseFloat(vals[0], 64); err != nil {
+		// >>>>> Baseline: Gmax:Gmean:SD:Zr:Zi:SWR (SWR is derived)
+		case "Baseline":
+			p.Base = &Performance{Eff: math.NaN(), Iso: math.NaN(), BW: math.NaN(), Ghoriz: math.NaN(), Gaz: math.NaN(), Gel: math.NaN(), Gsys: math.NaN(), Q: math.NaN()}
+			if err = parsePerf(p.Base, vals); err != nil {
 				return
 			}
-			if p.Perf.Gain.Mean, err = strconv.ParseFloat(vals[1], 64); err != nil {
+
+		// >>>>> Efficiency: eff
+		case "Efficiency":
+			if p.Perf.Eff, err = strconv.ParseFloat(vals[0], 64); err != nil {
 				return
 			}
-			if p.Perf.Gain.SD, err = strconv.ParseFloat(vals[2], 64); err != nil {
+
+		// >>>>> Isotropy: iso
+		case "Isotropy":
+			if p.Perf.Iso, err = strconv.ParseFloat(vals[0], 64); err != nil {
 				return
 			}
-			var Zr, Zi float64
-			if Zr, err = strconv.ParseFloat(vals[3], 64); err != nil {
+
+		// >>>>> Bandwidth: bw
+		case "Bandwidth":
+			if p.Perf.BW, err = strconv.ParseFloat(vals[0], 64); err != nil {
 				return
 			}
-			if Zi, err = strconv.ParseFloat(vals[4], 64); err != nil {
+
+		// >>>>> Ghoriz: gain
+		case "Ghoriz":
+			if p.Perf.Ghoriz, err = strconv.ParseFloat(vals[0], 64); err != nil {
+				return
+			}
+
+		// >>>>> Gaz: gain
+		case "Gaz":
+			if p.Perf.Gaz, err = strconv.ParseFloat(vals[0], 64); err != nil {
+				return
+			}
+
+		// >>>>> Gel: gain
+		case "Gel":
+			if p.Perf.Gel, err = strconv.ParseFloat(vals[0], 64); err != nil {
+				return
+			}
+
+		// >>>>> Gsys: gain
+		case "Gsys":
+			if p.Perf.Gsys, err = strconv.ParseFloat(vals[0], 64); err != nil {
+				return
+			}
+
+		// >>>>> Q: q
+		case "Q":
+			if p.Perf.Q, err = strconv.ParseFloat(vals[0], 64); err != nil {
 				return
 			}
-			p.Perf.Z = complex(Zr, Zi)
-			found++
 
 		// >>>>> Stats: mthds:steps:sims:elapsed
 		case "Stats":
@@ -221,12 +459,47 @@ func ParseMdlParams(cmts []string) (p *Record, ok bool, err error) {
 			}
 			p.Stats.Elapsed = time.Duration(t) * time.Second
 			found++
+
+		// >>>>> Cmdline: args
+		case "Cmdline":
+			p.runInfo().Cmdline = strings.Join(vals, ":")
+
+		// >>>>> Config: simulation
+		case "Config":
+			p.runInfo().Config = strings.Join(vals, ":")
+
+		// >>>>> Library: module@version
+		case "Library":
+			p.runInfo().Library = strings.Join(vals, ":")
 		}
 	}
 	ok = (found > 0)
 	return
 }
 
+// parse performance values (Gmax:Gmean:SD:Zr:Zi)
+func parsePerf(perf *Performance, vals []string) (err error) {
+	perf.Gain = new(Gain)
+	if perf.Gain.Max, err = strconv.ParseFloat(vals[0], 64); err != nil {
+		return
+	}
+	if perf.Gain.Mean, err = strconv.ParseFloat(vals[1], 64); err != nil {
+		return
+	}
+	if perf.Gain.SD, err = strconv.ParseFloat(vals[2], 64); err != nil {
+		return
+	}
+	var Zr, Zi float64
+	if Zr, err = strconv.ParseFloat(vals[3], 64); err != nil {
+		return
+	}
+	if Zi, err = strconv.ParseFloat(vals[4], 64); err != nil {
+		return
+	}
+	perf.Z = complex(Zr, Zi)
+	return
+}
+
 // ParseMdlParamsFromNEC retrieves model parameters from a NEC2 model file
 func ParseMdlParamsFromNEC(fName, dirIn string) (p *Record, ok bool, err error) {
 	var fIn *os.File
@@ -236,6 +509,7 @@ func ParseMdlParamsFromNEC(fName, dirIn string) (p *Record, ok bool, err error)
 	defer fIn.Close()
 
 	var cmts []string
+	var wires []*Line
 	rdr := bufio.NewReader(fIn)
 	var buf []byte
 	for {
@@ -249,25 +523,58 @@ func ParseMdlParamsFromNEC(fName, dirIn string) (p *Record, ok bool, err error)
 		line := string(buf)
 		if len(line) > 2 && line[:3] == "CM " {
 			cmts = append(cmts, line[3:])
+		} else if len(line) > 2 && line[:3] == "GW " {
+			var w *Line
+			if w, err = parseWireCard(line); err != nil {
+				return
+			}
+			wires = append(wires, w)
 		}
 	}
 	p, ok, err = ParseMdlParams(cmts)
 	if p != nil {
 		p.Path = strings.ReplaceAll(filepath.Dir(fName), dirIn+"/", "")
+		if len(wires) > 0 {
+			// antenna size from wire geometry
+			box := NewBoundingBox()
+			p.WireLen = 0
+			for _, w := range wires {
+				box.Include(w.Start())
+				box.Include(w.End())
+				p.WireLen += w.Length()
+			}
+			p.Size[0], p.Size[1], p.Size[2] = box.Extent()
+		}
 	}
 	return
 }
 
+// parseWireCard returns the end points of a wire from a NEC2 GW card
+// ("GW tag segs x1 y1 z1 x2 y2 z2 radius").
+func parseWireCard(line string) (w *Line, err error) {
+	f := strings.Fields(line)
+	if len(f) < 9 {
+		err = fmt.Errorf("invalid wire card '%s'", line)
+		return
+	}
+	var v [6]float64
+	for i := range v {
+		if v[i], err = strconv.ParseFloat(f[i+3], 64); err != nil {
+			return
+		}
+	}
+	w = NewLine(Vec3{v[0], v[1], v[2]}, Vec3{v[3], v[4], v[5]})
+	return
+}
+
 // SplitParam dissects a parameter string
 func SplitParam(line string) (kind string, vals []string) {
-	if strings.HasPrefix(line, "CM ") {
-		line = line[:3]
-	}
+	line = strings.TrimPrefix(line, "CM ")
 	idx := strings.IndexRune(line, ':')
 	if idx == -1 {
 		return
 	}
 	kind = line[:idx]
-	vals = strings.Split(line[idx+2:], ":")
+	vals = strings.Split(strings.TrimPrefix(line[idx+1:], " "), ":")
 	return
 }
@@ -20,7 +20,14 @@
 
 package lib
 
-import "testing"
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestParse(t *testing.T) {
 	lines := []string{
@@ -43,3 +50,133 @@ func TestParse(t *testing.T) {
 	}
 	t.Logf("%v", p)
 }
+
+func TestParamsRoundTrip(t *testing.T) {
+	spec := &Specification{
+		K: 0.75,
+		Wire: Wire{
+			Diameter:     0.002,
+			Material:     "CuL",
+			Conductivity: 5.96e7,
+			Inductance:   1.1e-7,
+		},
+		Ground: Ground{Height: 2.5, Mode: 1, Type: 2, NRadl: 4, Epse: 13, Sig: 0.005},
+		Source: Source{Z: Impedance{R: 50, X: 0}, Freq: 435000000, Span: 10000000},
+		Feedpt: Feedpt{Gap: 0.005, Extension: 0.01, HatSpokes: 4, HatLength: 0.02},
+	}
+	ini := &Performance{Gain: &Gain{Max: 2.1, Mean: -2.2, SD: 41.8}, Z: complex(7.25, -449.5), Eff: math.NaN(), Iso: math.NaN(), BW: math.NaN(), Ghoriz: math.NaN(), Q: math.NaN()}
+	perf := &Performance{Gain: &Gain{Max: 3.5, Mean: -1.5, SD: 8.25}, Z: complex(50.5, -0.25), Eff: 0.875, Iso: 0.125, BW: 0.0625, Ghoriz: 1.5, Gaz: 1.25, Gel: 3.25, Gsys: 0.75, Q: 12.5}
+	stats := Stats{NumMthds: 1, NumSteps: 40, NumSims: 235, Elapsed: 4 * time.Second}
+	base := &Performance{Gain: &Gain{Max: 2.25, Mean: -0.5, SD: 4.5}, Z: complex(72.5, 42.25)}
+	run, err := NewRunInfo([]string{"antgen", "-sweep", "k=0.5:1:0.25", "-tag", "a b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmts := GenMdlParams(0.5, spec, ini, perf, base, "bend2d", "stroll", "Gmax", 1000, 42, "750", stats, run)
+
+	p, ok, err := ParseMdlParams(cmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("!OK")
+	}
+	switch {
+	case p.Freq != spec.Source.Freq || p.Span != spec.Source.Span:
+		t.Errorf("source mismatch: %d/%d", p.Freq, p.Span)
+	case p.Wire != spec.Wire:
+		t.Errorf("wire mismatch: %v", p.Wire)
+	case p.Gnd != spec.Ground:
+		t.Errorf("ground mismatch: %v", p.Gnd)
+	case p.Feedpt != spec.Feedpt:
+		t.Errorf("feedpoint mismatch: %v", p.Feedpt)
+	case p.K != spec.K || p.Param != 0.5 || p.Tag != "750":
+		t.Errorf("param mismatch: %f/%f/%s", p.K, p.Param, p.Tag)
+	case p.Mdl != "bend2d" || p.Gen != "stroll" || p.Opt != "Gmax":
+		t.Errorf("mode mismatch: %s/%s/%s", p.Mdl, p.Gen, p.Opt)
+	case p.Seed != 1000 || p.GenSeed != 42:
+		t.Errorf("seed mismatch: %d/%d", p.Seed, p.GenSeed)
+	case p.Init == nil || *p.Init.Gain != *ini.Gain || p.Init.Z != ini.Z:
+		t.Errorf("initial performance mismatch: %v", p.Init)
+	case p.Base == nil || *p.Base.Gain != *base.Gain || p.Base.Z != base.Z:
+		t.Errorf("baseline performance mismatch: %v", p.Base)
+	case *p.Perf.Gain != *perf.Gain || p.Perf.Z != perf.Z || p.Perf.Eff != perf.Eff || p.Perf.Iso != perf.Iso || p.Perf.BW != perf.BW || p.Perf.Ghoriz != perf.Ghoriz || p.Perf.Gaz != perf.Gaz || p.Perf.Gel != perf.Gel || p.Perf.Gsys != perf.Gsys || p.Perf.Q != perf.Q:
+		t.Errorf("performance mismatch: %v", p.Perf)
+	case p.Stats != stats:
+		t.Errorf("stats mismatch: %v", p.Stats)
+	case p.Run == nil || *p.Run != *run:
+		t.Errorf("run environment mismatch: %v", p.Run)
+	}
+	if run.Cmdline != `antgen -sweep k=0.5:1:0.25 -tag "a b"` {
+		t.Errorf("unexpected command line: %s", run.Cmdline)
+	}
+}
+
+func TestSplitParam(t *testing.T) {
+	for _, line := range []string{
+		"Feedpoint: 0.005:0.010",
+		"CM Feedpoint: 0.005:0.010",
+	} {
+		kind, vals := SplitParam(line)
+		if kind != "Feedpoint" {
+			t.Errorf("'%s': wrong kind '%s'", line, kind)
+		}
+		if len(vals) != 2 || vals[0] != "0.005" || vals[1] != "0.010" {
+			t.Errorf("'%s': wrong values %v", line, vals)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	for _, line := range []string{
+		"Source:",
+		"Wire: 0.002:CuL",
+		"Result: 2.290155:-2.211046",
+		"Stats: 0:0",
+	} {
+		if _, _, err := ParseMdlParams([]string{line}); err == nil {
+			t.Errorf("'%s': no error", line)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	// L-shaped wire (1m along x, 0.5m along y) and a short z stub
+	lines := []string{
+		"CM Source: 435000000:50.000000:0.000000",
+		"CM Param: 0.250000::100",
+		"CM Result: 2.5:-2.2:4.1:50:0",
+		"GW 1 5 -0.5 0 0 0.5 0 0 0.001",
+		"GW 2 3 0.5 0 0 0.5 0.5 0 0.001",
+		"GW 3 1 0.5 0.5 0 0.5 0.5 0.25 0.001",
+		"GE 0",
+	}
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "set", "model-100.nec")
+	if err := os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fname, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, _, err := ParseMdlParamsFromNEC(fname, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Size != (Vec3{1, 0.5, 0.25}) {
+		t.Errorf("size mismatch: %v", p.Size)
+	}
+	if math.Abs(p.WireLen-1.75) > 1e-12 {
+		t.Errorf("wire length mismatch: %f", p.WireLen)
+	}
+	if p.Path != "set" {
+		t.Errorf("path mismatch: %s", p.Path)
+	}
+	// model without wires has no size
+	if p, _, err = ParseMdlParams(lines[:3]); err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(p.WireLen) || !math.IsNaN(p.Size[0]) {
+		t.Errorf("size without wires: %v/%f", p.Size, p.WireLen)
+	}
+}
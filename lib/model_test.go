@@ -0,0 +1,56 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import "testing"
+
+func TestMaxSegs(t *testing.T) {
+	defer func(n int) { Cfg.Sim.MaxSegs = n }(Cfg.Sim.MaxSegs)
+	for _, gap := range []float64{0, 0.01} {
+		for _, limit := range []int{0, 100, 101} {
+			Cfg.Sim.MaxSegs = limit
+			spec := &Specification{
+				K:      2,
+				Wire:   Wire{Diameter: 0.002},
+				Source: Source{Freq: 14000000},
+				Feedpt: Feedpt{Gap: gap},
+			}
+			mdl := new(ModelDipole)
+			if _, err := mdl.Init("", spec, nil); err != nil {
+				t.Fatal(err)
+			}
+			num := 2 * mdl.Num
+			if gap == 0 {
+				num++
+			}
+			if limit > 0 && num > limit {
+				t.Errorf("gap=%g, limit=%d: %d segments", gap, limit, num)
+			}
+			span := 2*spec.K*spec.Source.Lambda() - gap
+			if d := float64(num)*mdl.SegL - span; d > 1e-9 || d < -1e-9 {
+				t.Errorf("gap=%g, limit=%d: segments do not cover span (%g)", gap, limit, d)
+			}
+			if n := len(mdl.Warnings()); (limit > 0) != (n == 1) {
+				t.Errorf("gap=%g, limit=%d: %d warnings", gap, limit, n)
+			}
+		}
+	}
+}
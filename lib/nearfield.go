@@ -0,0 +1,223 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette/moreland"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// NearFieldPoint is the electric and magnetic field at a point (complex
+// peak values of the x, y and z components)
+type NearFieldPoint struct {
+	Pos Vec3          // location
+	E   [3]complex128 // electric field (V/m)
+	H   [3]complex128 // magnetic field (A/m)
+}
+
+// field strength (magnitude of a complex field vector)
+func fieldStrength(f [3]complex128) float64 {
+	var sum float64
+	for _, c := range f {
+		a := cmplx.Abs(c)
+		sum += a * a
+	}
+	return math.Sqrt(sum)
+}
+
+// EMag returns the electric field strength (V/m)
+func (p *NearFieldPoint) EMag() float64 {
+	return fieldStrength(p.E)
+}
+
+// HMag returns the magnetic field strength (A/m)
+func (p *NearFieldPoint) HMag() float64 {
+	return fieldStrength(p.H)
+}
+
+// NearField computes the electric and magnetic field at the given points
+// (antenna driven like in Eval). The NEC binding can't compute near
+// fields, so an external engine is required (see NearFieldReader).
+func (a *Antenna) NearField(freq int64, wire Wire, ground Ground, points []Vec3) (nf []*NearFieldPoint, err error) {
+	var sim Simulator
+	if sim, err = engineSimulator(); err != nil {
+		return
+	}
+	defer sim.Close()
+	nr, ok := sim.(NearFieldReader)
+	if !ok {
+		err = fmt.Errorf("engine '%s' can't compute the near field (use an external NEC2 program)", Cfg.Sim.Engine)
+		return
+	}
+	if err = a.setupSim(sim, freq, wire, ground); err != nil {
+		return
+	}
+	if err = nr.NearField(points); err != nil {
+		return
+	}
+	return nr.NearFieldResults()
+}
+
+//----------------------------------------------------------------------
+
+// NearFieldPlane is a square grid of sample points parallel to the
+// XY plane at height Z (centered on the origin).
+type NearFieldPlane struct {
+	Z    float64 // height of the plane
+	Size float64 // edge length of the plane
+	Num  int     // number of points along an edge
+}
+
+// check plane parameters
+func (plane NearFieldPlane) check() error {
+	if plane.Num < 2 || plane.Size <= 0 {
+		return fmt.Errorf("invalid near-field plane (%d points, size %g)", plane.Num, plane.Size)
+	}
+	return nil
+}
+
+// coordinate of the i-th grid line
+func (plane NearFieldPlane) coord(i int) float64 {
+	return -plane.Size/2 + float64(i)*plane.Size/float64(plane.Num-1)
+}
+
+// Points returns the sample points of the plane (row by row in y, x
+// ascending in a row)
+func (plane NearFieldPlane) Points() (pts []Vec3, err error) {
+	if err = plane.check(); err != nil {
+		return
+	}
+	for j := range plane.Num {
+		for i := range plane.Num {
+			pts = append(pts, NewVec3(plane.coord(i), plane.coord(j), plane.Z))
+		}
+	}
+	return
+}
+
+// DumpNearField writes a card deck that computes the electric and
+// magnetic near field (NE/NH cards) at the points of a plane instead of
+// the radiation pattern (for other NEC2 programs; see NearField).
+func (a *Antenna) DumpNearField(wrt io.Writer, spec *Specification, comments []string, plane NearFieldPlane) (err error) {
+	if err = plane.check(); err != nil {
+		return
+	}
+	a.dumpDeck(wrt, spec, comments, func() {
+		d := plane.Size / float64(plane.Num-1)
+		x0 := -plane.Size / 2
+		for _, card := range []string{"NE", "NH"} {
+			fmt.Fprintf(wrt, "%s 0 %d %d 1 %e %e %e %e %e 0\n", card, plane.Num, plane.Num, x0, x0, plane.Z, d, d)
+		}
+	})
+	return
+}
+
+// WriteNearFieldCSV writes the near field as CSV: location, field
+// strengths (E in V/m, H in A/m; peak values).
+func WriteNearFieldCSV(wrt io.Writer, nf []*NearFieldPoint) (err error) {
+	if _, err = fmt.Fprintln(wrt, "x,y,z,E,H"); err != nil {
+		return
+	}
+	for _, p := range nf {
+		if _, err = fmt.Fprintf(wrt, "%g,%g,%g,%g,%g\n", p.Pos[0], p.Pos[1], p.Pos[2], p.EMag(), p.HMag()); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// nfGrid is the electric field strength on a plane (heatmap grid)
+type nfGrid struct {
+	plane NearFieldPlane
+	vals  []float64
+}
+
+// Dims returns the grid dimensions
+func (g *nfGrid) Dims() (c, r int) {
+	return g.plane.Num, g.plane.Num
+}
+
+// X returns the x coordinate of column c
+func (g *nfGrid) X(c int) float64 {
+	return g.plane.coord(c)
+}
+
+// Y returns the y coordinate of row r
+func (g *nfGrid) Y(r int) float64 {
+	return g.plane.coord(r)
+}
+
+// Z returns the field strength in a grid cell
+func (g *nfGrid) Z(c, r int) float64 {
+	return g.vals[r*g.plane.Num+c]
+}
+
+// PlotNearField renders the electric field strength on a plane (computed
+// with NearField at the plane points) as a heatmap.
+func PlotNearField(plane NearFieldPlane, nf []*NearFieldPoint, format string) (out string, err error) {
+	if len(nf) != plane.Num*plane.Num {
+		err = fmt.Errorf("near field doesn't match plane (%d points)", len(nf))
+		return
+	}
+	g := &nfGrid{plane: plane, vals: make([]float64, len(nf))}
+	for i, p := range nf {
+		g.vals[i] = p.EMag()
+	}
+	pal := moreland.SmoothBlueRed().Palette(30)
+	hm := plotter.NewHeatMap(g, pal)
+
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("|E| (V/m) at z=%gm", plane.Z)
+	p.X.Label.Text = "x (m)"
+	p.Y.Label.Text = "y (m)"
+	p.Add(hm)
+	thumbs := plotter.PaletteThumbnailers(pal)
+	for i := len(thumbs) - 1; i >= 0; i-- {
+		label := ""
+		switch i {
+		case 0:
+			label = fmt.Sprintf("%.3g", hm.Min)
+		case len(thumbs) - 1:
+			label = fmt.Sprintf("%.3g", hm.Max)
+		}
+		p.Legend.Add(label, thumbs[i])
+	}
+	p.Legend.Top = true
+
+	var wrt io.WriterTo
+	if wrt, err = p.WriterTo(16*vg.Centimeter, 14*vg.Centimeter, format); err != nil {
+		return
+	}
+	buf := new(bytes.Buffer)
+	if _, err = wrt.WriteTo(buf); err != nil {
+		return
+	}
+	out = buf.String()
+	return
+}
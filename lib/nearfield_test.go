@@ -0,0 +1,158 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// near-field output of a NEC2 program (two points; impedance line
+// required)
+const nec2cNearField = `
+                           --------- ANTENNA INPUT PARAMETERS ---------
+  TAG   SEG       VOLTAGE (VOLTS)         CURRENT (AMPS)         IMPEDANCE (OHMS)        ADMITTANCE (MHOS)     POWER
+  NO.   NO.     REAL      IMAGINARY     REAL      IMAGINARY     REAL      IMAGINARY    REAL       IMAGINARY   (WATTS)
+    1     1  1.0000E+00  0.0000E+00  1.0568E-02 -5.9042E-03  7.2094E+01  4.0283E+01  1.0568E-02 -5.9042E-03  5.2842E-03
+
+                             -------- NEAR ELECTRIC FIELDS --------
+     ------- LOCATION -------     ------- EX ------    ------- EY ------    ------- EZ ------
+      X         Y         Z       MAGNITUDE   PHASE    MAGNITUDE   PHASE    MAGNITUDE   PHASE
+    METERS    METERS    METERS     VOLTS/M  DEGREES    VOLTS/M  DEGREES     VOLTS/M  DEGREES
+   -0.5000    0.0000    0.1000  3.0000E+00    0.00  4.0000E+00   90.00  0.0000E+00    0.00
+    0.5000    0.0000    0.1000  1.0000E+00   45.00  0.0000E+00    0.00  0.0000E+00    0.00
+
+                             -------- NEAR MAGNETIC FIELDS ---------
+     ------- LOCATION -------     ------- HX ------    ------- HY ------    ------- HZ ------
+      X         Y         Z       MAGNITUDE   PHASE    MAGNITUDE   PHASE    MAGNITUDE   PHASE
+    METERS    METERS    METERS      AMPS/M  DEGREES      AMPS/M  DEGREES      AMPS/M  DEGREES
+   -0.5000    0.0000    0.1000  0.0000E+00    0.00  1.0000E-02    0.00  0.0000E+00    0.00
+    0.5000    0.0000    0.1000  0.0000E+00    0.00  2.0000E-02    0.00  0.0000E+00    0.00
+`
+
+func TestParseNearField(t *testing.T) {
+	out, err := ParseNec2Output(strings.NewReader(nec2cNearField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.NearE) != 2 || len(out.NearH) != 2 {
+		t.Fatalf("%d/%d near-field points", len(out.NearE), len(out.NearH))
+	}
+	e := out.NearE[0]
+	if e.Pos != NewVec3(-0.5, 0, 0.1) || math.Abs(imag(e.F[1])-4) > 1e-9 {
+		t.Errorf("unexpected field %+v", e)
+	}
+}
+
+func TestNearField(t *testing.T) {
+	// fake engine: copy the canned output
+	dir := t.TempDir()
+	res := filepath.Join(dir, "result.out")
+	if err := os.WriteFile(res, []byte(nec2cNearField), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "nec2c")
+	script := "#!/bin/sh\ncp " + res + " \"$4\"\n"
+	if err := os.WriteFile(exe, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(c Simulation) { Cfg.Sim = &c }(*Cfg.Sim)
+	Cfg.Sim.Engine = exe
+
+	ant := NewAntenna("test")
+	ant.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	pts := []Vec3{NewVec3(-0.5, 0, 0.1), NewVec3(0.5, 0, 0.1)}
+	nf, err := ant.NearField(435000000, Wire{Diameter: 0.002}, Ground{}, pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nf) != 2 || math.Abs(nf[0].EMag()-5) > 1e-9 || math.Abs(nf[1].HMag()-0.02) > 1e-9 {
+		t.Fatalf("unexpected near field %+v", nf)
+	}
+	buf := new(bytes.Buffer)
+	if err = WriteNearFieldCSV(buf, nf); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || lines[1] != "-0.5,0,0.1,5,0.01" {
+		t.Errorf("unexpected CSV %q", lines)
+	}
+	// more points requested than computed
+	if _, err = ant.NearField(435000000, Wire{Diameter: 0.002}, Ground{}, append(pts, pts...)); err == nil {
+		t.Error("expected error for incomplete near field")
+	}
+	// NEC2 library can't compute the near field
+	Cfg.Sim.Engine = "necpp"
+	if _, err = ant.NearField(435000000, Wire{Diameter: 0.002}, Ground{}, pts); err == nil {
+		t.Error("expected error for NEC2 library")
+	}
+}
+
+func TestNearFieldPlane(t *testing.T) {
+	plane := NearFieldPlane{Z: 0.1, Size: 1, Num: 3}
+	pts, err := plane.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 9 || pts[0] != NewVec3(-0.5, -0.5, 0.1) || pts[5] != NewVec3(0.5, 0, 0.1) {
+		t.Fatalf("unexpected points %v", pts)
+	}
+	nf := make([]*NearFieldPoint, len(pts))
+	for i, p := range pts {
+		nf[i] = &NearFieldPoint{Pos: p, E: [3]complex128{complex(float64(i), 0)}}
+	}
+	out, err := PlotNearField(plane, nf, "svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "<svg") {
+		t.Error("no SVG output")
+	}
+	if _, err = PlotNearField(plane, nf[1:], "svg"); err == nil {
+		t.Error("expected error for mismatched plane")
+	}
+}
+
+func TestDumpNearField(t *testing.T) {
+	ant := NewAntenna("test")
+	ant.Add(NewLine(NewVec3(-0.25, 0, 0), NewVec3(0.25, 0, 0)))
+	plane := NearFieldPlane{Z: 0.1, Size: 1, Num: 11}
+	buf := new(bytes.Buffer)
+	if err := ant.DumpNearField(buf, new(Specification), nil, plane); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "\nRP ") {
+		t.Fatal("unexpected RP card")
+	}
+	for _, card := range []string{"\nNE 0 11 11 1 ", "\nNH 0 11 11 1 "} {
+		if !strings.Contains(out, card) {
+			t.Fatalf("missing card '%s'", card[1:])
+		}
+	}
+	plane.Num = 1
+	if err := ant.DumpNearField(buf, new(Specification), nil, plane); err == nil {
+		t.Fatal("expected error for invalid plane")
+	}
+}
@@ -0,0 +1,425 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Nec2cSimulator runs an external NEC2 program (like 'nec2c') as a
+// subprocess: the simulator calls assemble a card deck that is written to
+// a temporary file; the engine is started on the first request for results
+// and its output file is parsed for impedance and radiation pattern.
+type Nec2cSimulator struct {
+	exe    string       // external engine
+	deck   bytes.Buffer // card deck
+	nTheta int          // number of pattern elevation steps
+	nPhi   int          // number of pattern azimuth steps
+	dTheta float64      // pattern elevation step (degree)
+	dPhi   float64      // pattern azimuth step (degree)
+	nNear  int          // number of near-field points
+	tags   []int        // tags of excited wires (in order of Excite)
+	out    *Nec2Output  // parsed output (after run)
+}
+
+// NewNec2cSimulator returns a simulator for an external NEC2 program. The
+// program is called as '<exe> -i <deck> -o <output>'.
+func NewNec2cSimulator(exe string) *Nec2cSimulator {
+	s := &Nec2cSimulator{exe: exe}
+	s.deck.WriteString("CM antgen\nCE\n")
+	return s
+}
+
+// Wire adds a straight wire (GW card)
+func (s *Nec2cSimulator) Wire(tag, segs int, start, end Vec3, radius float64) error {
+	fmt.Fprintf(&s.deck, "GW %d %d %e %e %e %e %e %e %e\n", tag, segs,
+		start[0], start[1], start[2], end[0], end[1], end[2], radius)
+	return nil
+}
+
+// GroundComplete ends the geometry and sets the ground parameters
+// (GE and GN cards)
+func (s *Nec2cSimulator) GroundComplete(ground Ground) error {
+	fmt.Fprintf(&s.deck, "GE %d\n", ground.Mode)
+	if ground.Mode != 0 {
+		fmt.Fprintf(&s.deck, "GN %d %d 0 0 %e %e\n", ground.Type, ground.NRadl, ground.Epse, ground.Sig)
+	}
+	return nil
+}
+
+// Load sets the wire loss and inductance (LD cards)
+func (s *Nec2cSimulator) Load(wire Wire, freq int64) error {
+	if !IsNull(wire.Conductivity) {
+		if Cfg.Sim.SkinEffect {
+			fmt.Fprintf(&s.deck, "LD 2 0 0 0 %e 0 0\n", wire.Resistance(freq))
+		} else {
+			fmt.Fprintf(&s.deck, "LD 5 0 0 0 %e\n", wire.Conductivity)
+		}
+	}
+	if !IsNull(wire.Inductance) {
+		fmt.Fprintf(&s.deck, "LD 2 0 0 0 0 %e 0\n", wire.Inductance)
+	}
+	return nil
+}
+
+// Frequency sets the simulation frequency (FR card)
+func (s *Nec2cSimulator) Frequency(freq int64) error {
+	fmt.Fprintf(&s.deck, "FR 0 1 0 0 %f 0\n", float64(freq)/1e6)
+	return nil
+}
+
+// Excite applies a voltage to a segment (EX card)
+func (s *Nec2cSimulator) Excite(seg int, volts complex128) error {
+	fmt.Fprintf(&s.deck, "EX 0 %d 1 0 %e %e\n", seg+1, real(volts), imag(volts))
+	s.tags = append(s.tags, seg+1)
+	return nil
+}
+
+// Pattern requests the radiation pattern (RP card)
+func (s *Nec2cSimulator) Pattern(nTheta, nPhi int, thetaStep, phiStep float64) error {
+	s.nTheta, s.nPhi, s.dTheta, s.dPhi = nTheta, nPhi, thetaStep, phiStep
+	fmt.Fprintf(&s.deck, "RP 0 %d %d 1000 0 0 %g %g\n", nTheta, nPhi, thetaStep, phiStep)
+	return nil
+}
+
+// Results runs the external engine and returns the gain summary and the
+// input impedance (of the first excited wire).
+func (s *Nec2cSimulator) Results() (gain *Gain, z complex128, err error) {
+	if err = s.run(); err != nil {
+		return
+	}
+	gain = new(Gain)
+	gain.Max, gain.MaxR, gain.MaxL = -999.99, -999.99, -999.99
+	gain.MaxH, gain.MaxV = -999.99, -999.99
+	var sum, sum2 float64
+	for _, pt := range s.out.Pattern {
+		gain.Max = max(gain.Max, pt.Total)
+		gain.MaxR = max(gain.MaxR, pt.Rhcp)
+		gain.MaxL = max(gain.MaxL, pt.Lhcp)
+		gain.MaxH = max(gain.MaxH, pt.Hor)
+		gain.MaxV = max(gain.MaxV, pt.Vert)
+		sum += pt.Total
+		sum2 += pt.Total * pt.Total
+	}
+	n := float64(len(s.out.Pattern))
+	gain.Mean = sum / n
+	gain.SD = math.Sqrt(max(0, sum2/n-gain.Mean*gain.Mean))
+	z = s.out.Z
+	if len(s.tags) > 1 {
+		var zs []complex128
+		if zs, err = s.Impedances(); err != nil {
+			return
+		}
+		z = zs[0]
+	}
+	return
+}
+
+// Gain returns the pattern gain at the given pattern indices
+func (s *Nec2cSimulator) Gain(theta, phi int) (float64, error) {
+	if err := s.run(); err != nil {
+		return 0, err
+	}
+	th, ph := float64(theta)*s.dTheta, float64(phi)*s.dPhi
+	for _, pt := range s.out.Pattern {
+		if math.Abs(pt.Theta-th) < 1e-3 && math.Abs(pt.Phi-ph) < 1e-3 {
+			return pt.Total, nil
+		}
+	}
+	return 0, fmt.Errorf("no pattern gain at Θ=%g, Φ=%g", th, ph)
+}
+
+// NearField requests the electric and magnetic field at the given points
+// (NE/NH cards)
+func (s *Nec2cSimulator) NearField(points []Vec3) error {
+	for _, card := range []string{"NE", "NH"} {
+		for _, p := range points {
+			fmt.Fprintf(&s.deck, "%s 0 1 1 1 %e %e %e 0 0 0\n", card, p[0], p[1], p[2])
+		}
+	}
+	s.nNear = len(points)
+	return nil
+}
+
+// NearFieldResults runs the external engine and returns the near field at
+// the requested points (in order)
+func (s *Nec2cSimulator) NearFieldResults() (pts []*NearFieldPoint, err error) {
+	if err = s.run(); err != nil {
+		return
+	}
+	e, h := s.out.NearE, s.out.NearH
+	if len(e) != s.nNear || len(h) != s.nNear {
+		err = fmt.Errorf("%s: incomplete near field (%d/%d of %d points)", s.exe, len(e), len(h), s.nNear)
+		return
+	}
+	for i := range e {
+		pts = append(pts, &NearFieldPoint{Pos: e[i].Pos, E: e[i].F, H: h[i].F})
+	}
+	return
+}
+
+// Currents returns the currents of all NEC segments (in wire order)
+func (s *Nec2cSimulator) Currents() ([]complex128, error) {
+	if err := s.run(); err != nil {
+		return nil, err
+	}
+	return s.out.Currents, nil
+}
+
+// Impedances returns the input impedances of all feed points (in the
+// order of the Excite calls).
+func (s *Nec2cSimulator) Impedances() (z []complex128, err error) {
+	if err = s.run(); err != nil {
+		return
+	}
+	for _, tag := range s.tags {
+		i := slices.IndexFunc(s.out.Inputs, func(in *Nec2Input) bool { return in.Tag == tag })
+		if i < 0 {
+			return nil, fmt.Errorf("nec2: no input parameters for wire %d", tag)
+		}
+		z = append(z, s.out.Inputs[i].Z)
+	}
+	return
+}
+
+// Close releases the simulator resources
+func (s *Nec2cSimulator) Close() {}
+
+// run the external engine (once)
+func (s *Nec2cSimulator) run() (err error) {
+	if s.out != nil {
+		return
+	}
+	var dir string
+	if dir, err = os.MkdirTemp("", "antgen-nec"); err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "model.nec"), filepath.Join(dir, "model.out")
+	s.deck.WriteString("EN\n")
+	if err = os.WriteFile(in, s.deck.Bytes(), 0o644); err != nil {
+		return
+	}
+	cmd := exec.Command(s.exe, "-i", in, "-o", out)
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w (%s)", s.exe, err, strings.TrimSpace(string(msg)))
+	}
+	var f *os.File
+	if f, err = os.Open(out); err != nil {
+		return
+	}
+	defer f.Close()
+	if s.out, err = ParseNec2Output(f); err != nil {
+		return
+	}
+	if n := len(s.out.Pattern); n != s.nTheta*s.nPhi {
+		err = fmt.Errorf("%s: incomplete radiation pattern (%d of %d points)", s.exe, n, s.nTheta*s.nPhi)
+	}
+	return
+}
+
+//----------------------------------------------------------------------
+
+// Nec2Output is the result of a NEC2 run (single frequency)
+type Nec2Output struct {
+	Z        complex128     // input impedance (first feed point)
+	Inputs   []*Nec2Input   // input parameters of all feed points
+	Pattern  []*Nec2Pattern // radiation pattern
+	Currents []complex128   // currents of all segments (in wire order)
+	NearE    []*Nec2Field   // near electric field (V/m)
+	NearH    []*Nec2Field   // near magnetic field (A/m)
+}
+
+// Nec2Input holds the input parameters of a feed point
+type Nec2Input struct {
+	Tag, Seg int        // excited wire and segment
+	Z        complex128 // input impedance
+}
+
+// Nec2Field is a near-field vector at a point (peak values)
+type Nec2Field struct {
+	Pos Vec3          // location
+	F   [3]complex128 // field components (x, y, z)
+}
+
+// Nec2Pattern is a point of the radiation pattern (gains in dBi)
+type Nec2Pattern struct {
+	Theta, Phi float64 // direction (degree)
+	Total      float64 // total power gain
+	Rhcp, Lhcp float64 // circular polarized components
+	Vert, Hor  float64 // linear polarized components
+}
+
+// numbers in NEC2 output (Fortran-style exponents; adjacent numbers are
+// not always separated by blanks)
+var nec2Num = regexp.MustCompile(`[-+]?(\d+\.?\d*|\.\d+)([EeDd][-+]?\d+)?`)
+
+// column headings in NEC2 output (dashed like section headings)
+var nec2Columns = []string{"ANGLES", "GAIN", "CURRENT", "LOCATION"}
+
+// parse all numbers in a line of NEC2 output
+func nec2Numbers(line string) (vals []float64) {
+	for _, s := range nec2Num.FindAllString(line, -1) {
+		s = strings.Map(func(r rune) rune {
+			if r == 'D' || r == 'd' {
+				return 'E'
+			}
+			return r
+		}, s)
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil
+		}
+		vals = append(vals, v)
+	}
+	return
+}
+
+// ParseNec2Output reads the impedance, the segment currents, the near
+// field and the radiation pattern from the output of a NEC2 program. Sections are identified by their headings;
+// data lines are recognized by the number of numeric fields, so different
+// column widths and spacing (as found in NEC2 forks) are accepted.
+func ParseNec2Output(rdr io.Reader) (out *Nec2Output, err error) {
+	out = new(Nec2Output)
+	const (
+		secNone = iota
+		secInput
+		secPattern
+		secCurrents
+		secNearE
+		secNearH
+	)
+	sec, haveZ := secNone, false
+	scanner := bufio.NewScanner(rdr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		upper := strings.ToUpper(line)
+		switch {
+		case strings.Contains(upper, "ANTENNA INPUT PARAMETERS"):
+			sec = secInput
+			continue
+		case strings.Contains(upper, "RADIATION PATTERN"):
+			sec = secPattern
+			continue
+		case strings.Contains(upper, "CURRENTS AND LOCATION"):
+			sec = secCurrents
+			continue
+		case strings.Contains(upper, "NEAR ELECTRIC FIELDS"):
+			sec = secNearE
+			continue
+		case strings.Contains(upper, "NEAR MAGNETIC FIELDS"):
+			sec = secNearH
+			continue
+		case strings.Contains(upper, "- - -") || strings.Contains(upper, "-----"):
+			// start of another section (unless column headings)
+			if !slices.ContainsFunc(nec2Columns, func(s string) bool {
+				return strings.Contains(upper, s)
+			}) {
+				sec = secNone
+			}
+			continue
+		}
+		vals := nec2Numbers(line)
+		switch sec {
+		case secInput:
+			// tag, seg, voltage, current, impedance, admittance[, power]
+			if len(vals) >= 10 {
+				z := complex(vals[6], vals[7])
+				out.Inputs = append(out.Inputs, &Nec2Input{Tag: int(vals[0]), Seg: int(vals[1]), Z: z})
+				if !haveZ {
+					out.Z = z
+					haveZ = true
+				}
+			}
+		case secPattern:
+			// theta, phi, vert., hor., total gain, axial ratio, tilt,
+			// [sense], E(theta) mag/phase, E(phi) mag/phase
+			if len(vals) < 11 {
+				continue
+			}
+			pt := &Nec2Pattern{Theta: vals[0], Phi: vals[1], Total: vals[4], Vert: vals[2], Hor: vals[3]}
+			pt.Rhcp, pt.Lhcp = circularGains(pt.Total, vals[5], upper)
+			out.Pattern = append(out.Pattern, pt)
+		case secCurrents:
+			// seg, tag, center x/y/z, length, current real/imag/magn/phase
+			if len(vals) >= 10 {
+				out.Currents = append(out.Currents, complex(vals[6], vals[7]))
+			}
+		case secNearE, secNearH:
+			// location x/y/z, magnitude/phase of x, y and z component
+			if len(vals) < 9 {
+				continue
+			}
+			f := &Nec2Field{Pos: NewVec3(vals[0], vals[1], vals[2])}
+			for i := range 3 {
+				f.F[i] = cmplx.Rect(vals[3+2*i], vals[4+2*i]*math.Pi/180)
+			}
+			if sec == secNearE {
+				out.NearE = append(out.NearE, f)
+			} else {
+				out.NearH = append(out.NearH, f)
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if !haveZ {
+		err = errors.New("no input impedance in NEC2 output")
+	} else if len(out.Pattern) == 0 && len(out.NearE) == 0 {
+		err = errors.New("no radiation pattern in NEC2 output")
+	}
+	return
+}
+
+// split the total gain (dBi) of an elliptically polarized field into its
+// circular components based on the axial ratio and the sense of rotation.
+func circularGains(total, axial float64, line string) (rhcp, lhcp float64) {
+	r := math.Min(math.Abs(axial), 1)
+	co := (1 + r) * (1 + r) / (2 * (1 + r*r))
+	db := func(frac float64) float64 {
+		if frac <= 0 || total <= -999 {
+			return -999.99
+		}
+		return max(-999.99, total+10*math.Log10(frac))
+	}
+	switch {
+	case strings.Contains(line, "RIGHT"):
+		return db(co), db(1 - co)
+	case strings.Contains(line, "LEFT"):
+		return db(1 - co), db(co)
+	}
+	// linear polarization
+	return db(0.5), db(0.5)
+}
@@ -0,0 +1,169 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// excerpt of 'nec2c' output (two feed points, 6 pattern points, glued
+// numbers in the impedance line)
+const nec2cOutput = `
+                           --------- ANTENNA INPUT PARAMETERS ---------
+  TAG   SEG       VOLTAGE (VOLTS)         CURRENT (AMPS)         IMPEDANCE (OHMS)        ADMITTANCE (MHOS)     POWER
+  NO.   NO.     REAL      IMAGINARY     REAL      IMAGINARY     REAL      IMAGINARY    REAL       IMAGINARY   (WATTS)
+    1     1  1.0000E+00  0.0000E+00  1.0568E-02 -5.9042E-03  7.2094E+01 4.0283E+01-1.0568E-02 -5.9042E-03  5.2842E-03
+    2     2  0.0000E+00  1.0000E+00  3.8462E-03  1.9231E-02  5.0000E+01 1.0000E+01 3.8462E-03  1.9231E-02  9.6154E-03
+
+                           --------- CURRENTS AND LOCATION ---------
+  SEG.  TAG    COORD. OF SEG. CENTER     SEG.            - - - CURRENT (AMPS) - - -
+   1    1   0.0000  0.0000  0.0000   0.01000  1.0568E-02 -5.9042E-03  1.2106E-02  -29.19
+
+                           ---------- RADIATION PATTERNS -----------
+  ---- ANGLES -----     ----- POWER GAINS -----       ---- POLARIZATION ----   ---- E(THETA) ----    ----- E(PHI) ------
+  THETA      PHI      VERTC    HORIZ    TOTAL       AXIAL      TILT  SENSE   MAGNITUDE  PHASE    MAGNITUDE   PHASE
+ DEGREES   DEGREES      DB       DB       DB        RATIO   DEGREES            VOLTS  DEGREES      VOLTS   DEGREES
+    0.00      0.00    -3.00  -999.99    -3.00    0.00000    0.00  LINEAR  1.0000E+00    0.00  0.0000E+00    0.00
+  180.00      0.00    -3.00  -999.99    -3.00    0.00000    0.00  LINEAR  1.0000E+00    0.00  0.0000E+00    0.00
+    0.00    180.00    -3.00  -999.99    -3.00    0.00000    0.00  LINEAR  1.0000E+00    0.00  0.0000E+00    0.00
+  180.00    180.00    -3.00  -999.99    -3.00    0.00000    0.00  LINEAR  1.0000E+00    0.00  0.0000E+00    0.00
+    0.00    360.00     2.15     2.15     5.16    1.00000    0.00  RIGHT   1.0000E+00    0.00  1.0000E+00   90.00
+  180.00    360.00    -3.00  -999.99    -3.00    0.00000    0.00  LINEAR  1.0000E+00    0.00  0.0000E+00    0.00
+
+          AVERAGE POWER GAIN:  1.0024E+00  - SOLID ANGLE USED IN AVERAGING: ( 4.0000 )*PI STERADIANS
+`
+
+func TestParseNec2Output(t *testing.T) {
+	out, err := ParseNec2Output(strings.NewReader(nec2cOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Z != complex(72.094, 40.283) {
+		t.Errorf("Z = %v", out.Z)
+	}
+	if len(out.Inputs) != 2 || out.Inputs[1].Tag != 2 || out.Inputs[1].Z != complex(50, 10) {
+		t.Errorf("inputs = %v", out.Inputs)
+	}
+	if len(out.Currents) != 1 || out.Currents[0] != complex(1.0568e-2, -5.9042e-3) {
+		t.Errorf("currents = %v", out.Currents)
+	}
+	if len(out.Pattern) != 6 {
+		t.Fatalf("%d pattern points", len(out.Pattern))
+	}
+	// circular polarization (right-hand)
+	pt := out.Pattern[4]
+	if pt.Phi != 360 || pt.Total != 5.16 || pt.Rhcp != 5.16 || pt.Lhcp > -999 {
+		t.Errorf("unexpected pattern point %+v", pt)
+	}
+	if pt.Vert != 2.15 || pt.Hor != 2.15 {
+		t.Errorf("unexpected linear components %+v", pt)
+	}
+	// linear polarization
+	if pt = out.Pattern[0]; math.Abs(pt.Rhcp-(-6.0103)) > 1e-3 || pt.Rhcp != pt.Lhcp {
+		t.Errorf("unexpected pattern point %+v", pt)
+	}
+	if _, err = ParseNec2Output(strings.NewReader("no output")); err == nil {
+		t.Error("expected error for missing results")
+	}
+}
+
+func TestNec2cSimulator(t *testing.T) {
+	// fake engine: copy the canned output
+	dir := t.TempDir()
+	res := filepath.Join(dir, "result.out")
+	if err := os.WriteFile(res, []byte(nec2cOutput), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "nec2c")
+	script := "#!/bin/sh\ncp " + res + " \"$4\"\n"
+	if err := os.WriteFile(exe, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(c Simulation) { Cfg.Sim = &c }(*Cfg.Sim)
+	Cfg.Sim.Engine = exe
+	Cfg.Sim.ThetaStep, Cfg.Sim.PhiStep = 180, 180
+	if err := CheckEngine(); err != nil {
+		t.Fatal(err)
+	}
+
+	ant := NewAntenna("test")
+	ant.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	if err := ant.Eval(435000000, Wire{Diameter: 0.002}, Ground{}); err != nil {
+		t.Fatal(err)
+	}
+	if ant.Perf.Gain.Max != 5.16 || ant.Perf.Z != complex(72.094, 40.283) {
+		t.Errorf("unexpected performance: %s", ant.Perf)
+	}
+	if g := ant.Perf.Gain; g.MaxV != 2.15 || g.MaxH != 2.15 {
+		t.Errorf("unexpected polarized gains: %+v", g)
+	}
+	if rp := ant.Perf.Rp; rp.Values[0][2] != 5.16 || rp.Values[1][0] != -3 {
+		t.Errorf("unexpected pattern: %v", rp.Values)
+	}
+
+	// segment currents: single pattern direction (first point only)
+	i := strings.Index(nec2cOutput, "  180.00      0.00")
+	j := strings.Index(nec2cOutput, "\n\n          AVERAGE")
+	if err := os.WriteFile(res, []byte(nec2cOutput[:i]+nec2cOutput[j+1:]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ant.EvalCurrents(435000000, Wire{Diameter: 0.002}, Ground{}); err != nil {
+		t.Fatal(err)
+	}
+	if cur := ant.Perf.Currents; len(cur) != 1 || cur[0] != complex(1.0568e-2, -5.9042e-3) {
+		t.Errorf("unexpected currents: %v", cur)
+	}
+	// current in phase: positive (red); opposite phase: negative (blue)
+	phi := -cmplx.Phase(ant.Perf.Currents[0])
+	if clr := ant.CurrentColors(phi).segColor(0); clr.R != 255 || clr.B != 0 {
+		t.Errorf("unexpected color %v", clr)
+	}
+	if clr := ant.CurrentColors(phi + math.Pi).segColor(0); clr.R != 0 || clr.B != 255 {
+		t.Errorf("unexpected color %v", clr)
+	}
+	if ant.segColor(0) != nil {
+		t.Error("colored copy changed the antenna")
+	}
+
+	// impedances of all feed points (in order of excitation)
+	if err := os.WriteFile(res, []byte(nec2cOutput), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	arr := NewAntenna("array")
+	arr.Add(NewLine(NewVec3(-0.005, 0.3, 0), NewVec3(0.005, 0.3, 0)))
+	arr.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	arr.SetExcitation(1)
+	arr.AddExcitation(0, complex(0, 1))
+	if err := arr.Eval(435000000, Wire{Diameter: 0.002}, Ground{}); err != nil {
+		t.Fatal(err)
+	}
+	if z := arr.Perf.FeedZ; arr.Perf.Z != complex(50, 10) || len(z) != 2 || z[0] != complex(50, 10) || z[1] != complex(72.094, 40.283) {
+		t.Errorf("unexpected feed impedances: %v", z)
+	}
+	if c := arr.clone(); &c.feeds[0] == &arr.feeds[0] {
+		t.Error("clone shares the feed points")
+	}
+}
@@ -31,15 +31,37 @@ import (
 // ParseImpedance (complex value) from string.
 // A valid string is formed from one or two numbers combined; the single
 // number or one of the two numbers can be tagged by a "j" or "i" as
-// imaginary. Spaces and multiplication signs in a string are ignored.
+// imaginary (in front of or after the number). Spaces, multiplication
+// signs and units ("Ω", "ohm" or "ohms") in a string are ignored. A sign at
+// the start of the string belongs to the first number; the second number
+// is separated by its sign. Two numbers must be one real and one imaginary
+// part. Numbers can have a magnitude suffix (see ParseNumber).
 //
 // Examples of valid strings:
 // * "50"     		// only real part -> (50,0)
+// * "-50"     		// only real part -> (-50,0)
 // * "-j30.624"		// only imaginary part -> (0,-30.624)
+// * "+j10"		// only imaginary part -> (0,10)
 // * "87.37+j41.74" // complex number -> (87.37,41.74)
+// * "-35.4-6.8j"   // complex number -> (-35.4,-6.8)
 // * "j41.74+87.37" // complex number -> (87.37,41.74)
+// * "48.5 - j3.2 Ω" // complex number -> (48.5,-3.2)
 func ParseImpedance(s string) (Z complex128, err error) {
-	// remove redundant runes from string
+	return ParseImpedanceAt(s, 0)
+}
+
+// units of impedance (removed from impedance strings)
+var ohms = []string{"Ω", "Ω", "ohms", "Ohms", "OHMS", "ohm", "Ohm", "OHM"}
+
+// ParseImpedanceAt parses an impedance (see ParseImpedance) at a given
+// frequency: the reactance can also be specified by the value of a
+// component in series ("+" only) to the real part: an inductance (suffix
+// "H") or a capacitance (suffix "F"), e.g. "50+100nH" or "50+10pF".
+func ParseImpedanceAt(s string, freq float64) (Z complex128, err error) {
+	// remove units and redundant runes from string
+	for _, u := range ohms {
+		s = strings.ReplaceAll(s, u, "")
+	}
 	var t string
 	for _, r := range s {
 		if !strings.ContainsRune(" *·", r) {
@@ -47,42 +69,90 @@ func ParseImpedance(s string) (Z complex128, err error) {
 		}
 	}
 	s = strings.ReplaceAll(t, "i", "j")
+	if len(s) == 0 {
+		err = errors.New("empty impedance string")
+		return
+	}
 
-	// parse impedance
-	var r, i float64
-	if pos := max(strings.IndexRune(s, '+'), strings.IndexRune(s, '-')); pos < 1 {
-		// only one part
-		im := strings.ContainsRune(s, 'j')
-		s = strings.ReplaceAll(t, "j", "")
-		if r, err = ParseNumber(s); err != nil {
+	// find separator (sign of second number; not in an exponent)
+	pos := -1
+	for i := 1; i < len(s); i++ {
+		if (s[i] == '+' || s[i] == '-') && s[i-1] != 'e' && s[i-1] != 'E' {
+			if pos != -1 {
+				err = fmt.Errorf("invalid impedance '%s'", t)
+				return
+			}
+			pos = i
+		}
+	}
+	// parse parts
+	parts := []string{s}
+	if pos != -1 {
+		parts = []string{s[:pos], s[pos:]}
+	}
+	var (
+		v      float64
+		im     bool
+		nr, ni int
+	)
+	for _, part := range parts {
+		if v, im, err = parsePart(part, freq); err != nil {
 			return
 		}
 		if im {
-			Z = complex(0, r)
+			Z += complex(0, v)
+			ni++
 		} else {
-			Z = complex(r, 0)
+			Z += complex(v, 0)
+			nr++
 		}
+	}
+	if nr > 1 || ni > 1 {
+		err = fmt.Errorf("invalid impedance '%s' (need real and imaginary part)", t)
+	}
+	return
+}
 
-	} else {
-		// split string into two values
-		sign := (s[pos] == '-')
-		v1, v2 := s[:pos], s[pos+1:]
-		if strings.ContainsRune(v1, 'j') {
-			v1, v2 = v2, v1
+// parse a signed number with optional imaginary tag ('j' in front of or
+// after the number) or a component value (reactance at given frequency)
+func parsePart(s string, freq float64) (v float64, im bool, err error) {
+	sign := ""
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign, s = s[:1], s[1:]
+	}
+	if n := len(s); n > 1 && (s[n-1] == 'H' || s[n-1] == 'F') {
+		// component in series
+		if sign == "-" {
+			err = fmt.Errorf("negative component '%s'", s)
+			return
 		}
-		v2 = strings.Replace(v2, "j", "", 1)
-
-		if r, err = ParseNumber(v1); err != nil {
+		if freq <= 0 {
+			err = fmt.Errorf("component '%s' needs a frequency", s)
 			return
 		}
-		if i, err = ParseNumber(v2); err != nil {
+		if v, err = ParseNumber(s[:n-1]); err != nil {
 			return
 		}
-		if sign {
-			i = -i
+		if v <= 0 {
+			err = fmt.Errorf("invalid component '%s'", s)
+			return
+		}
+		comp := Component{Kind: "L", Value: v}
+		if s[n-1] == 'F' {
+			comp.Kind = "C"
 		}
-		Z = complex(r, i)
+		return comp.Reactance(freq), true, nil
+	}
+	if t, ok := strings.CutPrefix(s, "j"); ok {
+		s, im = t, true
+	} else if t, ok := strings.CutSuffix(s, "j"); ok {
+		s, im = t, true
+	}
+	if strings.ContainsRune(s, 'j') {
+		err = fmt.Errorf("invalid number '%s'", sign+s)
+		return
 	}
+	v, err = ParseNumber(sign + s)
 	return
 }
 
@@ -141,3 +211,43 @@ func FormatNumber(v float64, n int) string {
 	}
 	return ""
 }
+
+// Inch in meter
+const Inch = 0.0254
+
+// FormatImperial formats a length (in meter) in feet and inches; the
+// fraction of an inch is rounded to a multiple of 1/denom (and reduced
+// to lowest terms).
+func FormatImperial(v float64, denom int) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	denom = max(1, denom)
+	n := int(math.Round(v / Inch * float64(denom)))
+	in, frac := n/denom, n%denom
+	ft := in / 12
+	in %= 12
+
+	var s string
+	if ft > 0 {
+		s = fmt.Sprintf("%d' ", ft)
+	}
+	if in > 0 || frac == 0 {
+		s += fmt.Sprintf("%d ", in)
+	}
+	if frac > 0 {
+		g := gcd(frac, denom)
+		s += fmt.Sprintf("%d/%d", frac/g, denom/g)
+	}
+	return sign + strings.TrimSpace(s) + `"`
+}
+
+// gcd returns the greatest common divisor of two positive integers
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
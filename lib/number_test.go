@@ -22,6 +22,7 @@ package lib
 
 import (
 	"math"
+	"math/cmplx"
 	"math/rand"
 	"testing"
 )
@@ -48,14 +49,117 @@ func TestNumbers(t *testing.T) {
 }
 
 func TestComplex(t *testing.T) {
-	s := []string{
-		"10", "23+j42", "-35.4-6.8*i",
+	for _, tc := range []struct {
+		s  string
+		z  complex128
+		ok bool
+	}{
+		{"10", 10, true},
+		{"-50", -50, true},
+		{"2k", 2000, true},
+		{"-j30", complex(0, -30), true},
+		{"-i30", complex(0, -30), true},
+		{"+j10", complex(0, 10), true},
+		{"j5", complex(0, 5), true},
+		{"23+j42", complex(23, 42), true},
+		{"-35.4-6.8*i", complex(-35.4, -6.8), true},
+		{"-35.4-6.8j", complex(-35.4, -6.8), true},
+		{"- 35.4 - j 6.8", complex(-35.4, -6.8), true},
+		{"j41.74+87.37", complex(87.37, 41.74), true},
+		{"1e-3-j5", complex(0.001, -5), true},
+		{"1k-j2.5e+1", complex(1000, -25), true},
+		{"48.5 - j3.2 Ω", complex(48.5, -3.2), true},
+		{"50ohm+j10ohm", complex(50, 10), true},
+		{"75 Ohms", 75, true},
+		{"50+100nH", 0, false},
+		{"", 0, false},
+		{"50+30", 0, false},
+		{"j5+j6", 0, false},
+		{"5-", 0, false},
+		{"5+6+j7", 0, false},
+		{"5j6", 0, false},
+	} {
+		z, err := ParseImpedance(tc.s)
+		if (err == nil) != tc.ok {
+			t.Errorf("'%s': unexpected error state: %v", tc.s, err)
+			continue
+		}
+		if tc.ok && cmplx.Abs(z-tc.z) > 1e-12 {
+			t.Errorf("'%s': got %v, expected %v", tc.s, z, tc.z)
+		}
 	}
-	for _, x := range s {
-		if k, err := ParseImpedance(x); err != nil {
-			t.Fatal(err)
-		} else {
-			t.Logf("%s -- %v", x, k)
+}
+
+func TestImpedanceAt(t *testing.T) {
+	f := 100e6
+	w := 2 * math.Pi * f
+	for _, tc := range []struct {
+		s  string
+		z  complex128
+		ok bool
+	}{
+		{"50", 50, true},
+		{"50+100nH", complex(50, w*100e-9), true},
+		{"50 Ω + 10pF", complex(50, -1/(w*10e-12)), true},
+		{"100nH+50", complex(50, w*100e-9), true},
+		{"22pF", complex(0, -1/(w*22e-12)), true},
+		{"50-100nH", 0, false},
+		{"50+0pF", 0, false},
+		{"50+j10+1nH", 0, false},
+	} {
+		z, err := ParseImpedanceAt(tc.s, f)
+		if (err == nil) != tc.ok {
+			t.Errorf("'%s': unexpected error state: %v", tc.s, err)
+			continue
+		}
+		if tc.ok && cmplx.Abs(z-tc.z) > 1e-9 {
+			t.Errorf("'%s': got %v, expected %v", tc.s, z, tc.z)
+		}
+	}
+}
+
+func TestImperial(t *testing.T) {
+	for _, tc := range []struct {
+		v     float64
+		denom int
+		s     string
+	}{
+		{0, 16, `0"`},
+		{Inch, 16, `1"`},
+		{12.5 * Inch, 16, `1' 1/2"`},
+		{-3.0625 * Inch, 16, `-3 1/16"`},
+		{40.37 * Inch, 16, `3' 4 3/8"`},
+		{0.375 * Inch, 16, `3/8"`},
+		{0.5 * Inch, 10, `1/2"`},
+		{2.6 * Inch, 10, `2 3/5"`},
+		{0.75 * Inch, 12, `3/4"`},
+	} {
+		if s := FormatImperial(tc.v, tc.denom); s != tc.s {
+			t.Errorf("%f: got '%s', expected '%s'", tc.v, s, tc.s)
+		}
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	for _, tc := range []struct {
+		s   string
+		num int
+		ok  bool
+	}{
+		{"0.2", 1, true},
+		{"0.2:0.3:0.01", 11, true},
+		{"1:2:0.5", 3, true},
+		{"0.3:0.2:0.01", 0, false},
+		{"0.2:0.3", 0, false},
+		{"0.2:0.3:0", 0, false},
+	} {
+		vals, err := ParseSteps(tc.s)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error state: %v", tc.s, err)
+			continue
+		}
+		if len(vals) != tc.num {
+			t.Errorf("%s: got %d values, expected %d", tc.s, len(vals), tc.num)
 		}
 	}
 }
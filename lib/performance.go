@@ -21,6 +21,7 @@
 package lib
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"math"
@@ -31,16 +32,125 @@ import (
 
 // Gain of antenna
 type Gain struct {
-	Max  float64 // maximum gain
-	Mean float64 // mean gain
-	SD   float64 // standard deviation of mean
+	Max  float64 `json:"max"`  // maximum gain
+	Mean float64 `json:"mean"` // mean gain
+	SD   float64 `json:"sd"`   // standard deviation of mean
+	MaxR float64 `json:"maxR"` // maximum gain (right-hand circular polarization)
+	MaxL float64 `json:"maxL"` // maximum gain (left-hand circular polarization)
+	MaxH float64 `json:"maxH"` // maximum gain (horizontal polarization)
+	MaxV float64 `json:"maxV"` // maximum gain (vertical polarization)
 }
 
 // Performance of antenna
 type Performance struct {
-	Gain *Gain       // antenna gain
-	Z    complex128  // antenna impedance
-	Rp   *RadPattern // radiation pattern
+	Gain   *Gain       // antenna gain
+	Z      complex128  // antenna impedance
+	Rp     *RadPattern // radiation pattern
+	Eff    float64     // radiation efficiency (NaN if not computed)
+	Iso    float64     // isotropy of radiation pattern (NaN if not computed)
+	BW     float64     // relative SWR bandwidth (NaN if not computed)
+	Ghoriz float64     // gain in reference direction (NaN if not computed)
+	Gaz    float64     // peak gain in azimuth plane at takeoff angle (NaN if not computed)
+	Gel    float64     // peak gain in elevation plane (NaN if not computed)
+	Gsys   float64     // gain including feedline losses (NaN if not computed)
+	Q      float64     // antenna Q (NaN if not computed)
+	Curv   float64     // total curvature of geometry (sum of bending angles)
+	Len    float64     // total wire length of geometry (driven element)
+
+	Currents []complex128 // segment currents (optional, see EvalCurrents)
+	FeedZ    []complex128 // impedances of all feed points (optional, primary first)
+}
+
+// performance data in JSON-encodable form
+type perfJSON struct {
+	Gain   *Gain    `json:"gain"`
+	Zr     float64  `json:"Zr"`
+	Zi     float64  `json:"Zi"`
+	Eff    *float64 `json:"eff,omitempty"`
+	Iso    *float64 `json:"iso,omitempty"`
+	BW     *float64 `json:"bw,omitempty"`
+	Ghoriz *float64 `json:"ghoriz,omitempty"`
+	Gaz    *float64 `json:"gaz,omitempty"`
+	Gel    *float64 `json:"gel,omitempty"`
+	Gsys   *float64 `json:"gsys,omitempty"`
+	Q      *float64 `json:"q,omitempty"`
+}
+
+// MarshalJSON encodes the performance (without radiation pattern)
+func (p *Performance) MarshalJSON() ([]byte, error) {
+	out := perfJSON{
+		Gain: p.Gain,
+		Zr:   real(p.Z),
+		Zi:   imag(p.Z),
+	}
+	if !math.IsNaN(p.Eff) {
+		out.Eff = &p.Eff
+	}
+	if !math.IsNaN(p.Iso) {
+		out.Iso = &p.Iso
+	}
+	if !math.IsNaN(p.BW) {
+		out.BW = &p.BW
+	}
+	if !math.IsNaN(p.Ghoriz) {
+		out.Ghoriz = &p.Ghoriz
+	}
+	if !math.IsNaN(p.Gaz) {
+		out.Gaz = &p.Gaz
+	}
+	if !math.IsNaN(p.Gel) {
+		out.Gel = &p.Gel
+	}
+	if !math.IsNaN(p.Gsys) {
+		out.Gsys = &p.Gsys
+	}
+	if !math.IsNaN(p.Q) {
+		out.Q = &p.Q
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a performance
+func (p *Performance) UnmarshalJSON(data []byte) error {
+	in := new(perfJSON)
+	if err := json.Unmarshal(data, in); err != nil {
+		return err
+	}
+	p.Gain = in.Gain
+	p.Z = complex(in.Zr, in.Zi)
+	p.Eff = math.NaN()
+	if in.Eff != nil {
+		p.Eff = *in.Eff
+	}
+	p.Iso = math.NaN()
+	if in.Iso != nil {
+		p.Iso = *in.Iso
+	}
+	p.BW = math.NaN()
+	if in.BW != nil {
+		p.BW = *in.BW
+	}
+	p.Ghoriz = math.NaN()
+	if in.Ghoriz != nil {
+		p.Ghoriz = *in.Ghoriz
+	}
+	p.Gaz = math.NaN()
+	if in.Gaz != nil {
+		p.Gaz = *in.Gaz
+	}
+	p.Gel = math.NaN()
+	if in.Gel != nil {
+		p.Gel = *in.Gel
+	}
+	p.Gsys = math.NaN()
+	if in.Gsys != nil {
+		p.Gsys = *in.Gsys
+	}
+	p.Q = math.NaN()
+	if in.Q != nil {
+		p.Q = *in.Q
+	}
+	return nil
 }
 
 // String returns a human-readable performance text
@@ -54,8 +164,7 @@ func (p *Performance) String() string {
 
 // SWR for (unmatched) antenna at source impedance
 func (p *Performance) SWR(Zs complex128) float64 {
-	g := cmplx.Abs((p.Z - Zs) / (p.Z + Zs))
-	return (1 + g) / (1 - g)
+	return SWR(p.Z, Zs)
 }
 
 // Loss (in dB) of transfering power from a source with impedance Zs to an
@@ -65,6 +174,13 @@ func (p *Performance) Loss(Zs complex128) float64 {
 	return 10 * math.Log10(4*s/Sqr(s+1))
 }
 
+// ConjLoss (in dB) of transfering power from a source with impedance Zs
+// to an antenna with impedance r.Z; no loss for a conjugate match.
+func (p *Performance) ConjLoss(Zs complex128) float64 {
+	g := cmplx.Abs(PowerReflection(p.Z, Zs))
+	return 10 * math.Log10(1-g*g)
+}
+
 // Power factor (in dB) of a matched antenna.
 func (p *Performance) Attenuation(Zs complex128) float64 {
 	// power factor (depends on phase shift between U and I)
@@ -96,6 +212,7 @@ type Comparator struct {
 	eval    []Evaluate
 	pos     int
 	spec    *Specification
+	penalty float64 // weight of curvature penalty (per radian)
 }
 
 // Create a new comparator for a target (and a possible target value).
@@ -103,6 +220,13 @@ type Comparator struct {
 // * Gmax: highest gain
 // * Gmean: best mean gain
 // * SD: smallest standard deviation
+// * Gmax_r: highest gain (right-hand circular polarization)
+// * Gmax_l: highest gain (left-hand circular polarization)
+// * Gmax_h: highest gain (horizontal polarization; external engine only)
+// * Gmax_v: highest gain (vertical polarization; external engine only)
+// * efficiency: highest radiation efficiency (requires Cfg.Sim.Efficiency)
+// * Z: best impedance match (SWR) to the source
+// * Zconj: best conjugate match to the (complex) source impedance
 // * custom: custom comparator (possibly plugin)
 func NewComparator(target string, spec *Specification) (cmp *Comparator, err error) {
 	cmp = new(Comparator)
@@ -152,6 +276,10 @@ func NewComparator(target string, spec *Specification) (cmp *Comparator, err err
 				if len(parts) > 1 {
 					args = parts[1]
 				}
+				if (parts[0] == "Gmax_h" || parts[0] == "Gmax_v") && !LinearPolarization() {
+					err = fmt.Errorf("target '%s' requires an external NEC2 engine", parts[0])
+					return
+				}
 				eval = cmp.value
 			}
 		} else {
@@ -168,11 +296,22 @@ func NewComparator(target string, spec *Specification) (cmp *Comparator, err err
 	return
 }
 
+// SetPenalty sets the weight of a penalty proportional to the total
+// curvature of a geometry; among similar performers the optimizer will
+// prefer smoother geometries.
+func (cmp *Comparator) SetPenalty(w float64) {
+	cmp.penalty = w
+}
+
 // Value returns the evaluated value from perfomance data.
 func (cmp *Comparator) Value(p *Performance) float64 {
 	target := cmp.targets[cmp.pos]
 	args := cmp.args[target]
-	return cmp.eval[cmp.pos](p, args, cmp.spec.Source.Impedance())
+	val := cmp.eval[cmp.pos](p, args, cmp.spec.Source.Impedance())
+	if cmp.penalty > 0 {
+		val -= cmp.penalty * p.Curv
+	}
+	return val
 }
 
 // standard evaluation
@@ -204,12 +343,42 @@ func (cmp *Comparator) value(p *Performance, args string, feedZ complex128) (val
 		} else {
 			log.Fatalf("invalid argument '%s' for 'Gmean'", args)
 		}
+	case "Gmax_r", "Gmax_l", "Gmax_h", "Gmax_v":
+		// opt for best directional pattern of a polarization
+		switch cmp.targets[cmp.pos] {
+		case "Gmax_r":
+			val = p.Gain.MaxR
+		case "Gmax_l":
+			val = p.Gain.MaxL
+		case "Gmax_h":
+			val = p.Gain.MaxH
+		case "Gmax_v":
+			val = p.Gain.MaxV
+		}
+		if args == "unmatched" {
+			val += p.Loss(feedZ)
+		} else if args == "matched" {
+			val += p.Attenuation(feedZ)
+		} else if args == "resonant" {
+			val += p.Resonance()
+		} else if len(args) > 0 && args != "raw" {
+			log.Fatalf("invalid argument '%s' for '%s'", args, cmp.targets[cmp.pos])
+		}
 	case "SD":
 		// opt for smaller SD
 		val = -p.Gain.SD
+	case "efficiency":
+		// opt for least wire losses (in dB)
+		if math.IsNaN(p.Eff) {
+			log.Fatal("efficiency not computed (enable in configuration)")
+		}
+		val = 10 * math.Log10(p.Eff)
 	case "Z":
 		// opt for matching impedance
 		val = p.Loss(feedZ)
+	case "Zconj":
+		// opt for conjugate matching impedance
+		val = p.ConjLoss(feedZ)
 	case "none":
 		val = 0
 	default:
@@ -218,24 +387,63 @@ func (cmp *Comparator) value(p *Performance, args string, feedZ complex128) (val
 	return
 }
 
+// Scalar returns true if the current target can be evaluated from the
+// scalar performance values (gain, impedance) alone; custom evaluators
+// (that might need the radiation pattern) and efficiency are not scalar.
+func (cmp *Comparator) Scalar() bool {
+	target := cmp.targets[cmp.pos]
+	if _, ok := CustomEvaluators[target]; ok {
+		return false
+	}
+	switch target {
+	case "Gmax", "Gmean", "Gmax_r", "Gmax_l", "Gmax_h", "Gmax_v", "SD", "Z", "Zconj", "none":
+		return true
+	}
+	return false
+}
+
+// Impedance returns true if the current target only depends on the
+// impedance (no radiation pattern needed to compare candidates).
+func (cmp *Comparator) Impedance() bool {
+	switch cmp.targets[cmp.pos] {
+	case "Z", "Zconj", "none":
+		return true
+	}
+	return false
+}
+
 // Compare antenna results based on the optimization target.
-// Returns 0 if same, -1 if worse, 1 if better
+// Returns 0 if same, -1 if worse, 1 if better. Target values within
+// Cfg.Sim.TieEps of each other are a tie; ties are broken in favor of
+// the smoother geometry (lower curvature) and then of the shorter wire.
+// Only if those are equal too (within the same tolerance) the result
+// is 0 (the optimizer keeps the old geometry).
 func (cmp *Comparator) Compare(curr, old *Performance) (sign int, val float64) {
 	// execute comparator
-	eps := 1e-9
+	eps := Cfg.Sim.TieEps
 	val = cmp.Value(curr)
-	chg := val - cmp.Value(old)
 
-	// calculate improvement
-	sign = 0
-	if chg > eps {
-		sign = 1
-	} else if chg < -eps {
-		sign = -1
+	// calculate improvement (with tie-breaking)
+	sign = compareEps(val, cmp.Value(old), eps)
+	if sign == 0 {
+		if sign = compareEps(old.Curv, curr.Curv, eps); sign == 0 {
+			sign = compareEps(old.Len, curr.Len, eps)
+		}
 	}
 	return
 }
 
+// compare two values with tolerance: returns 1 if a > b, -1 if a < b
+// and 0 if equal (within tolerance)
+func compareEps(a, b, eps float64) int {
+	if chg := a - b; chg > eps {
+		return 1
+	} else if chg < -eps {
+		return -1
+	}
+	return 0
+}
+
 // Target returns the current optimization target
 func (cmp *Comparator) Target() string {
 	return fmt.Sprintf("%s (%d/%d)", cmp.targets[cmp.pos], cmp.pos+1, len(cmp.targets))
@@ -253,9 +461,59 @@ func (cmp *Comparator) Next() (ok bool) {
 
 // RadPattern is the radiation pattern of an antenna
 type RadPattern struct {
-	NPhi, NTheta int
-	Min, Max     float64
-	Values       [][]float64
+	NPhi   int         `json:"nPhi"`   // number of Φ steps
+	NTheta int         `json:"nTheta"` // number of Θ steps
+	Min    float64     `json:"min"`    // min. gain
+	Max    float64     `json:"max"`    // max. gain
+	Values [][]float64 `json:"values"` // gain values [Θ][Φ]
+}
+
+// GainAt returns the gain in a direction (Θ and Φ in degrees, nearest
+// point of the pattern grid). If Φ is negative, the maximum gain over
+// all azimuths at the given Θ is returned.
+func (rp *RadPattern) GainAt(theta, phi float64) (g float64) {
+	thetaStep := 180. / float64(rp.NTheta-1)
+	iTheta := min(max(int(math.Round(theta/thetaStep)), 0), rp.NTheta-1)
+	row := rp.Values[iTheta]
+	if phi < 0 {
+		g = row[0]
+		for _, val := range row[1:] {
+			g = max(g, val)
+		}
+		return
+	}
+	phiStep := 360. / float64(rp.NPhi-1)
+	iPhi := int(math.Round(math.Mod(phi, 360) / phiStep))
+	return row[min(iPhi, rp.NPhi-1)]
+}
+
+// AzimuthCut returns the peak gain in the azimuth plane at a given
+// elevation (degrees above the horizon) and its azimuth Φ (degrees).
+func (rp *RadPattern) AzimuthCut(elev float64) (g, phi float64) {
+	thetaStep := 180. / float64(rp.NTheta-1)
+	iTheta := min(max(int(math.Round((90-elev)/thetaStep)), 0), rp.NTheta-1)
+	row := rp.Values[iTheta]
+	iMax := 0
+	for i, val := range row {
+		if val > row[iMax] {
+			iMax = i
+		}
+	}
+	return row[iMax], float64(iMax) * 360. / float64(rp.NPhi-1)
+}
+
+// ElevationCut returns the peak gain in the elevation plane through the
+// azimuth Φ (degrees); the plane includes the opposite azimuth Φ+180°.
+func (rp *RadPattern) ElevationCut(phi float64) (g float64) {
+	phiStep := 360. / float64(rp.NPhi-1)
+	g = math.Inf(-1)
+	for _, p := range []float64{phi, phi + 180} {
+		iPhi := min(int(math.Round(math.Mod(p, 360)/phiStep)), rp.NPhi-1)
+		for _, row := range rp.Values {
+			g = max(g, row[iPhi])
+		}
+	}
+	return
 }
 
 // Spherical is a metric for the isotropicity of a radition pattern.
@@ -41,6 +41,27 @@ func TestLoss(t *testing.T) {
 	}
 }
 
+func TestConjLoss(t *testing.T) {
+	Zs := complex(25, 40)
+	r := &Performance{Z: cmplx.Conj(Zs)}
+	if f := r.ConjLoss(Zs); math.Abs(f) > 1e-12 {
+		t.Errorf("conjugate match: loss=%f", f)
+	}
+	// Z target prefers Z=Zs, Zconj prefers Z=Zs*
+	if r.Loss(Zs) >= 0 {
+		t.Error("Z target: no loss for conjugate match")
+	}
+	r.Z = Zs
+	if f := r.ConjLoss(Zs); f >= -1 {
+		t.Errorf("Zconj target: loss=%f for Z=Zs", f)
+	}
+	// real source impedance: same loss for both targets
+	r.Z = complex(108, 74)
+	if f, g := r.ConjLoss(50), r.Loss(50); math.Abs(f-g) > 1e-12 {
+		t.Errorf("real source: %f != %f", f, g)
+	}
+}
+
 func TestSWR(t *testing.T) {
 	Zs := complex(50, 0)
 	r := new(Performance)
@@ -121,3 +142,105 @@ func TestEval2(t *testing.T) {
 		t.Logf("k=%f, a=%f", k, a)
 	}
 }
+
+func TestPenalty(t *testing.T) {
+	spec := &Specification{Source: Source{Z: Impedance{50, 0}}}
+	cmp, err := NewComparator("Gmax", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jagged := &Performance{Gain: &Gain{Max: 3.01}, Curv: 2}
+	smooth := &Performance{Gain: &Gain{Max: 3.0}, Curv: 0.5}
+	if sign, _ := cmp.Compare(jagged, smooth); sign != 1 {
+		t.Fatal("unpenalized: jagged geometry should be better")
+	}
+	cmp.SetPenalty(0.1)
+	if sign, _ := cmp.Compare(jagged, smooth); sign != -1 {
+		t.Fatal("penalized: smooth geometry should be better")
+	}
+}
+
+func TestLinearPolarization(t *testing.T) {
+	defer func(c Simulation) { Cfg.Sim = &c }(*Cfg.Sim)
+	spec := &Specification{Source: Source{Z: Impedance{50, 0}}}
+	Cfg.Sim.Engine = "necpp"
+	if _, err := NewComparator("Gmax_h", spec); err == nil {
+		t.Fatal("expected error for NEC2 library")
+	}
+	Cfg.Sim.Engine = "nec2c"
+	cmp, err := NewComparator("Gmax_v", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vert := &Performance{Gain: &Gain{Max: 3, MaxV: 2, MaxH: -1}}
+	hor := &Performance{Gain: &Gain{Max: 4, MaxV: -1, MaxH: 3}}
+	if sign, _ := cmp.Compare(vert, hor); sign != 1 {
+		t.Fatal("vertical polarization should be better")
+	}
+}
+
+func TestTieBreak(t *testing.T) {
+	spec := &Specification{Source: Source{Z: Impedance{50, 0}}}
+	cmp, err := NewComparator("Gmax", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gain := &Gain{Max: 3}
+	for _, tc := range []struct {
+		curr, old *Performance
+		sign      int
+	}{
+		{&Performance{Gain: gain, Curv: 1, Len: 1}, &Performance{Gain: gain, Curv: 2, Len: 1}, 1},
+		{&Performance{Gain: gain, Curv: 2, Len: 1}, &Performance{Gain: gain, Curv: 1, Len: 0.5}, -1},
+		{&Performance{Gain: gain, Curv: 1, Len: 0.5}, &Performance{Gain: gain, Curv: 1, Len: 1}, 1},
+		{&Performance{Gain: &Gain{Max: 3 + 1e-12}, Curv: 1, Len: 1}, &Performance{Gain: gain, Curv: 1, Len: 1}, 0},
+	} {
+		if sign, _ := cmp.Compare(tc.curr, tc.old); sign != tc.sign {
+			t.Errorf("%v vs %v: got %d, expected %d", tc.curr, tc.old, sign, tc.sign)
+		}
+	}
+}
+
+func TestGainAt(t *testing.T) {
+	// 3x5 grid: Θ = 0,90,180; Φ = 0,90,180,270,360
+	rp := &RadPattern{NTheta: 3, NPhi: 5, Values: [][]float64{
+		{0, 0, 0, 0, 0},
+		{1, 4, 2, -1, 1},
+		{-5, -5, -5, -5, -5},
+	}}
+	for _, tc := range []struct {
+		theta, phi, g float64
+	}{
+		{90, -1, 4},
+		{90, 180, 2},
+		{88, 268, -1},
+		{180, 0, -5},
+	} {
+		if g := rp.GainAt(tc.theta, tc.phi); g != tc.g {
+			t.Errorf("(%g,%g): got %g, expected %g", tc.theta, tc.phi, g, tc.g)
+		}
+	}
+}
+
+func TestPlaneCuts(t *testing.T) {
+	// 5x5 grid: Θ = 0,45,90,135,180; Φ = 0,90,180,270,360
+	rp := &RadPattern{NTheta: 5, NPhi: 5, Values: [][]float64{
+		{0, 0, 0, 0, 0},
+		{1, 2, 6, 2, 1},
+		{3, 5, 4, 1, 3},
+		{-1, -1, -1, -1, -1},
+		{-5, -5, -5, -5, -5},
+	}}
+	if g, phi := rp.AzimuthCut(0); g != 5 || phi != 90 {
+		t.Errorf("azimuth cut (horizon): got %g at %g°", g, phi)
+	}
+	if g, phi := rp.AzimuthCut(40); g != 6 || phi != 180 {
+		t.Errorf("azimuth cut (45°): got %g at %g°", g, phi)
+	}
+	if g := rp.ElevationCut(0); g != 6 {
+		t.Errorf("elevation cut (0°/180°): got %g", g)
+	}
+	if g := rp.ElevationCut(270); g != 5 {
+		t.Errorf("elevation cut (270°/90°): got %g", g)
+	}
+}
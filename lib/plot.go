@@ -21,12 +21,16 @@
 package lib
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"image/color"
 	"io"
 	"math"
 	"slices"
+	"strings"
+	"sync"
 
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/palette/moreland"
@@ -38,14 +42,30 @@ import (
 // list of plot targets
 var PlotValues = []string{
 	// performance value
-	"Gmax",  // maximum gain
-	"Gmean", // mean gain
-	"SD",    // standard deviation
-	"Zr",    // Resistance (Impedance)
-	"Zi",    // Reactance (Impedance)
+	"Gmax",   // maximum gain
+	"Gmean",  // mean gain
+	"SD",     // standard deviation
+	"Zr",     // Resistance (Impedance)
+	"Zi",     // Reactance (Impedance)
+	"Eff",    // radiation efficiency
+	"Iso",    // isotropy of radiation pattern
+	"BW",     // relative SWR bandwidth
+	"Ghoriz", // gain in reference direction (horizon)
+	"Gaz",    // peak gain in azimuth plane (takeoff angle)
+	"Gel",    // peak gain in elevation plane
+	"Gsys",   // gain including feedline losses
+	"Q",      // antenna Q
+
+	// antenna size (from model file)
+	"SizeX",   // extent of bounding box (x)
+	"SizeY",   // extent of bounding box (y)
+	"SizeZ",   // extent of bounding box (z)
+	"WireLen", // total wire length
+	"Extent",  // largest extent of bounding box
 
 	// derived performance
 	"Geff",   // maximum gain of matched antenna
+	"GBW",    // gain-bandwidth product
 	"Loss",   // loss due to impedance mismatch
 	"PwrFac", // loss/inefficiency due to phase shift between U and I
 }
@@ -55,6 +75,44 @@ var PlotSpecial = []string{
 	"Smith",
 }
 
+// cache of parsed plot expressions
+var (
+	exprCache = make(map[string]*Expr)
+	exprLock  sync.Mutex
+)
+
+// PlotExpr returns a parsed expression for a custom plot target (like
+// "Gmax - 10*Loss"). All identifiers in the expression must be plot
+// values or parameters ('k', 'param').
+func PlotExpr(target string) (e *Expr, err error) {
+	exprLock.Lock()
+	defer exprLock.Unlock()
+	var ok bool
+	if e, ok = exprCache[target]; ok {
+		return
+	}
+	if e, err = ParseExpr(target); err != nil {
+		return
+	}
+	for _, v := range e.Vars() {
+		if v != "k" && v != "param" && !slices.Contains(PlotValues, v) {
+			err = fmt.Errorf("expression '%s': unknown identifier '%s'", target, v)
+			return
+		}
+	}
+	exprCache[target] = e
+	return
+}
+
+// check if target is a plot value (or a valid custom expression)
+func isPlotValue(target string) bool {
+	if slices.Contains(PlotValues, target) {
+		return true
+	}
+	_, err := PlotExpr(target)
+	return err == nil
+}
+
 //----------------------------------------------------------------------
 
 type PlotSet struct {
@@ -106,8 +164,9 @@ const NumPlots = 15
 
 // Selection of plot parameters
 type Selection struct {
-	Target string             // Parameter (Gmax,Gmean,Zr,Zi)
-	Sets   [NumPlots]*PlotSet // list of PlotSets selected
+	Target  string             // Parameter (Gmax,Gmean,Zr,Zi)
+	Target2 string             // secondary parameter (right Y axis; optional)
+	Sets    [NumPlots]*PlotSet // list of PlotSets selected
 }
 
 // NewSelection for given target
@@ -117,6 +176,18 @@ func NewSelection(target string) *Selection {
 	}
 }
 
+// Clone returns a deep copy of the selection
+func (s *Selection) Clone() *Selection {
+	c := *s
+	for i, ps := range s.Sets {
+		if ps != nil {
+			cps := *ps
+			c.Sets[i] = &cps
+		}
+	}
+	return &c
+}
+
 //----------------------------------------------------------------------
 
 // Pre-defined colors for plotting
@@ -159,12 +230,13 @@ func Plotter(db *Database, sel *Selection, format string) (out map[string]string
 				ps.Kidx == -1 && ps.Pidx == -1)
 		}
 	}
-	if heatmap && !slices.Contains(PlotValues, sel.Target) {
+	if heatmap && !isPlotValue(sel.Target) {
 		// heatmap not possible
 		heatmap = false
 	}
 	// create plot
 	var p *plot.Plot
+	var sec *secondary
 	out = make(map[string]string)
 	if heatmap {
 		p, err = plotHeatmap(db, sel, idx)
@@ -174,14 +246,19 @@ func Plotter(db *Database, sel *Selection, format string) (out map[string]string
 		}
 		p.Legend = plot.NewLegend()
 	} else {
-		p, err = plotGraph(db, sel)
+		p, sec, err = plotGraph(db, sel)
 	}
 	if err != nil {
 		return
 	}
 	// create plot output
 	var wrt io.WriterTo
-	if wrt, err = p.WriterTo(18*vg.Centimeter, 18*vg.Centimeter, format); err != nil {
+	if sec != nil {
+		wrt, err = sec.writerTo(p, 18*vg.Centimeter, 18*vg.Centimeter, format)
+	} else {
+		wrt, err = p.WriterTo(18*vg.Centimeter, 18*vg.Centimeter, format)
+	}
+	if err != nil {
 		return
 	}
 	buf := new(bytes.Buffer)
@@ -193,7 +270,7 @@ func Plotter(db *Database, sel *Selection, format string) (out map[string]string
 }
 
 // Simple graph plot (2D with lines)
-func plotGraph(db *Database, sel *Selection) (*plot.Plot, error) {
+func plotGraph(db *Database, sel *Selection) (p *plot.Plot, sec *secondary, err error) {
 	// generate plot for value
 	if slices.Contains(PlotValues, sel.Target) {
 		return plotXY(db, sel)
@@ -201,14 +278,147 @@ func plotGraph(db *Database, sel *Selection) (*plot.Plot, error) {
 	// handle special plots
 	switch sel.Target {
 	case "Smith":
-		return plotSmith(db, sel)
+		p, err = plotSmith(db, sel)
+		return
+	}
+	// handle custom expression
+	if _, err = PlotExpr(sel.Target); err != nil {
+		return
 	}
-	// unknown plot target
-	return nil, fmt.Errorf("unhandled plot target '%s'", sel.Target)
+	return plotXY(db, sel)
 }
 
-// Simple X-Y-plot
-func plotXY(db *Database, sel *Selection) (p *plot.Plot, err error) {
+// Simple X-Y-plot (with optional secondary target on a right Y axis)
+func plotXY(db *Database, sel *Selection) (p *plot.Plot, sec *secondary, err error) {
+	// assemble table of values for target
+	var tbl *Table
+	if tbl, err = xyTable(db, sel, sel.Target); err != nil {
+		return
+	}
+	// plot table
+	p = plot.New()
+	p.Title.Text = tbl.Name
+	p.X.Label.Text = tbl.Dims[0]
+	p.Y.Label.Text = ""
+
+	numCols, numRows := len(tbl.Dims), len(tbl.Vals)
+	var graph *plotter.Line
+	for col := tbl.NumIdx; col < numCols; col++ {
+		var plt plot.Plotter
+		if plt, graph, err = xyGraph(tbl, col); err != nil {
+			return
+		}
+		p.Add(plt)
+		if graph != nil {
+			_, graph.LineStyle = PlotStyle(tbl.Refs[col])
+			p.Legend.Add(tbl.Dims[col], graph)
+		}
+	}
+
+	// handle optional value ticks/lines
+	Ytgt := math.NaN()
+	switch sel.Target {
+	case "Zr":
+		Ytgt = 50
+		p.Legend.Top = true
+	case "Zi":
+		Ytgt = 0
+	}
+	if !math.IsNaN(Ytgt) {
+		data := plotter.XYs{
+			plotter.XY{
+				X: TblValue[float64](tbl, 0, 0),
+				Y: Ytgt,
+			},
+			plotter.XY{
+				X: TblValue[float64](tbl, numRows-1, 0),
+				Y: Ytgt,
+			},
+		}
+		if graph, err = plotter.NewLine(data); err != nil {
+			return
+		}
+		graph.LineStyle = draw.LineStyle{
+			Width: vg.Points(1), Color: color.RGBA{0, 0, 0, 255},
+			Dashes: []vg.Length{vg.Points(5), vg.Points(3)}}
+		p.Add(graph)
+
+		// mark operating points (where graphs cross the reference line)
+		var marks plotter.XYLabels
+		for col := tbl.NumIdx; col < numCols; col++ {
+			_, ls := PlotStyle(tbl.Refs[col])
+			for _, x := range crossings(xyData(tbl, col), Ytgt) {
+				data := plotter.XYs{{X: x, Y: p.Y.Min}, {X: x, Y: p.Y.Max}}
+				if graph, err = plotter.NewLine(data); err != nil {
+					return
+				}
+				graph.LineStyle = draw.LineStyle{
+					Width: vg.Points(0.5), Color: ls.Color,
+					Dashes: []vg.Length{vg.Points(2), vg.Points(2)}}
+				p.Add(graph)
+				marks.XYs = append(marks.XYs, plotter.XY{X: x, Y: Ytgt})
+				marks.Labels = append(marks.Labels, fmt.Sprintf(" %.4g", x))
+			}
+		}
+		if len(marks.XYs) > 0 {
+			var lbls *plotter.Labels
+			if lbls, err = plotter.NewLabels(marks); err != nil {
+				return
+			}
+			p.Add(lbls)
+		}
+	}
+
+	// handle secondary target
+	if len(sel.Target2) == 0 || sel.Target2 == sel.Target {
+		return
+	}
+	if !slices.Contains(PlotValues, sel.Target2) {
+		if _, err = PlotExpr(sel.Target2); err != nil {
+			return
+		}
+	}
+	var tbl2 *Table
+	if tbl2, err = xyTable(db, sel, sel.Target2); err != nil {
+		return
+	}
+	sec = &secondary{p: plot.New()}
+	for col := tbl2.NumIdx; col < len(tbl2.Dims); col++ {
+		var plt plot.Plotter
+		if plt, graph, err = xyGraph(tbl2, col); err != nil {
+			return
+		}
+		sec.p.Add(plt)
+		sec.plts = append(sec.plts, plt)
+		if graph != nil {
+			// same color as primary graph, dotted line
+			_, graph.LineStyle = PlotStyle(tbl2.Refs[col])
+			graph.LineStyle.Dashes = styles[2].Dashes
+			p.Legend.Add(tbl2.Dims[col]+" ("+sel.Target2+")", graph)
+		}
+	}
+	p.Title.Text = sel.Target + " / " + sel.Target2
+
+	// share the X range between both axes
+	p.X.Min = min(p.X.Min, sec.p.X.Min)
+	p.X.Max = max(p.X.Max, sec.p.X.Max)
+	sec.p.X.Min, sec.p.X.Max = p.X.Min, p.X.Max
+	return
+}
+
+// PlotTable returns the table of target values for all selected plot sets
+// (the data of an X-Y plot).
+func PlotTable(db *Database, sel *Selection) (tbl *Table, err error) {
+	if !slices.Contains(PlotValues, sel.Target) {
+		if _, err = PlotExpr(sel.Target); err != nil {
+			return
+		}
+	}
+	return xyTable(db, sel, sel.Target)
+}
+
+// assemble a table of target values for all selected plot sets
+func xyTable(db *Database, sel *Selection, target string) (tbl *Table, err error) {
 	// collect data sets
 	data := make([]*Set, len(sel.Sets))
 	tags := make([]string, len(sel.Sets))
@@ -250,8 +460,8 @@ func plotXY(db *Database, sel *Selection) (p *plot.Plot, err error) {
 	}
 
 	// create new table
-	tbl := new(Table)
-	tbl.Name = sel.Target
+	tbl = new(Table)
+	tbl.Name = target
 
 	// assemble column header
 	tbl.Dims = make([]string, 0)
@@ -281,78 +491,116 @@ func plotXY(db *Database, sel *Selection) (p *plot.Plot, err error) {
 		}
 		for _, tag := range tagList {
 			pos := slices.Index(tags, tag)
-			val := data[pos].Value(idx, sel.Target)
+			val := data[pos].Value(idx, target)
 			valList = append(valList, val)
 		}
 		tbl.Vals = append(tbl.Vals, valList)
 	}
+	return
+}
 
-	// plot table
-	p = plot.New()
-	p.Title.Text = tbl.Name
-	p.X.Label.Text = tbl.Dims[0]
-	p.Y.Label.Text = ""
+// create graph for a table column: a single point is plotted as
+// scatter, multiple points as line (returned in 'line' for styling).
+func xyGraph(tbl *Table, col int) (plt plot.Plotter, line *plotter.Line, err error) {
+	data := xyData(tbl, col)
+	if len(data) == 1 {
+		plt, err = plotter.NewScatter(data)
+		return
+	}
+	if line, err = plotter.NewLine(data); err != nil {
+		return
+	}
+	plt = line
+	return
+}
 
-	numCols, numRows := len(tbl.Dims), len(tbl.Vals)
-	var graph *plotter.Line
-	for col := tbl.NumIdx; col < numCols; col++ {
-		// convert table data to plotter.Values
-		data := make(plotter.XYs, 0)
-		for row := range numRows {
-			val := TblValue[float64](tbl, row, col)
-			if math.IsNaN(val) {
-				continue
-			}
-			data = append(data, plotter.XY{
-				X: TblValue[float64](tbl, row, 0),
-				Y: val,
-			})
-		}
-		if len(data) == 1 {
-			var pnt *plotter.Scatter
-			if pnt, err = plotter.NewScatter(data); err != nil {
-				return
-			}
-			p.Add(pnt)
-		} else {
-			if graph, err = plotter.NewLine(data); err != nil {
-				return
-			}
-			_, graph.LineStyle = PlotStyle(tbl.Refs[col])
-			p.Add(graph)
-			p.Legend.Add(tbl.Dims[col], graph)
+// convert table column to plotter values (skipping undefined values)
+func xyData(tbl *Table, col int) (data plotter.XYs) {
+	data = make(plotter.XYs, 0)
+	for row := range len(tbl.Vals) {
+		val := TblValue[float64](tbl, row, col)
+		if math.IsNaN(val) {
+			continue
 		}
+		data = append(data, plotter.XY{
+			X: TblValue[float64](tbl, row, 0),
+			Y: val,
+		})
 	}
+	return
+}
 
-	// handle optional value ticks/lines
-	Ytgt := math.NaN()
-	switch sel.Target {
-	case "Zr":
-		Ytgt = 50
-		p.Legend.Top = true
-	case "Zi":
-		Ytgt = 0
+// crossings returns the (linear interpolated) X values where a graph
+// crosses the horizontal line at Y.
+func crossings(data plotter.XYs, y float64) (xs []float64) {
+	for i := 1; i < len(data); i++ {
+		p0, p1 := data[i-1], data[i]
+		d0, d1 := p0.Y-y, p1.Y-y
+		switch {
+		case d0 == 0:
+			xs = append(xs, p0.X)
+		case d0*d1 < 0:
+			xs = append(xs, p0.X+d0*(p1.X-p0.X)/(d0-d1))
+		}
 	}
-	if !math.IsNaN(Ytgt) {
-		data := plotter.XYs{
-			plotter.XY{
-				X: TblValue[float64](tbl, 0, 0),
-				Y: Ytgt,
-			},
-			plotter.XY{
-				X: TblValue[float64](tbl, numRows-1, 0),
-				Y: Ytgt,
-			},
+	// check last point
+	if n := len(data); n > 1 && data[n-1].Y == y {
+		xs = append(xs, data[n-1].X)
+	}
+	return
+}
+
+// secondary graphs (plotted against a right Y axis)
+type secondary struct {
+	p    *plot.Plot     // plot holding the axis ranges
+	plts []plot.Plotter // graphs of the secondary target
+}
+
+// writerTo draws the primary plot (with space reserved for the
+// right axis) and the secondary graphs with their own Y axis.
+func (sec *secondary) writerTo(p *plot.Plot, w, h vg.Length, format string) (io.WriterTo, error) {
+	cw, err := draw.NewFormattedCanvas(w, h, format)
+	if err != nil {
+		return nil, err
+	}
+	c := draw.New(cw)
+
+	// compute ticks and labels of the right axis
+	ax := sec.p.Y
+	ticks := ax.Tick.Marker.Ticks(ax.Min, ax.Max)
+	var width vg.Length
+	for _, t := range ticks {
+		if len(t.Label) > 0 {
+			width = max(width, ax.Tick.Label.Width(t.Label))
 		}
-		if graph, err = plotter.NewLine(data); err != nil {
-			return
+	}
+	width += ax.Tick.Length + ax.Padding + vg.Points(4)
+
+	// draw primary plot
+	pc := draw.Crop(c, 0, -width, 0, 0)
+	p.Draw(pc)
+
+	// draw secondary graphs in the data area of the primary plot
+	dc := p.DataCanvas(pc)
+	for _, plt := range sec.plts {
+		plt.Plot(dc, sec.p)
+	}
+	// draw right axis
+	x := dc.Max.X + ax.Padding
+	c.StrokeLine2(ax.LineStyle, x, dc.Min.Y, x, dc.Max.Y)
+	for _, t := range ticks {
+		y := dc.Y(ax.Norm(t.Value))
+		if t.IsMinor() {
+			c.StrokeLine2(ax.Tick.LineStyle, x, y, x+ax.Tick.Length/2, y)
+			continue
 		}
-		graph.LineStyle = draw.LineStyle{
-			Width: vg.Points(1), Color: color.RGBA{0, 0, 0, 255},
-			Dashes: []vg.Length{vg.Points(5), vg.Points(3)}}
-		p.Add(graph)
+		c.StrokeLine2(ax.Tick.LineStyle, x, y, x+ax.Tick.Length, y)
+		sty := ax.Tick.Label
+		sty.XAlign = draw.XLeft
+		sty.YAlign = draw.YCenter
+		c.FillText(sty, vg.Point{X: x + ax.Tick.Length + vg.Points(2), Y: y}, t.Label)
 	}
-	return
+	return cw, nil
 }
 
 //----------------------------------------------------------------------
@@ -451,11 +699,10 @@ func plotLegend(legend plot.Legend, width, height float64, format string) (out s
 	return
 }
 
-// plot Smith chart for selections
-func plotSmith(db *Database, sel *Selection) (p *plot.Plot, err error) {
-	// assemle Smith chart
-	sc := new(SmithChart)
-
+// SmithTable returns the table of feed point impedances (complex128) for
+// all selected plot sets (the data of a Smith chart). Only one parameter
+// may vary.
+func SmithTable(db *Database, sel *Selection) (tbl *Table, err error) {
 	// collect data sets
 	data := make([]*Set, len(sel.Sets))
 	tags := make([]string, len(sel.Sets))
@@ -501,7 +748,7 @@ func plotSmith(db *Database, sel *Selection) (p *plot.Plot, err error) {
 	}
 
 	// create new table
-	tbl := new(Table)
+	tbl = new(Table)
 	tbl.Name = sel.Target
 
 	// assemble column header
@@ -538,7 +785,18 @@ func plotSmith(db *Database, sel *Selection) (p *plot.Plot, err error) {
 		}
 		tbl.Vals = append(tbl.Vals, valList)
 	}
+	return
+}
 
+// plot Smith chart for selections
+func plotSmith(db *Database, sel *Selection) (p *plot.Plot, err error) {
+	// assemble table of impedances
+	var tbl *Table
+	if tbl, err = SmithTable(db, sel); err != nil {
+		return
+	}
+	// assemle Smith chart
+	sc := new(SmithChart)
 	sc.tracks = make([][]complex128, 0)
 	numCols, numRows := len(tbl.Dims), len(tbl.Vals)
 	for col := tbl.NumIdx; col < numCols; col++ {
@@ -564,3 +822,62 @@ func plotSmithRaw(sc *SmithChart) (p *plot.Plot, err error) {
 	p.Add(sc)
 	return
 }
+
+//----------------------------------------------------------------------
+// convergence plots (from JSON-lines step logs)
+//----------------------------------------------------------------------
+
+// ReadSteps reads the values of a metric (like "Gmax" or "Zr") over the
+// step index from a JSON-lines step log (written by "antgen -logfmt json").
+func ReadSteps(rdr io.Reader, metric string) (data plotter.XYs, err error) {
+	scanner := bufio.NewScanner(rdr)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+		rec := make(map[string]any)
+		if err = json.Unmarshal([]byte(text), &rec); err != nil {
+			err = fmt.Errorf("line %d: %w", line, err)
+			return
+		}
+		step, ok := rec["step"].(float64)
+		val, ok2 := rec[metric].(float64)
+		if !ok || !ok2 {
+			err = fmt.Errorf("line %d: missing 'step' or '%s'", line, metric)
+			return
+		}
+		data = append(data, plotter.XY{X: step, Y: val})
+	}
+	if err = scanner.Err(); err == nil && len(data) == 0 {
+		err = fmt.Errorf("no steps for '%s'", metric)
+	}
+	return
+}
+
+// PlotSteps renders the convergence graphs (metric over step index) of
+// multiple step logs into a single plot (one line per log).
+func PlotSteps(names []string, series []plotter.XYs, metric, format string) (out string, err error) {
+	p := plot.New()
+	p.Title.Text = metric
+	p.X.Label.Text = "step"
+	for i, data := range series {
+		var line *plotter.Line
+		if line, err = plotter.NewLine(data); err != nil {
+			return
+		}
+		_, line.LineStyle = PlotStyle(i)
+		p.Add(line)
+		p.Legend.Add(names[i], line)
+	}
+	var wrt io.WriterTo
+	if wrt, err = p.WriterTo(18*vg.Centimeter, 18*vg.Centimeter, format); err != nil {
+		return
+	}
+	buf := new(bytes.Buffer)
+	if _, err = wrt.WriteTo(buf); err != nil {
+		return
+	}
+	out = buf.String()
+	return
+}
@@ -0,0 +1,71 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+func TestCrossings(t *testing.T) {
+	data := plotter.XYs{{X: 0, Y: -2}, {X: 1, Y: 2}, {X: 2, Y: 3}, {X: 3, Y: 0}, {X: 4, Y: -1}}
+	xs := crossings(data, 0)
+	if len(xs) != 2 || xs[0] != 0.5 || xs[1] != 3 {
+		t.Fatalf("unexpected crossings: %v", xs)
+	}
+	if xs = crossings(data, 50); len(xs) != 0 {
+		t.Fatalf("unexpected crossings: %v", xs)
+	}
+}
+
+func TestReadSteps(t *testing.T) {
+	log := `{"step":2,"Gmax":5.1,"Zr":61.2,"pos":14,"dTheta":0.021}
+
+{"step":3,"Gmax":5.3,"Zr":58.0,"pos":-4,"dTheta":0,"scale":1.02}
+`
+	data, err := ReadSteps(strings.NewReader(log), "Gmax")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[1].X != 3 || data[1].Y != 5.3 {
+		t.Fatalf("unexpected data: %v", data)
+	}
+	if _, err = ReadSteps(strings.NewReader(log), "Eff"); err == nil {
+		t.Fatal("missing metric not detected")
+	}
+	if _, err = PlotSteps([]string{"log"}, []plotter.XYs{data}, "Gmax", "svg"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectionClone(t *testing.T) {
+	sel := NewSelection("Gmax")
+	sel.Sets[0] = NewPlotSet("2m/bend2d")
+	c := sel.Clone()
+	c.Target = "Zr"
+	c.Sets[0].Kidx = 1
+	c.Sets[1] = NewPlotSet("2m/stretch")
+	if sel.Target != "Gmax" || sel.Sets[0].Kidx != -1 || sel.Sets[1] != nil {
+		t.Fatalf("selection changed by clone: %+v", *sel)
+	}
+}
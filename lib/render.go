@@ -21,7 +21,9 @@
 package lib
 
 import (
+	"fmt"
 	"image/color"
+	"math"
 )
 
 // Color definitions for drawing
@@ -37,6 +39,72 @@ var (
 	ClrCyan  = &color.RGBA{0, 255, 255, 0}
 )
 
+// Theme defines the colors used by a canvas
+type Theme struct {
+	Background *color.RGBA // background
+	Wire       *color.RGBA // wire segments
+	Excitation *color.RGBA // excited (feed point) segment
+	Text       *color.RGBA // messages and geometry info
+	Perf       *color.RGBA // performance at center frequency
+	Band       *color.RGBA // performance at band edges, hints
+	Marker     *color.RGBA // marker of last change
+	KeepOut    *color.RGBA // keep-out regions
+	Axis       *color.RGBA // XY axes (scale option)
+}
+
+// Pre-defined themes
+var (
+	// ThemeDefault (light background)
+	ThemeDefault = Theme{
+		Background: ClrWhite,
+		Wire:       ClrBlue,
+		Excitation: ClrRed,
+		Text:       ClrBlack,
+		Perf:       ClrRed,
+		Band:       ClrPink,
+		Marker:     ClrGreen,
+		KeepOut:    ClrGray,
+		Axis:       &color.RGBA{208, 208, 208, 0},
+	}
+	// ThemeDark (dark background, e.g. for dark-mode documents)
+	ThemeDark = Theme{
+		Background: &color.RGBA{32, 32, 32, 0},
+		Wire:       ClrCyan,
+		Excitation: &color.RGBA{255, 96, 96, 0},
+		Text:       &color.RGBA{224, 224, 224, 0},
+		Perf:       &color.RGBA{255, 160, 96, 0},
+		Band:       &color.RGBA{255, 128, 255, 0},
+		Marker:     ClrGreen,
+		KeepOut:    ClrGray,
+		Axis:       &color.RGBA{80, 80, 80, 0},
+	}
+	// ThemePrint (black and white)
+	ThemePrint = Theme{
+		Background: ClrWhite,
+		Wire:       ClrBlack,
+		Excitation: ClrGray,
+		Text:       ClrBlack,
+		Perf:       ClrBlack,
+		Band:       ClrGray,
+		Marker:     ClrGray,
+		KeepOut:    ClrGray,
+		Axis:       &color.RGBA{192, 192, 192, 0},
+	}
+)
+
+// GetTheme returns a pre-defined theme by name
+func GetTheme(name string) (Theme, error) {
+	switch name {
+	case "", "default":
+		return ThemeDefault, nil
+	case "dark":
+		return ThemeDark, nil
+	case "print":
+		return ThemePrint, nil
+	}
+	return ThemeDefault, fmt.Errorf("unknown theme '%s'", name)
+}
+
 // Callback on key press
 type Action func(ant *Antenna, key rune, step int) bool
 
@@ -50,6 +118,12 @@ type Canvas interface {
 
 	SetHint(m string)
 
+	// SetTheme sets the colors used for rendering
+	SetTheme(t Theme)
+
+	// SetScale enables drawing of a scale bar and XY axes
+	SetScale(on bool)
+
 	// Circle primitive
 	Circle(x, y, r, w float64, clrBorder, clrFill *color.RGBA)
 
@@ -77,7 +151,60 @@ func GetCanvas(kind string, width, height int, side float64) (c Canvas, err erro
 	return
 }
 
-// GetCanvasFromCfg returns a canvas from configuration
-func GetCanvasFromCfg(cfg *RenderConfig, side float64) (Canvas, error) {
-	return GetCanvas(cfg.Canvas, cfg.Width, cfg.Height, side)
+// GetCanvasFromCfg returns a canvas (with theme) from configuration
+func GetCanvasFromCfg(cfg *RenderConfig, side float64) (c Canvas, err error) {
+	var theme Theme
+	if theme, err = GetTheme(cfg.Theme); err != nil {
+		return
+	}
+	if c, err = GetCanvas(cfg.Canvas, cfg.Width, cfg.Height, side); err == nil && c != nil {
+		c.SetTheme(theme)
+		c.SetScale(cfg.Scale)
+	}
+	return
+}
+
+// ScaleLength returns a "nice" length (1, 2 or 5 times a power of ten) not
+// larger than the given maximum length (in meter) and its label.
+func ScaleLength(maxLen float64) (l float64, label string) {
+	if maxLen <= 0 {
+		return 0, ""
+	}
+	p := math.Pow(10, math.Floor(math.Log10(maxLen)))
+	l = p
+	for _, f := range []float64{5, 2} {
+		if f*p <= maxLen {
+			l = f * p
+			break
+		}
+	}
+	switch {
+	case l >= 1:
+		label = fmt.Sprintf("%g m", l)
+	case l >= 0.01:
+		label = fmt.Sprintf("%g cm", math.Round(l*1e4)/100)
+	default:
+		label = fmt.Sprintf("%g mm", math.Round(l*1e6)/1000)
+	}
+	return
+}
+
+// drawScale draws light XY axes through the origin (spanning the bounding
+// box of the antenna) and a labeled scale bar at the given y position
+// (left-aligned to the bounding box) on a canvas.
+func drawScale(c Canvas, box *BoundingBox, y, fs float64, t Theme) {
+	pad := fs / 2
+	w := fs / 20
+	c.Line(box.Xmin-pad, 0, box.Xmax+pad, 0, w, t.Axis)
+	c.Line(0, min(box.Ymin, 0)-pad, 0, max(box.Ymax, 0)+pad, w, t.Axis)
+
+	l, label := ScaleLength((box.Xmax - box.Xmin) / 3)
+	if l == 0 {
+		return
+	}
+	x0, x1 := box.Xmin, box.Xmin+l
+	c.Line(x0, y, x1, y, w*2, t.Text)
+	c.Line(x0, y-fs/4, x0, y+fs/4, w, t.Text)
+	c.Line(x1, y-fs/4, x1, y+fs/4, w, t.Text)
+	c.Text((x0+x1)/2, y-fs/3, fs/3, label, t.Text)
 }
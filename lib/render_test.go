@@ -0,0 +1,71 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScaleLength(t *testing.T) {
+	for _, tc := range []struct {
+		max   float64
+		l     float64
+		label string
+	}{
+		{0.34, 0.2, "20 cm"},
+		{1.2, 1, "1 m"},
+		{0.0071, 0.005, "5 mm"},
+		{0.1, 0.1, "10 cm"},
+		{27, 20, "20 m"},
+	} {
+		l, label := ScaleLength(tc.max)
+		if l != tc.l || label != tc.label {
+			t.Errorf("ScaleLength(%g) = %g '%s', expected %g '%s'", tc.max, l, label, tc.l, tc.label)
+		}
+	}
+}
+
+func TestSVGSheet(t *testing.T) {
+	var cells [][]byte
+	var labels []string
+	for i := range 5 {
+		ant := NewAntenna("test")
+		ant.Add(NewLine(NewVec3(-0.1, 0, 0), NewVec3(0.1, 0.05*float64(i), 0)))
+		c, err := NewSVGCanvas(0, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Show(ant, -1, "")
+		cells = append(cells, c.Bytes())
+		labels = append(labels, string(rune('a'+i)))
+	}
+	out := string(SVGSheet(cells, labels, 3, 200))
+	if !strings.Contains(out, `width="600"`) || !strings.Contains(out, `height="440"`) {
+		t.Errorf("unexpected sheet size: %.200s", out)
+	}
+	if n := strings.Count(out, "<image "); n != 5 {
+		t.Errorf("%d cells in sheet", n)
+	}
+	if !strings.Contains(out, ">e</text>") {
+		t.Error("missing label")
+	}
+}
@@ -0,0 +1,198 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Perturbation of the nominal specification and geometry for a robustness
+// check of an antenna: a design that is only optimal at exactly the design
+// frequency and the nominal wire is hard to build.
+type Perturbation struct {
+	Freq   float64 // relative frequency offset (±)
+	Wire   float64 // relative change of wire diameter (±)
+	Jitter float64 // std. deviation of bending angles (degree)
+	Trials int     // number of geometries with jittered bending angles
+}
+
+// DefaultPerturbation for robustness checks
+var DefaultPerturbation = Perturbation{
+	Freq:   0.01,
+	Wire:   0.1,
+	Jitter: 1,
+	Trials: 5,
+}
+
+// ParsePerturbation converts a perturbation spec
+// "freq=<rel>,wire=<rel>,jitter=<deg>,trials=<n>" into a Perturbation.
+// Missing entries keep the default values; the spec "default" uses the
+// default perturbation.
+func ParsePerturbation(s string) (p Perturbation, err error) {
+	p = DefaultPerturbation
+	if s == "default" {
+		return
+	}
+	for _, e := range strings.Split(s, ",") {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			err = fmt.Errorf("perturbation: invalid entry '%s'", e)
+			return
+		}
+		switch kv[0] {
+		case "freq":
+			p.Freq, err = strconv.ParseFloat(kv[1], 64)
+		case "wire":
+			p.Wire, err = strconv.ParseFloat(kv[1], 64)
+		case "jitter":
+			p.Jitter, err = strconv.ParseFloat(kv[1], 64)
+		case "trials":
+			p.Trials, err = strconv.Atoi(kv[1])
+		default:
+			err = fmt.Errorf("perturbation: unknown parameter '%s'", kv[0])
+		}
+		if err != nil {
+			return
+		}
+	}
+	if p.Freq < 0 || p.Freq >= 1 || p.Wire < 0 || p.Wire >= 1 || p.Jitter < 0 || p.Trials < 0 {
+		err = errors.New("perturbation: value out of range")
+	}
+	return
+}
+
+// Sims returns the number of simulations for a robustness check
+func (p Perturbation) Sims() int {
+	return 5 + p.Trials
+}
+
+// RobustCheck is the performance of a perturbed antenna
+type RobustCheck struct {
+	Name string  // perturbation
+	Gmax float64 // maximum gain
+	SWR  float64 // SWR (relative to source impedance)
+}
+
+// Robustness of an antenna design under perturbations
+type Robustness struct {
+	Gmax   float64        // nominal maximum gain
+	SWR    float64        // nominal SWR
+	Checks []*RobustCheck // perturbed performance
+	DGmax  float64        // worst degradation of maximum gain (dB)
+	DSWR   float64        // worst increase of SWR
+	Score  float64        // robustness score (1: perfectly robust)
+}
+
+// RobustScore computes the robustness score from the worst gain
+// degradation (dB) and SWR increase: 1/(1+ΔGmax+ΔSWR). A design that loses
+// 1dB of gain (or gains one unit of SWR) under perturbation scores 0.5.
+func RobustScore(dGmax, dSWR float64) float64 {
+	return 1 / (1 + max(dGmax, 0) + max(dSWR, 0))
+}
+
+// EvalRobustness re-evaluates an antenna (geometry 'nodes' built for the
+// specification) at perturbed frequencies (±), wire diameters (±) and with
+// randomly jittered bending angles. Only the scalar performance (gain,
+// impedance) is computed for each check.
+func EvalRobustness(kind string, spec *Specification, nodes []*Node, p Perturbation, rnd *rand.Rand) (r *Robustness, err error) {
+	zs := spec.Source.Impedance()
+	scalar := &Proxy{Accept: func(*Performance) bool { return false }}
+	eval := func(name string, s *Specification, nodes []*Node, freq float64) (chk *RobustCheck, err error) {
+		ant := BuildAntenna(kind, s, nodes)
+		if _, err = ant.EvalStaged(int64(freq), s.Wire, s.Ground, scalar); err != nil {
+			return
+		}
+		chk = &RobustCheck{
+			Name: name,
+			Gmax: ant.Perf.Gain.Max,
+			SWR:  SWR(ant.Perf.Z, zs),
+		}
+		return
+	}
+	// nominal performance
+	freq := float64(spec.Source.Freq)
+	var chk *RobustCheck
+	if chk, err = eval("nominal", spec, nodes, freq); err != nil {
+		return
+	}
+	r = &Robustness{
+		Gmax: chk.Gmax,
+		SWR:  chk.SWR,
+	}
+	add := func(name string, s *Specification, nodes []*Node, freq float64) error {
+		chk, err := eval(name, s, nodes, freq)
+		if err != nil {
+			return err
+		}
+		r.Checks = append(r.Checks, chk)
+		r.DGmax = max(r.DGmax, r.Gmax-chk.Gmax)
+		if d := chk.SWR - r.SWR; !math.IsNaN(d) {
+			// SWR not defined for a total mismatch
+			r.DSWR = max(r.DSWR, d)
+		}
+		return nil
+	}
+	// perturbed frequency and wire diameter
+	signs := []float64{-1, 1}
+	for _, sign := range signs {
+		name := fmt.Sprintf("freq%+g%%", sign*100*p.Freq)
+		if err = add(name, spec, nodes, freq*(1+sign*p.Freq)); err != nil {
+			return
+		}
+	}
+	for _, sign := range signs {
+		s := *spec
+		s.Wire.Diameter *= 1 + sign*p.Wire
+		name := fmt.Sprintf("wire%+g%%", sign*100*p.Wire)
+		if err = add(name, &s, nodes, freq); err != nil {
+			return
+		}
+	}
+	// jittered bending angles
+	sigma := p.Jitter * math.Pi / 180
+	for i := range p.Trials {
+		jit := CloneNodes(nodes)
+		for _, n := range jit {
+			n.Theta += sigma * rnd.NormFloat64()
+		}
+		name := fmt.Sprintf("jitter#%d", i+1)
+		if err = add(name, spec, jit, freq); err != nil {
+			return
+		}
+	}
+	r.Score = RobustScore(r.DGmax, r.DSWR)
+	return
+}
+
+// Info returns a list of comment lines describing the robustness
+func (r *Robustness) Info(p Perturbation) (lines []string) {
+	lines = append(lines, fmt.Sprintf("Robustness: score=%.3f, ΔGmax=%.3fdB, ΔSWR=%.3f (freq=±%g%%, wire=±%g%%, jitter=%g°×%d)",
+		r.Score, r.DGmax, r.DSWR, 100*p.Freq, 100*p.Wire, p.Jitter, p.Trials))
+	for _, chk := range r.Checks {
+		lines = append(lines, fmt.Sprintf("Robustness: %s: Gmax=%.3fdB, SWR=%.3f", chk.Name, chk.Gmax, chk.SWR))
+	}
+	return
+}
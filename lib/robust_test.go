@@ -0,0 +1,69 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParsePerturbation(t *testing.T) {
+	p, err := ParsePerturbation("default")
+	if err != nil || p != DefaultPerturbation {
+		t.Fatalf("default: %v (%v)", p, err)
+	}
+	if p, err = ParsePerturbation("wire=0.05,trials=3"); err != nil {
+		t.Fatal(err)
+	}
+	if p.Wire != 0.05 || p.Trials != 3 || p.Freq != DefaultPerturbation.Freq || p.Sims() != 8 {
+		t.Errorf("unexpected perturbation %+v", p)
+	}
+	for _, s := range []string{"", "freq", "freq=x", "speed=1", "wire=1.5", "trials=-1"} {
+		if _, err = ParsePerturbation(s); err == nil {
+			t.Errorf("'%s' accepted", s)
+		}
+	}
+}
+
+func TestRobustness(t *testing.T) {
+	if s := RobustScore(1, 0); s != 0.5 {
+		t.Errorf("score %g for 1dB degradation", s)
+	}
+	useIdealDipole(t)
+	spec := testSpec()
+	spec.Source.Z = Impedance{R: 50}
+	nodes := make([]*Node, 2)
+	nodes[0] = NewNode(0.01, 0, 0)
+	nodes[1] = NewNode(0.24*spec.Source.Lambda(), 0.3, 0)
+
+	// no perturbation: all checks match the nominal performance
+	p := Perturbation{Trials: 2}
+	r, err := EvalRobustness("test", spec, nodes, p, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Checks) != p.Sims()-1 || r.Score != 1 || r.DGmax != 0 || r.DSWR != 0 {
+		t.Errorf("unexpected robustness %+v", *r)
+	}
+	if len(r.Info(p)) != len(r.Checks)+1 {
+		t.Error("missing info lines")
+	}
+}
@@ -0,0 +1,118 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"math"
+)
+
+// Recommended NEC2 bounds for wire segments (see NEC2 user guide); the
+// lower bounds are the configured geometry constraints (segMinLambda,
+// segMinWire).
+const (
+	SegMaxLambda = 0.1 // segment length below 0.1λ
+	SegMaxRatio  = 5   // length ratio of adjacent segments
+)
+
+// SegmentQuality describes the lengths of the NEC2 segments of an antenna
+// (the wires are subdivided into segments for simulation).
+type SegmentQuality struct {
+	Num    int     // number of NEC2 segments
+	Min    float64 // shortest segment
+	Max    float64 // longest segment
+	Mean   float64 // mean segment length
+	Ratio  float64 // worst length ratio of adjacent segments
+	Lambda float64 // wavelength
+	Dia    float64 // wire diameter
+}
+
+// number of NEC2 segments for a wire (as used in simulations)
+func (a *Antenna) numSegs(seg *Line) int {
+	return max(1, min(100, int(seg.Length()/(a.Lambda/100))))
+}
+
+// SegmentQuality returns statistics on the segment lengths of the antenna
+// at the current wavelength. Adjacent segments are segments within a wire
+// and wires sharing an endpoint (e.g. after FixGeometry).
+func (a *Antenna) SegmentQuality() (q *SegmentQuality) {
+	q = &SegmentQuality{
+		Min:    math.Inf(1),
+		Ratio:  1,
+		Lambda: a.Lambda,
+		Dia:    a.dia,
+	}
+	lens := make([]float64, len(a.segs))
+	for i, seg := range a.segs {
+		k := a.numSegs(seg)
+		lens[i] = seg.Length() / float64(k)
+		q.Num += k
+		q.Min = min(q.Min, lens[i])
+		q.Max = max(q.Max, lens[i])
+		q.Mean += seg.Length()
+	}
+	if q.Num == 0 {
+		q.Min = 0
+		return
+	}
+	q.Mean /= float64(q.Num)
+
+	// worst ratio of connected wires
+	const eps = 1e-6
+	for i, s1 := range a.segs {
+		for j := i + 1; j < len(a.segs); j++ {
+			s2 := a.segs[j]
+			if s1.start.Sub(s2.start).Length() < eps || s1.start.Sub(s2.end).Length() < eps ||
+				s1.end.Sub(s2.start).Length() < eps || s1.end.Sub(s2.end).Length() < eps {
+				r := max(lens[i], lens[j]) / min(lens[i], lens[j])
+				q.Ratio = max(q.Ratio, r)
+			}
+		}
+	}
+	return
+}
+
+// Warnings returns messages for segment lengths outside the recommended
+// NEC2 bounds.
+func (q *SegmentQuality) Warnings() (msgs []string) {
+	if q.Num == 0 {
+		return
+	}
+	if q.Max > SegMaxLambda*q.Lambda {
+		msgs = append(msgs, fmt.Sprintf("segments too long: %.4fm > %gλ", q.Max, SegMaxLambda))
+	}
+	if q.Min < Cfg.Sim.SegMinLambda*q.Lambda {
+		msgs = append(msgs, fmt.Sprintf("segments too short: %.4fm < %gλ", q.Min, Cfg.Sim.SegMinLambda))
+	}
+	if q.Dia > 0 && q.Min < Cfg.Sim.SegMinWire*q.Dia {
+		msgs = append(msgs, fmt.Sprintf("segments too thick: length %.4fm < %g wire diameters", q.Min, Cfg.Sim.SegMinWire))
+	}
+	if q.Ratio > SegMaxRatio {
+		msgs = append(msgs, fmt.Sprintf("adjacent segment lengths differ by factor %.1f (> %d)", q.Ratio, SegMaxRatio))
+	}
+	return
+}
+
+// String returns a human-readable summary.
+func (q *SegmentQuality) String() string {
+	return fmt.Sprintf("%d segments, length=%.4f/%.4f/%.4fm (min/mean/max), ratio=%.2f",
+		q.Num, q.Min, q.Mean, q.Max, q.Ratio)
+}
@@ -0,0 +1,54 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSegmentQuality(t *testing.T) {
+	// two connected wires of one segment each (at λ=1m): 0.019m and
+	// 0.0025m
+	ant := NewAntenna("test")
+	ant.Lambda = 1
+	ant.dia = 0.0002
+	ant.Add(NewLine(NewVec3(0, 0, 0), NewVec3(0.019, 0, 0)))
+	ant.Add(NewLine(NewVec3(0.019, 0, 0), NewVec3(0.0215, 0, 0)))
+	q := ant.SegmentQuality()
+	if q.Num != 2 || math.Abs(q.Min-0.0025) > 1e-9 || math.Abs(q.Max-0.019) > 1e-9 {
+		t.Fatalf("unexpected quality: %s", q)
+	}
+	if math.Abs(q.Ratio-7.6) > 1e-6 {
+		t.Fatalf("unexpected ratio: %f", q.Ratio)
+	}
+	msgs := q.Warnings()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "adjacent") {
+		t.Fatalf("unexpected warnings: %v", msgs)
+	}
+
+	// unconnected wires don't count as adjacent
+	ant.segs[1] = NewLine(NewVec3(0.3, 0, 0), NewVec3(0.3025, 0, 0))
+	if q = ant.SegmentQuality(); q.Ratio != 1 || len(q.Warnings()) != 0 {
+		t.Fatalf("unexpected quality: %s %v", q, q.Warnings())
+	}
+}
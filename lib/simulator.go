@@ -0,0 +1,139 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Simulator is a NEC2 engine that computes the performance of an antenna.
+// The methods are called in order: Wire (for every wire), GroundComplete,
+// Load (optional), Frequency, Excite (for every feed point), Pattern,
+// Results and Gain.
+type Simulator interface {
+	// Wire adds a straight wire with 'segs' segments (tags start at 1)
+	Wire(tag, segs int, start, end Vec3, radius float64) error
+
+	// GroundComplete ends the geometry and sets the ground parameters
+	GroundComplete(ground Ground) error
+
+	// Load sets the wire loss (at given frequency) for all segments
+	Load(wire Wire, freq int64) error
+
+	// Frequency sets the simulation frequency (in Hz)
+	Frequency(freq int64) error
+
+	// Excite applies a voltage to a segment (index starts at 0)
+	Excite(seg int, volts complex128) error
+
+	// Pattern requests the radiation pattern (angles in degrees)
+	Pattern(nTheta, nPhi int, thetaStep, phiStep float64) error
+
+	// Results returns the gain summary and the input impedance
+	Results() (gain *Gain, z complex128, err error)
+
+	// Gain returns the pattern gain (dBi) at the given pattern indices
+	Gain(theta, phi int) (float64, error)
+
+	// Close releases the simulator resources
+	Close()
+}
+
+// CurrentReader is implemented by simulators that can read back the
+// currents of all wire segments (after Results).
+type CurrentReader interface {
+	Currents() ([]complex128, error)
+}
+
+// FeedReader is implemented by simulators that can read back the input
+// impedances of all feed points (after Results); the impedances are in
+// the order of the Excite calls.
+type FeedReader interface {
+	Impedances() ([]complex128, error)
+}
+
+// NearFieldReader is implemented by simulators that compute the near
+// field: the points are requested (NearField) before the results are
+// read (NearFieldResults).
+type NearFieldReader interface {
+	NearField(points []Vec3) error
+	NearFieldResults() ([]*NearFieldPoint, error)
+}
+
+// NewSimulator returns a new instance of the simulation engine used for
+// antenna evaluation (selected by the configuration).
+var NewSimulator = newSimulator
+
+// constructor of the NEC2 library simulator (nil if not linked)
+var newNecpp func() (Simulator, error)
+
+// newSimulator returns the simulator selected by 'Cfg.Sim.Engine': the
+// NEC2 library ("necpp" or empty) or the name of an external NEC2 program
+// (e.g. "nec2c"). The simulator is wrapped by a cache if enabled
+// ('Cfg.Sim.Cache').
+func newSimulator() (sim Simulator, err error) {
+	var cache *SimCache
+	if cache, err = GetSimCache(); err != nil || cache != nil {
+		if err == nil {
+			sim = NewCachedSimulator(cache, engineSimulator)
+		}
+		return
+	}
+	return engineSimulator()
+}
+
+// engineSimulator returns the simulator for the configured engine
+func engineSimulator() (sim Simulator, err error) {
+	switch Cfg.Sim.Engine {
+	case "", "necpp":
+		if newNecpp == nil {
+			err = errors.New("NEC2 library not available (use an external engine)")
+			return
+		}
+		return newNecpp()
+	}
+	return NewNec2cSimulator(Cfg.Sim.Engine), nil
+}
+
+// LinearPolarization returns true if the configured engine reports the
+// vertical and horizontal gain components (Gain.MaxV, Gain.MaxH). The
+// NEC2 library only provides the circularly polarized components.
+func LinearPolarization() bool {
+	switch Cfg.Sim.Engine {
+	case "", "necpp":
+		return false
+	}
+	return true
+}
+
+// CheckEngine checks that the configured simulation engine is available.
+func CheckEngine() (err error) {
+	switch Cfg.Sim.Engine {
+	case "", "necpp":
+		if newNecpp == nil {
+			err = errors.New("NEC2 library not available (use an external engine)")
+		}
+	default:
+		_, err = exec.LookPath(Cfg.Sim.Engine)
+	}
+	return
+}
@@ -0,0 +1,132 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+//go:build !nonecpp
+
+package lib
+
+import (
+	necpp "github.com/ctdk/go-libnecpp"
+)
+
+func init() {
+	newNecpp = NewNecppSimulator
+}
+
+// NecppSimulator uses the NEC2 library (go-libnecpp)
+type NecppSimulator struct {
+	ctx *necpp.NecppCtx
+}
+
+// NewNecppSimulator allocates a new NEC2 library context
+func NewNecppSimulator() (sim Simulator, err error) {
+	var ctx *necpp.NecppCtx
+	if ctx, err = necpp.New(); err != nil {
+		return
+	}
+	sim = &NecppSimulator{ctx: ctx}
+	return
+}
+
+// Wire adds a straight wire
+func (s *NecppSimulator) Wire(tag, segs int, start, end Vec3, radius float64) error {
+	return s.ctx.Wire(tag, segs, start[0], start[1], start[2], end[0], end[1], end[2], radius, 1, 1)
+}
+
+// GroundComplete ends the geometry and sets the ground parameters
+func (s *NecppSimulator) GroundComplete(ground Ground) (err error) {
+	if err = s.ctx.GeometryComplete(necpp.GeoGroundPlaneFlag(ground.Mode)); err != nil {
+		return
+	}
+	if ground.Mode != 0 {
+		err = s.ctx.GnCard(necpp.GroundTypeFlag(ground.Type), ground.NRadl, ground.Epse, ground.Sig, 0, 0, 0, 0)
+	}
+	return
+}
+
+// Load sets the wire loss as RF resistance at the simulated frequency
+// (skin effect) or as wire conductivity, and the wire inductance.
+func (s *NecppSimulator) Load(wire Wire, freq int64) (err error) {
+	if !IsNull(wire.Conductivity) {
+		if Cfg.Sim.SkinEffect {
+			err = s.ctx.LdCard(2, 0, 0, 0, wire.Resistance(freq), 0, 0)
+		} else {
+			err = s.ctx.LdCard(5, 0, 0, 0, wire.Conductivity, 0, 0)
+		}
+		if err != nil {
+			return
+		}
+	}
+	if !IsNull(wire.Inductance) {
+		err = s.ctx.LdCard(2, 0, 0, 0, 0, wire.Inductance, 0)
+	}
+	return
+}
+
+// Frequency sets the simulation frequency
+func (s *NecppSimulator) Frequency(freq int64) error {
+	return s.ctx.FrCard(necpp.Linear, 1, float64(freq)/1e6, 0)
+}
+
+// Excite applies a voltage to a segment
+func (s *NecppSimulator) Excite(seg int, volts complex128) error {
+	return s.ctx.ExCard(necpp.VoltageApplied, seg+1, 1, 0, real(volts), imag(volts), 0, 0, 0, 0)
+}
+
+// Pattern requests the radiation pattern
+func (s *NecppSimulator) Pattern(nTheta, nPhi int, thetaStep, phiStep float64) error {
+	return s.ctx.RpCard(necpp.Normal, nTheta, nPhi, necpp.MajorMinor, necpp.TotalNormalized,
+		necpp.PowerGain, necpp.NoAvg, 0, 0, thetaStep, phiStep, 0, 0)
+}
+
+// Results returns the gain summary and the input impedance
+func (s *NecppSimulator) Results() (gain *Gain, z complex128, err error) {
+	gain = new(Gain)
+	if gain.Max, err = s.ctx.GainMax(0); err != nil {
+		return
+	}
+	if gain.Mean, err = s.ctx.GainMean(0); err != nil {
+		return
+	}
+	if gain.SD, err = s.ctx.GainSd(0); err != nil {
+		return
+	}
+	// polarized components (circular)
+	if gain.MaxR, err = s.ctx.GainRhcpMax(0); err != nil {
+		return
+	}
+	if gain.MaxL, err = s.ctx.GainLhcpMax(0); err != nil {
+		return
+	}
+	// linear components are not available (no gain)
+	gain.MaxH, gain.MaxV = -999.99, -999.99
+	z, err = s.ctx.Impedance(0)
+	return
+}
+
+// Gain returns the pattern gain at the given pattern indices
+func (s *NecppSimulator) Gain(theta, phi int) (float64, error) {
+	return s.ctx.Gain(0, theta, phi)
+}
+
+// Close releases the NEC2 context
+func (s *NecppSimulator) Close() {
+	s.ctx.Delete()
+}
@@ -0,0 +1,106 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"slices"
+	"testing"
+)
+
+// fakeSim records the calls of the antenna evaluation
+type fakeSim struct {
+	wires  int
+	excite []int
+	rp     [][2]int // requested patterns
+	closed bool
+}
+
+func (s *fakeSim) Wire(tag, segs int, start, end Vec3, radius float64) error {
+	s.wires++
+	return nil
+}
+func (s *fakeSim) GroundComplete(Ground) error { return nil }
+func (s *fakeSim) Load(Wire, int64) error      { return nil }
+func (s *fakeSim) Frequency(int64) error       { return nil }
+func (s *fakeSim) Pattern(nTheta, nPhi int, _, _ float64) error {
+	s.rp = append(s.rp, [2]int{nTheta, nPhi})
+	return nil
+}
+func (s *fakeSim) Excite(seg int, volts complex128) error {
+	s.excite = append(s.excite, seg)
+	return nil
+}
+func (s *fakeSim) Results() (*Gain, complex128, error) {
+	return &Gain{Max: 2.15}, complex(73, 42), nil
+}
+func (s *fakeSim) Gain(theta, phi int) (float64, error) { return 2.15, nil }
+func (s *fakeSim) Close()                               { s.closed = true }
+
+func TestSimulator(t *testing.T) {
+	sim := new(fakeSim)
+	defer func(fn func() (Simulator, error)) { NewSimulator = fn }(NewSimulator)
+	NewSimulator = func() (Simulator, error) { return sim, nil }
+
+	ant := NewAntenna("array")
+	ant.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	ant.Add(NewLine(NewVec3(-0.005, 0.3, 0), NewVec3(0.005, 0.3, 0)))
+	ant.AddExcitation(1, complex(0, 1))
+	if err := ant.Eval(435000000, Wire{Diameter: 0.002}, Ground{}); err != nil {
+		t.Fatal(err)
+	}
+	if sim.wires != 2 || !slices.Equal(sim.excite, []int{0, 1}) || !sim.closed {
+		t.Errorf("unexpected simulation: %+v", sim)
+	}
+	if ant.Perf.Gain.Max != 2.15 || ant.Perf.Z != complex(73, 42) || ant.Perf.Rp == nil || ant.Perf.Rp.Max != 2.15 {
+		t.Errorf("unexpected performance: %s", ant.Perf)
+	}
+}
+
+func TestImpedanceProxy(t *testing.T) {
+	var sims []*fakeSim
+	defer func(fn func() (Simulator, error)) { NewSimulator = fn }(NewSimulator)
+	NewSimulator = func() (Simulator, error) {
+		sims = append(sims, new(fakeSim))
+		return sims[len(sims)-1], nil
+	}
+	ant := NewAntenna("test")
+	ant.Add(NewLine(NewVec3(-0.005, 0, 0), NewVec3(0.005, 0, 0)))
+	full := [2]int{int(180./Cfg.Sim.ThetaStep) + 1, int(360./Cfg.Sim.PhiStep) + 1}
+
+	// rejected: single simulation with a single pattern direction
+	proxy := &Proxy{Accept: func(*Performance) bool { return false }, Impedance: true}
+	ok, err := ant.EvalStaged(435000000, Wire{Diameter: 0.002}, Ground{}, proxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || len(sims) != 1 || !slices.Equal(sims[0].rp, [][2]int{{1, 1}}) || ant.Perf.Rp != nil {
+		t.Fatalf("unexpected evaluation of rejected candidate: %d simulations", len(sims))
+	}
+	// accepted: second simulation with the full pattern
+	sims = nil
+	proxy.Accept = func(p *Performance) bool { return p.Z == complex(73, 42) }
+	if ok, err = ant.EvalStaged(435000000, Wire{Diameter: 0.002}, Ground{}, proxy); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || len(sims) != 2 || !slices.Equal(sims[1].rp, [][2]int{full}) || ant.Perf.Rp == nil {
+		t.Fatalf("unexpected evaluation of accepted candidate: %d simulations", len(sims))
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -31,19 +32,42 @@ import (
 
 // Specification of antenna parameters
 type Specification struct {
-	K      float64 `json:"k"`      // leg in wavelength
-	Wire   Wire    `json:"wire"`   // wire parameters
-	Ground Ground  `json:"ground"` // ground parameters
-	Source Source  `json:"source"` // source parameters
-	Feedpt Feedpt  `json:"feedpt"` // feed point parameters
+	K      float64 `json:"k"`              // leg in wavelength
+	Wire   Wire    `json:"wire"`           // wire parameters
+	Ground Ground  `json:"ground"`         // ground parameters
+	Source Source  `json:"source"`         // source parameters
+	Feedpt Feedpt  `json:"feedpt"`         // feed point parameters
+	Loop   bool    `json:"loop,omitempty"` // closed geometry (loop)
+
+	Elements []*Element `json:"elements,omitempty"` // parasitic elements
 }
 
 // Stats return the optimization statistics
 type Stats struct {
-	NumMthds int
-	NumSteps int
-	NumSims  int
-	Elapsed  time.Duration
+	NumMthds int           `json:"mthds"`
+	NumSteps int           `json:"steps"`
+	NumSims  int           `json:"sims"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Lookups  int           `json:"lookups,omitempty"` // simulation cache lookups
+	Hits     int           `json:"hits,omitempty"`    // simulation cache hits
+}
+
+// Add statistics of another optimization run
+func (s *Stats) Add(o Stats) {
+	s.NumMthds += o.NumMthds
+	s.NumSteps += o.NumSteps
+	s.NumSims += o.NumSims
+	s.Elapsed += o.Elapsed
+	s.Lookups += o.Lookups
+	s.Hits += o.Hits
+}
+
+// HitRate returns the fraction of simulations served from the cache
+func (s *Stats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
 }
 
 //----------------------------------------------------------------------
@@ -100,6 +124,47 @@ type Ground struct {
 	Sig    float64 `json:"sig"`    // conductivity in mhos/meter of the ground in the vicinity of the antenna
 }
 
+// Validate checks the consistency of ground parameters: without a ground
+// plane (mode 0) the type must be free space (-1); with a ground plane
+// (mode 1 or -1) the type must be finite (0, 2) or perfect (1) ground.
+// Finite grounds need ground constants and radial wires are only
+// supported with the reflection coefficient approximation (type 0).
+func (g Ground) Validate() error {
+	switch g.Mode {
+	case 0:
+		if g.Type != -1 {
+			return fmt.Errorf("ground: type %d requires a ground mode (1 or -1)", g.Type)
+		}
+	case 1, -1:
+		switch g.Type {
+		case -1:
+			return fmt.Errorf("ground: mode %d requires a ground type (0, 1 or 2)", g.Mode)
+		case 0, 2:
+			if g.Epse <= 0 || g.Sig < 0 {
+				return fmt.Errorf("ground: type %d requires epse > 0 and sig >= 0", g.Type)
+			}
+		case 1:
+		default:
+			return fmt.Errorf("ground: invalid type %d", g.Type)
+		}
+	default:
+		return fmt.Errorf("ground: invalid mode %d", g.Mode)
+	}
+	if g.NRadl < 0 || (g.NRadl > 0 && g.Type != 0) {
+		return fmt.Errorf("ground: %d radials not supported with type %d", g.NRadl, g.Type)
+	}
+	return nil
+}
+
+// GroundPresets are named ground specifications (NEC2 mode/type and
+// typical ground constants); the height must be specified separately.
+var GroundPresets = map[string]Ground{
+	"free":    {Mode: 0, Type: -1},                      // free space
+	"perfect": {Mode: 1, Type: 1},                       // perfectly conducting ground
+	"average": {Mode: 1, Type: 2, Epse: 13, Sig: 0.005}, // average ground (Sommerfeld/Norton)
+	"poor":    {Mode: 1, Type: 2, Epse: 5, Sig: 0.001},  // poor ground (Sommerfeld/Norton)
+}
+
 // ParseGround converts a ground spec into Ground
 func ParseGround(groundS string, warn bool) (gnd Ground, err error) {
 	gnd = Cfg.Def.Ground
@@ -107,22 +172,36 @@ func ParseGround(groundS string, warn bool) (gnd Ground, err error) {
 		if warn {
 			log.Printf("no ground parameters defined - using defaults.")
 		}
+		err = gnd.Validate()
 		return
 	}
 	var i int64
+	set := make(map[string]bool)
 	for _, p := range strings.Split(groundS, ",") {
 		fp := strings.SplitN(p, "=", 2)
+		set[fp[0]] = true
+		if preset, ok := GroundPresets[fp[0]]; ok && len(fp) == 1 {
+			// named preset (keeps the height)
+			preset.Height = gnd.Height
+			gnd = preset
+			for _, key := range []string{"mode", "type", "epse", "sig", "nradl"} {
+				set[key] = true
+			}
+			continue
+		}
 		switch fp[0] {
 		case "height":
 			if len(fp) != 2 {
-				log.Fatal("ground: missing height value")
+				err = errors.New("ground: missing height value")
+				return
 			}
 			if gnd.Height, err = strconv.ParseFloat(fp[1], 64); err != nil {
 				return
 			}
 		case "mode":
 			if len(fp) != 2 {
-				log.Fatal("ground: missing mode value")
+				err = errors.New("ground: missing mode value")
+				return
 			}
 			if i, err = strconv.ParseInt(fp[1], 10, 64); err != nil {
 				return
@@ -130,7 +209,8 @@ func ParseGround(groundS string, warn bool) (gnd Ground, err error) {
 			gnd.Mode = int(i)
 		case "type":
 			if len(fp) != 2 {
-				log.Fatal("ground: missing type value")
+				err = errors.New("ground: missing type value")
+				return
 			}
 			if i, err = strconv.ParseInt(fp[1], 10, 64); err != nil {
 				return
@@ -138,7 +218,8 @@ func ParseGround(groundS string, warn bool) (gnd Ground, err error) {
 			gnd.Type = int(i)
 		case "nradl":
 			if len(fp) != 2 {
-				log.Fatal("ground: missing nradl value")
+				err = errors.New("ground: missing nradl value")
+				return
 			}
 			if i, err = strconv.ParseInt(fp[1], 10, 64); err != nil {
 				return
@@ -146,14 +227,16 @@ func ParseGround(groundS string, warn bool) (gnd Ground, err error) {
 			gnd.NRadl = int(i)
 		case "epse":
 			if len(fp) != 2 {
-				log.Fatal("ground: missing epse value")
+				err = errors.New("ground: missing epse value")
+				return
 			}
 			if gnd.Epse, err = strconv.ParseFloat(fp[1], 64); err != nil {
 				return
 			}
 		case "sig":
 			if len(fp) != 2 {
-				log.Fatal("ground: missing sig value")
+				err = errors.New("ground: missing sig value")
+				return
 			}
 			if gnd.Sig, err = strconv.ParseFloat(fp[1], 64); err != nil {
 				return
@@ -163,13 +246,24 @@ func ParseGround(groundS string, warn bool) (gnd Ground, err error) {
 			return
 		}
 	}
+	if warn {
+		defs := map[string]any{"mode": gnd.Mode, "type": gnd.Type, "epse": gnd.Epse, "sig": gnd.Sig}
+		for _, key := range []string{"mode", "type", "epse", "sig"} {
+			if !set[key] {
+				log.Printf("ground: no %s defined - using default (%v).", key, defs[key])
+			}
+		}
+	}
 	// sanity check
 	if !IsNull(gnd.Height) && gnd.Mode == 0 {
 		err = errors.New("ground: height set, but no ground mode defined")
+		return
 	}
 	if IsNull(gnd.Height) && gnd.Mode != 0 {
 		err = errors.New("ground: height not set, but ground mode defined")
+		return
 	}
+	err = gnd.Validate()
 	return
 }
 
@@ -194,14 +288,30 @@ func (src Source) Impedance() complex128 {
 	return complex(src.Z.R, src.Z.X)
 }
 
+// Voltage (peak) driving the antenna: the voltage that delivers the source
+// power into a load matching the source resistance (P = V²/2R, as NEC2
+// uses peak values). Falls back to the configured excitation voltage if
+// power or resistance are not set.
+func (src Source) Voltage() float64 {
+	if src.Power <= 0 || src.Z.R <= 0 {
+		return Cfg.Sim.ExciteU
+	}
+	return math.Sqrt(2 * src.Power * src.Z.R)
+}
+
 // Lambda (wavelength) of source frequency
 func (src Source) Lambda() float64 {
 	return C / float64(src.Freq)
 }
 
-// ParseSource converts a source spec into Source
-func ParseSource(sourceS string, warn bool) (src Source, err error) {
+// ParseSource converts a source spec into Source. Component values in the
+// source impedance (see ParseImpedanceAt) are converted at the given
+// frequency (default source frequency if 0).
+func ParseSource(sourceS string, freq int64, warn bool) (src Source, err error) {
 	src = Cfg.Def.Source
+	if freq <= 0 {
+		freq = src.Freq
+	}
 	if len(sourceS) == 0 {
 		if warn {
 			log.Printf("no source parameters defined - using defaults.")
@@ -217,13 +327,14 @@ func ParseSource(sourceS string, warn bool) (src Source, err error) {
 				return
 			}
 			var Z complex128
-			if Z, err = ParseImpedance(fp[1]); err != nil {
+			if Z, err = ParseImpedanceAt(fp[1], float64(freq)); err != nil {
 				return
 			}
 			src.Z.R, src.Z.X = real(Z), imag(Z)
 		case "Pwr":
 			if len(fp) != 2 {
-				log.Fatal("source: missing Power value")
+				err = errors.New("source: missing Power value")
+				return
 			}
 			if src.Power, err = ParseNumber(fp[1]); err != nil {
 				return
@@ -236,8 +347,10 @@ func ParseSource(sourceS string, warn bool) (src Source, err error) {
 //----------------------------------------------------------------------
 
 type Feedpt struct {
-	Gap       float64 `json:"gap"`       // distance between legs at feed point
-	Extension float64 `json:"extension"` // extension of wire away from feedpt
+	Gap       float64 `json:"gap"`                 // distance between legs at feed point
+	Extension float64 `json:"extension"`           // extension of wire away from feedpt
+	HatSpokes int     `json:"hatSpokes,omitempty"` // number of spokes in end hat (0=no hat)
+	HatLength float64 `json:"hatLength,omitempty"` // length of hat spokes (in λ)
 }
 
 // ParseFeedpt converts a feedpoint spec
@@ -262,11 +375,32 @@ func ParseFeedpt(feedptS string, warn bool) (fpt Feedpt, err error) {
 			}
 		case "ext":
 			if len(fp) != 2 {
-				log.Fatal("feedpt: missing extension value")
+				err = errors.New("feedpt: missing extension value")
+				return
 			}
 			if fpt.Extension, err = ParseNumber(fp[1]); err != nil {
 				return
 			}
+		case "hat":
+			// end hat: "<spokes>:<length in λ>"
+			var v []string
+			if len(fp) == 2 {
+				v = strings.SplitN(fp[1], ":", 2)
+			}
+			if len(v) != 2 {
+				err = errors.New("feedpt: hat requires '<spokes>:<length>'")
+				return
+			}
+			if fpt.HatSpokes, err = strconv.Atoi(v[0]); err != nil {
+				return
+			}
+			if fpt.HatLength, err = ParseNumber(v[1]); err != nil {
+				return
+			}
+			if fpt.HatSpokes < 1 || fpt.HatLength <= 0 {
+				err = errors.New("feedpt: invalid hat parameters")
+				return
+			}
 		}
 	}
 	return
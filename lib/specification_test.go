@@ -0,0 +1,119 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroundValidate(t *testing.T) {
+	for i, tc := range []struct {
+		gnd Ground
+		ok  bool
+	}{
+		{Ground{Mode: 0, Type: -1}, true},
+		{Ground{Mode: 0, Type: 1}, false},
+		{Ground{Mode: 0, Type: 2, Epse: 13, Sig: 0.005}, false},
+		{Ground{Mode: 1, Type: -1}, false},
+		{Ground{Mode: -1, Type: -1}, false},
+		{Ground{Mode: 1, Type: 1}, true},
+		{Ground{Mode: -1, Type: 1}, true},
+		{Ground{Mode: 1, Type: 0, Epse: 13, Sig: 0.005}, true},
+		{Ground{Mode: 1, Type: 2, Epse: 13, Sig: 0.005}, true},
+		{Ground{Mode: 1, Type: 2}, false},
+		{Ground{Mode: 1, Type: 0, Epse: 13, Sig: 0.005, NRadl: 16}, true},
+		{Ground{Mode: 1, Type: 2, Epse: 13, Sig: 0.005, NRadl: 16}, false},
+		{Ground{Mode: 1, Type: 3}, false},
+		{Ground{Mode: 2, Type: 1}, false},
+	} {
+		if err := tc.gnd.Validate(); (err == nil) != tc.ok {
+			t.Errorf("#%d (%+v): ok=%v, err=%v", i+1, tc.gnd, tc.ok, err)
+		}
+	}
+}
+
+func TestParseGround(t *testing.T) {
+	if _, err := ParseGround("height=10,mode=1,type=1", false); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseGround("height=10,mode=1", false); err == nil {
+		t.Error("ground mode without type accepted")
+	}
+}
+
+func TestParseMissingValues(t *testing.T) {
+	for _, s := range []string{"height", "mode", "type", "nradl", "epse", "sig"} {
+		if _, err := ParseGround(s, false); err == nil {
+			t.Errorf("ground: missing '%s' value accepted", s)
+		}
+	}
+	if _, err := ParseSource("Pwr", 0, false); err == nil {
+		t.Error("source: missing power value accepted")
+	}
+	if _, err := ParseFeedpt("ext", false); err == nil {
+		t.Error("feedpt: missing extension value accepted")
+	}
+}
+
+func TestParseSourceComponent(t *testing.T) {
+	src, err := ParseSource("Z=50+10pF", 145000000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := -1 / (2 * math.Pi * 145e6 * 10e-12); src.Z.R != 50 || math.Abs(src.Z.X-x) > 1e-9 {
+		t.Errorf("unexpected source impedance %+v", src.Z)
+	}
+}
+
+func TestSourceVoltage(t *testing.T) {
+	src := Source{Z: Impedance{R: 50}, Power: 1}
+	if v := src.Voltage(); v != 10 {
+		t.Errorf("voltage %f, expected 10", v)
+	}
+	src.Power = 0
+	if v := src.Voltage(); v != Cfg.Sim.ExciteU {
+		t.Errorf("voltage %f, expected fallback %f", v, Cfg.Sim.ExciteU)
+	}
+}
+
+func TestGroundPresets(t *testing.T) {
+	gnd, err := ParseGround("average,height=10", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gnd.Mode != 1 || gnd.Type != 2 || gnd.Epse != 13 || gnd.Sig != 0.005 || gnd.Height != 10 {
+		t.Errorf("unexpected ground %+v", gnd)
+	}
+	// explicit values override preset values
+	if gnd, err = ParseGround("height=5,poor,sig=0.002", false); err != nil {
+		t.Fatal(err)
+	}
+	if gnd.Epse != 5 || gnd.Sig != 0.002 || gnd.Height != 5 {
+		t.Errorf("unexpected ground %+v", gnd)
+	}
+	if _, err = ParseGround("free", false); err != nil {
+		t.Error(err)
+	}
+	if _, err = ParseGround("perfect", false); err == nil {
+		t.Error("ground without height accepted")
+	}
+}
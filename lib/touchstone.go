@@ -0,0 +1,70 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+// WriteTouchstone writes the impedance of a one-port (antenna feed point)
+// at the given frequencies as a Touchstone (.s1p) file. The reflection
+// factor S11 is computed relative to the (real) reference impedance z0.
+// Data is written as real/imaginary part (format "RI") or as linear
+// magnitude and angle in degrees (format "MA").
+func WriteTouchstone(wrt io.Writer, cmts []string, format string, z0 float64, freqs []int64, zs []complex128) (err error) {
+	if len(freqs) != len(zs) {
+		return fmt.Errorf("touchstone: %d frequencies for %d impedances", len(freqs), len(zs))
+	}
+	if z0 <= 0 {
+		return fmt.Errorf("touchstone: invalid reference impedance %g", z0)
+	}
+	var val func(s complex128) (float64, float64)
+	switch format {
+	case "RI":
+		val = func(s complex128) (float64, float64) {
+			return real(s), imag(s)
+		}
+	case "MA":
+		val = func(s complex128) (float64, float64) {
+			return cmplx.Abs(s), cmplx.Phase(s) * 180 / math.Pi
+		}
+	default:
+		return fmt.Errorf("touchstone: unknown format '%s'", format)
+	}
+	for _, c := range cmts {
+		if _, err = fmt.Fprintf(wrt, "! %s\n", c); err != nil {
+			return
+		}
+	}
+	if _, err = fmt.Fprintf(wrt, "# Hz S %s R %g\n", format, z0); err != nil {
+		return
+	}
+	for i, f := range freqs {
+		a, b := val(ToReflection(zs[i], complex(z0, 0)))
+		if _, err = fmt.Fprintf(wrt, "%d %.9g %.9g\n", f, a, b); err != nil {
+			return
+		}
+	}
+	return
+}
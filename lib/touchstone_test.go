@@ -0,0 +1,54 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTouchstone(t *testing.T) {
+	freqs := []int64{144000000, 146000000}
+	zs := []complex128{complex(50, 0), complex(150, 0)}
+	buf := new(bytes.Buffer)
+	if err := WriteTouchstone(buf, []string{"test"}, "RI", 50, freqs, zs); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"! test",
+		"# Hz S RI R 50",
+		"144000000 0 0",
+		"146000000 0.5 0",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("line %d: got '%s', want '%s'", i+1, line, want[i])
+		}
+	}
+	if err := WriteTouchstone(buf, nil, "DB", 50, freqs, zs); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -20,16 +20,27 @@
 
 package lib
 
+import "fmt"
+
 const (
 	TRK_MARK   = -1
 	TRK_SHORT  = -2
 	TRK_LENGTH = -3
+	TRK_SCALE  = -4 // scale all segment lengths
 )
 
 type Change struct {
 	Pos   int     `json:"pos"`
 	Theta float64 `json:"theta"`
 	Phi   float64 `json:"phi"`
+	Scale float64 `json:"scale,omitempty"` // scale factor (TRK_SCALE)
+}
+
+// scale lengths of all nodes
+func scaleNodes(nodes []*Node, f float64) {
+	for _, n := range nodes {
+		n.Length *= f
+	}
 }
 
 func Changes(nodes []*Node) []*Change {
@@ -52,6 +63,7 @@ type TrackList struct {
 	Num    int       `json:"num"`
 	Wire   Wire      `json:"wire"`
 	Height float64   `json:"height"`
+	Loop   bool      `json:"loop,omitempty"`
 	Track  []*Change `json:"track"`
 }
 
@@ -60,12 +72,16 @@ func (tl *TrackList) Nodes() []*Node {
 	// build initial geometry
 	nodes := make([]*Node, tl.Num)
 	for i := range nodes {
-		nodes[i] = NewNode(tl.SegL, 0, 0)
+		nodes[i] = NewNode2D(tl.SegL, 0)
 	}
 
 	// iterate over changes
 	for _, chg := range tl.Track {
-		if chg.Pos == -1 {
+		if chg.Pos == TRK_SCALE {
+			scaleNodes(nodes, chg.Scale)
+			continue
+		}
+		if chg.Pos < 0 {
 			continue
 		}
 		// apply change
@@ -75,3 +91,84 @@ func (tl *TrackList) Nodes() []*Node {
 	}
 	return nodes
 }
+
+// Kind of antenna described by the track
+func (tl *TrackList) Kind() string {
+	if tl.Loop {
+		return KindLoop
+	}
+	return "track"
+}
+
+// Replay the optimization track on a canvas. Wire and height of the
+// antenna are taken from the track; if 'eval' is set, each geometry is
+// evaluated at the source frequency (and band edges if a span is set)
+// of the specification. The callback
+// (if defined) is called with the current node list for each rendered
+// geometry. The canvas is closed at the end of the track.
+func (tl *TrackList) Replay(render Canvas, spec *Specification, eval bool, cb func([]*Node)) (err error) {
+	defer render.Close()
+	spec.Wire = tl.Wire
+	spec.Ground.Height = tl.Height
+	spec.Loop = tl.Loop
+	kind := tl.Kind()
+
+	// build initial geometry
+	num := tl.Num
+	nodes := make([]*Node, num)
+	for i := range nodes {
+		nodes[i] = NewNode2D(tl.SegL, 0)
+	}
+
+	// iterate over changes
+	var ant *Antenna
+	init := true
+	step := 0
+	for _, chg := range tl.Track {
+		switch chg.Pos {
+		case TRK_MARK:
+			// marker ends initial geometry build
+			init = false
+
+		case TRK_SHORT:
+			// shorten leg
+			num--
+			nodes = nodes[:num]
+			continue
+
+		case TRK_LENGTH:
+			// lengthen leg
+			num++
+			nodes = append(nodes, NewNode2D(tl.SegL, 0))
+			continue
+
+		case TRK_SCALE:
+			// stretch/shrink legs
+			scaleNodes(nodes, chg.Scale)
+
+		default:
+			// apply change
+			n := nodes[chg.Pos]
+			n.AddAngles(chg.Theta, chg.Phi)
+		}
+
+		// visualize antenna
+		if !init {
+			step++
+			ant = BuildAntenna(kind, spec, nodes)
+			if eval {
+				if err = ant.EvalBand(spec.Source.Freq, spec.Source.Span, spec.Wire, spec.Ground); err != nil {
+					return
+				}
+			}
+			if cb != nil {
+				cb(nodes)
+			}
+			render.Show(ant, chg.Pos, fmt.Sprintf("Step #%d", step))
+		}
+	}
+	if ant != nil {
+		render.Show(ant, -1, "final geometry")
+	}
+	return
+}
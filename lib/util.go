@@ -25,7 +25,11 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
+	"os"
+	"runtime/debug"
 	"strings"
 )
 
@@ -62,6 +66,74 @@ func GetFrequencyRange(s string) (freq, sw int64, err error) {
 	return
 }
 
+// ParseSteps parses a stepped range "<from>:<to>:<step>" (or a single
+// value) into the list of values in the range (including the limits).
+func ParseSteps(s string) (vals []float64, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) == 1 {
+		var v float64
+		if v, err = ParseNumber(parts[0]); err == nil {
+			vals = []float64{v}
+		}
+		return
+	}
+	if len(parts) != 3 {
+		err = fmt.Errorf("invalid steps '%s'", s)
+		return
+	}
+	var from, to, step float64
+	if from, err = ParseNumber(parts[0]); err != nil {
+		return
+	}
+	if to, err = ParseNumber(parts[1]); err != nil {
+		return
+	}
+	if step, err = ParseNumber(parts[2]); err != nil {
+		return
+	}
+	if step <= 0 || to < from {
+		err = fmt.Errorf("invalid steps '%s'", s)
+		return
+	}
+	// compute values from index to avoid accumulating rounding errors
+	// (and drop floating-point noise in the last digits)
+	num := int(math.Round((to-from)/step)) + 1
+	for i := 0; i < num; i++ {
+		v := from + float64(i)*step
+		vals = append(vals, math.Round(v*1e9)/1e9)
+	}
+	return
+}
+
+//----------------------------------------------------------------------
+
+// ReadInput reads the content of a file; the name "-" reads from stdin.
+func ReadInput(fName string) ([]byte, error) {
+	if fName == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(fName)
+}
+
+// CreateOutput creates an output file; the name "-" writes to stdout
+// (closing stdout is a no-op).
+func CreateOutput(fName string) (io.WriteCloser, error) {
+	if fName == "-" {
+		return stdout{os.Stdout}, nil
+	}
+	return os.Create(fName)
+}
+
+// stdout as io.WriteCloser
+type stdout struct {
+	io.Writer
+}
+
+// Close is a no-op on stdout
+func (stdout) Close() error {
+	return nil
+}
+
 //----------------------------------------------------------------------
 
 // Randomizer initialized with seed for deterministic randomization.
@@ -97,3 +169,38 @@ func FormatDuration(v int64) string {
 	}
 	return strings.TrimRight(out, " ")
 }
+
+// BuildVersion returns the version of the running binary (module version
+// or VCS revision if available)
+func BuildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && len(s.Value) > 12 {
+			version += "+" + s.Value[:12]
+		}
+	}
+	return version
+}
+
+// ModuleVersion returns the version of a module the running binary was
+// built with ("unknown" if not available)
+func ModuleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != path {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
@@ -0,0 +1,286 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Columns of the performance table usable in filter expressions (with
+// their canonical name); text columns are marked.
+var whereColumns = map[string]struct {
+	name string
+	text bool
+}{
+	"freq": {"freq", false}, "mat": {"mat", true}, "dia": {"dia", false},
+	"height": {"height", false}, "ground": {"ground", false}, "gtype": {"gType", false},
+	"k": {"k", false}, "param": {"param", false}, "gmax": {"Gmax", false},
+	"gmean": {"Gmean", false}, "sd": {"SD", false}, "zr": {"Zr", false},
+	"zi": {"Zi", false}, "eff": {"eff", false}, "iso": {"iso", false},
+	"bw": {"bw", false}, "ghoriz": {"ghoriz", false}, "q": {"q", false},
+	"gaz": {"gaz", false}, "gel": {"gel", false}, "gsys": {"gsys", false},
+	"mdl": {"mdl", true}, "opt": {"opt", true}, "gen": {"gen", true},
+	"fdir": {"fdir", true}, "ftag": {"ftag", true}, "seed": {"seed", false},
+	"mthds": {"mthds", false}, "steps": {"steps", false}, "sims": {"sims", false},
+	"elapsed": {"elapsed", false}, "sizex": {"sizeX", false}, "sizey": {"sizeY", false},
+	"sizez": {"sizeZ", false}, "wirelen": {"wirelen", false},
+}
+
+// SQL functions usable in filter expressions
+var whereFuncs = map[string]bool{
+	"abs": true, "sqrt": true, "exp": true, "ln": true, "log10": true,
+}
+
+// ParseWhere checks a filter expression and returns it as a SQL clause
+// for the performance table. The expression consists of comparisons
+// ('<', '<=', '>', '>=', '=', '!=' and 'like' for text) of arithmetic
+// terms ('+', '-', '*', '/', parentheses, numbers, quoted text, the
+// functions abs, sqrt, exp, ln and log10 and the columns of the table)
+// combined with 'and', 'or' and 'not'. Example:
+//
+//	Gmax > 5 and abs(Zi) < 10 and mdl = 'bend2d'
+//
+// The clause is assembled from the parsed tokens (identifiers are checked
+// against the table schema, text is re-quoted), so it is safe to use in a
+// database query.
+func ParseWhere(s string) (clause string, err error) {
+	p := &whereParser{src: s}
+	if p.toks, err = whereLexer.scan(s); err != nil {
+		err = p.fail("%s", err)
+		return
+	}
+	var typ int
+	if clause, typ, err = p.or(); err != nil {
+		return
+	}
+	if p.toks[p.pos].kind != tokEnd {
+		err = p.fail("unexpected '%s'", p.toks[p.pos].val)
+	} else if typ != typBool {
+		err = p.fail("not a condition")
+	}
+	return
+}
+
+// expression types
+const (
+	typNum = iota
+	typText
+	typBool
+)
+
+type whereParser struct {
+	src  string
+	toks []token
+	pos  int
+}
+
+// fail returns a parser error
+func (p *whereParser) fail(format string, args ...any) error {
+	return fmt.Errorf("filter '%s': %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// peek returns the current token (if it is an operator or keyword)
+func (p *whereParser) peek() string {
+	t := p.toks[p.pos]
+	switch t.kind {
+	case tokOp:
+		return t.val
+	case tokIdent:
+		return strings.ToLower(t.val)
+	}
+	return ""
+}
+
+// or := and { 'or' and }
+func (p *whereParser) or() (s string, typ int, err error) {
+	return p.logical("or", p.and)
+}
+
+// and := not { 'and' not }
+func (p *whereParser) and() (s string, typ int, err error) {
+	return p.logical("and", p.not)
+}
+
+// combine conditions with a logical operator
+func (p *whereParser) logical(op string, sub func() (string, int, error)) (s string, typ int, err error) {
+	if s, typ, err = sub(); err != nil {
+		return
+	}
+	for p.peek() == op {
+		p.pos++
+		if typ != typBool {
+			return "", 0, p.fail("'%s' needs conditions", op)
+		}
+		var r string
+		var rt int
+		if r, rt, err = sub(); err != nil {
+			return
+		}
+		if rt != typBool {
+			return "", 0, p.fail("'%s' needs conditions", op)
+		}
+		s += " " + op + " " + r
+	}
+	return
+}
+
+// not := 'not' not | cmp
+func (p *whereParser) not() (s string, typ int, err error) {
+	if p.peek() == "not" {
+		p.pos++
+		if s, typ, err = p.not(); err != nil {
+			return
+		}
+		if typ != typBool {
+			return "", 0, p.fail("'not' needs a condition")
+		}
+		return "not " + s, typBool, nil
+	}
+	return p.cmp()
+}
+
+// cmp := sum [ relop sum ]
+func (p *whereParser) cmp() (s string, typ int, err error) {
+	if s, typ, err = p.sum(); err != nil {
+		return
+	}
+	op := p.peek()
+	switch op {
+	case "<", "<=", ">", ">=", "=", "!=", "<>", "like":
+	default:
+		return
+	}
+	p.pos++
+	var r string
+	var rt int
+	if r, rt, err = p.sum(); err != nil {
+		return
+	}
+	if typ == typBool || rt != typ {
+		return "", 0, p.fail("invalid comparison '%s'", op)
+	}
+	if op == "like" && typ != typText {
+		return "", 0, p.fail("'like' needs text")
+	}
+	return s + " " + op + " " + r, typBool, nil
+}
+
+// sum := term { ('+'|'-') term }
+func (p *whereParser) sum() (s string, typ int, err error) {
+	return p.arith("+-", p.term)
+}
+
+// term := unary { ('*'|'/') unary }
+func (p *whereParser) term() (s string, typ int, err error) {
+	return p.arith("*/", p.unary)
+}
+
+// combine numbers with arithmetic operators
+func (p *whereParser) arith(ops string, sub func() (string, int, error)) (s string, typ int, err error) {
+	if s, typ, err = sub(); err != nil {
+		return
+	}
+	for op := p.peek(); len(op) == 1 && strings.Contains(ops, op); op = p.peek() {
+		p.pos++
+		var r string
+		var rt int
+		if r, rt, err = sub(); err != nil {
+			return
+		}
+		if typ != typNum || rt != typNum {
+			return "", 0, p.fail("'%s' needs numbers", op)
+		}
+		s += op + r
+	}
+	return
+}
+
+// unary := '-' unary | primary
+func (p *whereParser) unary() (s string, typ int, err error) {
+	if p.peek() == "-" {
+		p.pos++
+		if s, typ, err = p.unary(); err != nil {
+			return
+		}
+		if typ != typNum {
+			return "", 0, p.fail("'-' needs a number")
+		}
+		return "-" + s, typNum, nil
+	}
+	return p.primary()
+}
+
+// primary := number | text | column | func '(' sum ')' | '(' or ')'
+func (p *whereParser) primary() (s string, typ int, err error) {
+	t := p.toks[p.pos]
+	if t.kind == tokEnd {
+		return "", 0, p.fail("unexpected end")
+	}
+	p.pos++
+	switch t.kind {
+	case tokNum:
+		return t.val, typNum, nil
+	case tokText:
+		return "'" + strings.ReplaceAll(t.val, "'", "''") + "'", typText, nil
+	case tokIdent:
+		name := strings.ToLower(t.val)
+		if p.peek() == "(" {
+			if !whereFuncs[name] {
+				return "", 0, p.fail("unknown function '%s'", t.val)
+			}
+			if s, typ, err = p.group(); err != nil {
+				return
+			}
+			if typ != typNum {
+				return "", 0, p.fail("'%s' needs a number", t.val)
+			}
+			return name + s, typNum, nil
+		}
+		col, ok := whereColumns[name]
+		if !ok {
+			return "", 0, p.fail("unknown column '%s'", t.val)
+		}
+		typ = typNum
+		if col.text {
+			typ = typText
+		}
+		return col.name, typ, nil
+	}
+	if t.val == "(" {
+		p.pos--
+		return p.group()
+	}
+	return "", 0, p.fail("unexpected '%s'", t.val)
+}
+
+// group := '(' or ')'
+func (p *whereParser) group() (s string, typ int, err error) {
+	p.pos++
+	if s, typ, err = p.or(); err != nil {
+		return
+	}
+	if p.peek() != ")" {
+		return "", 0, p.fail("missing ')'")
+	}
+	p.pos++
+	return "(" + s + ")", typ, nil
+}
@@ -0,0 +1,115 @@
+//----------------------------------------------------------------------
+// This file is part of antgen.
+// Copyright (C) 2024-present Bernd Fix >Y<,  DO3YQ
+//
+// antgen is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// antgen is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL3.0-or-later
+//----------------------------------------------------------------------
+
+package lib
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseWhere(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{"Gmax>5", "Gmax > 5"},
+		{"gmax > 5 and abs(zi)<10", "Gmax > 5 and abs(Zi) < 10"},
+		{"not (Zr >= 40 or Zr<=-1e2) AND mdl = 'bend2d'", "not (Zr >= 40 or Zr <= -1e2) and mdl = 'bend2d'"},
+		{"Gmax+10*log10(Zr/sqrt(Zr*Zr+Zi*Zi)) != 3", "Gmax+10*log10(Zr/sqrt(Zr*Zr+Zi*Zi)) != 3"},
+		{"fdir like '2m/%' and ftag <> 'it''s'", "fdir like '2m/%' and ftag <> 'it''s'"},
+		{"wirelen < 1.2 and sizex*sizey<0.25", "wirelen < 1.2 and sizeX*sizeY < 0.25"},
+	} {
+		out, err := ParseWhere(tc.in)
+		if err != nil {
+			t.Errorf("%s: %s", tc.in, err)
+			continue
+		}
+		if out != tc.out {
+			t.Errorf("%s: got '%s', want '%s'", tc.in, out, tc.out)
+		}
+	}
+	for _, in := range []string{
+		"",
+		"Gmax",
+		"wirelength < 1.2",
+		"Gmax > 5; drop table performance",
+		"Gmax > 5 -- comment",
+		"mdl = 'bend2d",
+		"Gmax > 'x'",
+		"(Gmax > 5",
+		"Gmax > 5 and 3",
+		"Zr like 'x'",
+		"load_extension('x') > 0",
+		"Gmax > 5 Zr < 3",
+		"Gmax = \"x\"",
+	} {
+		if out, err := ParseWhere(in); err == nil {
+			t.Errorf("%s: accepted as '%s'", in, out)
+		}
+	}
+}
+
+func TestWhereColumns(t *testing.T) {
+	// all columns must exist in the database schema
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, col := range whereColumns {
+		if _, err = db.GetRows(col.name+" is null", ""); err != nil {
+			t.Errorf("column %s: %s", col.name, err)
+		}
+	}
+}
+
+func TestZRangePresets(t *testing.T) {
+	defer func(z map[string]string) { Cfg.ZRanges = z }(maps.Clone(Cfg.ZRanges))
+
+	// custom presets are merged with the built-in presets
+	fname := filepath.Join(t.TempDir(), "cfg.json")
+	data := `{"zRanges":{"matched":"Zr > 400 and Zr < 500","ladder":"Zr > 300"}}`
+	if err := os.WriteFile(fname, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadConfig(fname); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"resonant": "abs(Zi) < 1",
+		"matched":  "Zr > 400 and Zr < 500",
+		"ladder":   "Zr > 300",
+	} {
+		if got := Cfg.ZRanges[name]; got != want {
+			t.Errorf("%s: got '%s', want '%s'", name, got, want)
+		}
+	}
+	// all presets are valid filter expressions
+	for name, preset := range Cfg.ZRanges {
+		if len(preset) == 0 {
+			continue
+		}
+		if _, err := ParseWhere(preset); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+}